@@ -0,0 +1,51 @@
+package average
+
+import "errors"
+
+// WithBucketLimit sets a limit on the value of a bucket, for producers that
+// use the window as an admission-control signal, like at most 100 requests
+// per second. TryAdd reports whether the current bucket is within the limit.
+// If saturate is true, TryAdd rejects any value that would take the current
+// bucket beyond the limit, so the value of a bucket never exceeds it. The
+// limit applies to the value that the bucket stores, so with other
+// aggregations than AggregateSum, and for gauges, it limits the smallest, the
+// largest or the last value rather than the sum. The limit doesn't affect Add.
+func WithBucketLimit(limit float64, saturate bool) Option {
+	return func(sw *SlidingWindow) error {
+		if !(limit > 0) {
+			return errors.New("bucket limit has to be positive")
+		}
+
+		sw.limit = limit
+		sw.saturateLimit = saturate
+		return nil
+	}
+}
+
+// TryAdd increments the value of the current sample, like Add, and reports
+// whether the current bucket is still within the limit that was set with
+// WithBucketLimit. If the window was created to saturate at the limit, v is
+// only added if the bucket stays within the limit. TryAdd always returns true
+// for windows without a limit, unless the window is stopped.
+func (sw *SlidingWindow) TryAdd(v float64) bool {
+	sw.Lock()
+	defer sw.Unlock()
+
+	if sw.stopped {
+		sw.dropped++
+		return false
+	}
+	if sw.limit == 0 {
+		sw.add(v, 1)
+		return true
+	}
+
+	ok := sw.folded(sw.pos, v) <= sw.limit
+	if ok || !sw.saturateLimit {
+		sw.add(v, 1)
+	} else {
+		sw.dropped++
+	}
+
+	return ok
+}
@@ -0,0 +1,92 @@
+package average
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithBucketLimit(t *testing.T) {
+	_, err := New(4*time.Second, time.Second, WithBucketLimit(0, false))
+	assert.EqualError(t, err, "bucket limit has to be positive")
+
+	_, err = New(4*time.Second, time.Second, WithBucketLimit(math.NaN(), false))
+	assert.EqualError(t, err, "bucket limit has to be positive")
+}
+
+func TestTryAdd(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithBucketLimit(3, false))
+	defer sw.Stop()
+
+	assert.True(t, sw.TryAdd(2))
+	assert.True(t, sw.TryAdd(1))
+	assert.False(t, sw.TryAdd(1))
+
+	// Without saturation, values beyond the limit are still added.
+	total, count := sw.Total(time.Second)
+	assert.Equal(t, 4.0, total)
+	assert.Equal(t, int64(3), count)
+
+	// The next bucket starts within the limit again.
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+	assert.True(t, sw.TryAdd(3))
+}
+
+func TestTryAddSaturate(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithBucketLimit(3, true))
+	defer sw.Stop()
+
+	assert.True(t, sw.TryAdd(2))
+	assert.False(t, sw.TryAdd(2))
+	assert.True(t, sw.TryAdd(1))
+	assert.False(t, sw.TryAdd(0.5))
+
+	total, count := sw.Total(time.Second)
+	assert.Equal(t, 3.0, total)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, uint64(2), sw.Debug().Dropped)
+}
+
+func TestTryAddWithoutLimit(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+
+	assert.True(t, sw.TryAdd(1e300))
+	assert.True(t, sw.TryAdd(1e300))
+
+	sw.Stop()
+	assert.False(t, sw.TryAdd(1))
+}
+
+func TestTryAddAggregation(t *testing.T) {
+	tests := []struct {
+		name  string
+		opt   Option
+		adds  []float64
+		oks   []bool
+		value float64
+	}{
+		{"min", WithAggregation(AggregateMin), []float64{5, 2, 5}, []bool{false, true, true}, 2},
+		{"max", WithAggregation(AggregateMax), []float64{2, 4, 3}, []bool{true, false, true}, 3},
+		{"last", WithAggregation(AggregateLast), []float64{4, 2, 3}, []bool{false, true, true}, 3},
+		{"gauge", WithGauge(false), []float64{4, 2, 3}, []bool{false, true, true}, 3},
+	}
+
+	// The limit applies to the value that the aggregation stores, not to the
+	// sum of the values that were added.
+	for _, test := range tests {
+		sw := MustNew(4*time.Second, time.Second, test.opt, WithBucketLimit(3, true))
+
+		for i, v := range test.adds {
+			assert.Equal(t, test.oks[i], sw.TryAdd(v), test.name)
+		}
+
+		sw.RLock()
+		assert.Equal(t, test.value, sw.sum(sw.pos), test.name)
+		sw.RUnlock()
+		sw.Stop()
+	}
+}
@@ -0,0 +1,148 @@
+package average
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Aggregation defines how values that are added to the same bucket are folded
+// into it.
+type Aggregation int
+
+const (
+	// AggregateSum adds up the values in a bucket. This is the default.
+	AggregateSum Aggregation = iota
+	// AggregateMin keeps the smallest value in a bucket.
+	AggregateMin
+	// AggregateMax keeps the largest value in a bucket.
+	AggregateMax
+	// AggregateLast keeps the last value in a bucket.
+	AggregateLast
+)
+
+// String returns the name of the aggregation.
+func (a Aggregation) String() string {
+	switch a {
+	case AggregateSum:
+		return "sum"
+	case AggregateMin:
+		return "min"
+	case AggregateMax:
+		return "max"
+	case AggregateLast:
+		return "last"
+	default:
+		return "unknown"
+	}
+}
+
+// WithAggregation sets how Add folds values into the current bucket. With
+// anything but AggregateSum, every bucket stores a single value, like the
+// largest number of concurrent connections during that bucket. Average then
+// returns the mean of the buckets that have a value, and Aggregate folds the
+// buckets of a window with the same aggregation. Sample counts still count
+// every value that was added.
+func WithAggregation(a Aggregation) Option {
+	return func(sw *SlidingWindow) error {
+		switch a {
+		case AggregateSum:
+			sw.valid = nil
+		case AggregateMin, AggregateMax, AggregateLast:
+			sw.valid = make([]bool, sw.len())
+		default:
+			return errors.New("unknown aggregation")
+		}
+
+		sw.aggregation = a
+		return nil
+	}
+}
+
+// fold folds the mean v/n into the current bucket. It must be called with the
+// lock held.
+func (sw *SlidingWindow) fold(v float64, n int64) {
+	if n > 1 {
+		v /= float64(n)
+	}
+
+	pos := sw.pos
+	sw.set(pos, sw.folded(pos, v), sw.count(pos)+n)
+	sw.valid[pos] = true
+}
+
+// folded returns the value that the bucket at the specified position holds
+// once v is folded into it. It must be called with the lock held.
+func (sw *SlidingWindow) folded(pos int, v float64) float64 {
+	if sw.valid == nil {
+		return sw.sum(pos) + v
+	}
+	if !sw.valid[pos] {
+		return v
+	}
+
+	switch sw.aggregation {
+	case AggregateMin:
+		return math.Min(v, sw.sum(pos))
+	case AggregateMax:
+		return math.Max(v, sw.sum(pos))
+	default:
+		return v
+	}
+}
+
+// Aggregate folds the buckets of the specified window with the aggregation of
+// this window: the sum of the values, the smallest or the largest bucket, or
+// the most recent bucket with a value. It returns 0 if no bucket in the window
+// has a value.
+func (sw *SlidingWindow) Aggregate(window time.Duration) float64 {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	if sw.valid == nil {
+		total, _, _ := sw.total(window)
+		return total
+	}
+
+	var v float64
+	var found bool
+	for i, buckets := 0, sw.buckets(window); i < buckets; i++ {
+		pos := sw.index(i)
+		if !sw.valid[pos] || sw.open(i) {
+			continue
+		}
+
+		switch b := sw.sum(pos); {
+		case !found:
+			v, found = b, true
+		case sw.aggregation == AggregateMin:
+			v = math.Min(v, b)
+		case sw.aggregation == AggregateMax:
+			v = math.Max(v, b)
+		}
+		if sw.aggregation == AggregateLast {
+			break
+		}
+	}
+
+	return v
+}
+
+// valueAverage returns the mean of the buckets that have a value, and whether
+// there are any. It must be called with the lock held.
+func (sw *SlidingWindow) valueAverage(window time.Duration) (float64, bool) {
+	var total float64
+	var n int
+	for i, buckets := 0, sw.buckets(window); i < buckets; i++ {
+		if pos := sw.index(i); sw.valid[pos] && !sw.open(i) {
+			total += sw.sum(pos)
+			n++
+		}
+	}
+
+	if n == 0 {
+		return 0, false
+	}
+
+	return total / float64(n), true
+}
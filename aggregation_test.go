@@ -0,0 +1,60 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAggregation(t *testing.T) {
+	tests := []struct {
+		aggregation Aggregation
+		average     float64
+		aggregate   float64
+	}{
+		{AggregateSum, 4, 32},
+		{AggregateMin, 2, 1},
+		{AggregateMax, 7.5, 9},
+		{AggregateLast, 6, 3},
+	}
+
+	for _, test := range tests {
+		sw := MustNew(4*time.Second, time.Second, WithAggregation(test.aggregation))
+
+		sw.Add(4)
+		sw.Add(1)
+		sw.Add(9)
+		sw.Lock()
+		sw.shift()
+		sw.shift()
+		sw.Unlock()
+		sw.Add(3)
+		sw.Add(6)
+		sw.AddN(9, 3)
+
+		assert.Equal(t, test.average, sw.Average(4*time.Second), test.aggregation.String())
+		assert.Equal(t, test.aggregate, sw.Aggregate(4*time.Second), test.aggregation.String())
+
+		_, count := sw.Total(4 * time.Second)
+		assert.Equal(t, int64(8), count, test.aggregation.String())
+		sw.Stop()
+	}
+}
+
+func TestWithAggregationEmpty(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithAggregation(AggregateMin))
+	defer sw.Stop()
+
+	assert.Equal(t, 0.0, sw.Aggregate(4*time.Second))
+	assert.Equal(t, 0.0, sw.Average(4*time.Second))
+
+	sw.Add(-2)
+	assert.Equal(t, -2.0, sw.Aggregate(4*time.Second))
+}
+
+func TestWithAggregationUnknown(t *testing.T) {
+	_, err := New(4*time.Second, time.Second, WithAggregation(Aggregation(42)))
+	assert.EqualError(t, err, "unknown aggregation")
+	assert.Equal(t, "unknown", Aggregation(42).String())
+}
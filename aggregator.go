@@ -0,0 +1,121 @@
+package average
+
+import (
+	"errors"
+	"time"
+)
+
+// Aggregator is the state of a single bucket of an AggregateWindow. It allows
+// custom per-bucket state, like sketches, bitsets or centroids, to reuse the
+// rotation and locking of a sliding time window.
+type Aggregator interface {
+	// Add records v.
+	Add(v float64)
+	// Merge folds other, which is always created by the same factory as the
+	// receiver, into the receiver.
+	Merge(other Aggregator)
+	// Reset returns the aggregator to its initial state.
+	Reset()
+	// Value returns the result of the aggregation.
+	Value() float64
+}
+
+// AggregateWindow is a sliding time window that keeps a user-defined
+// Aggregator per bucket.
+type AggregateWindow struct {
+	sw          *SlidingWindow
+	aggregators []Aggregator
+	factory     func() Aggregator
+}
+
+// MustNewAggregateWindow returns a new AggregateWindow, but panics if an error
+// occurs.
+func MustNewAggregateWindow(window, granularity time.Duration, factory func() Aggregator) *AggregateWindow {
+	aw, err := NewAggregateWindow(window, granularity, factory)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return aw
+}
+
+// NewAggregateWindow returns a new AggregateWindow that uses factory to create
+// the aggregators of its buckets, as well as those used to merge buckets.
+func NewAggregateWindow(window, granularity time.Duration, factory func() Aggregator) (*AggregateWindow, error) {
+	if factory == nil {
+		return nil, errors.New("aggregator factory cannot be nil")
+	}
+
+	sw, err := newSlidingWindow(window, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	aw := &AggregateWindow{
+		sw:          sw,
+		aggregators: make([]Aggregator, sw.len()),
+		factory:     factory,
+	}
+	for i := range aw.aggregators {
+		aw.aggregators[i] = factory()
+	}
+	sw.onClear = aw.clear
+
+	sw.startShifter()
+	return aw, nil
+}
+
+func (aw *AggregateWindow) clear(pos int) {
+	aw.aggregators[pos].Reset()
+}
+
+// Add records v in the aggregator of the current bucket.
+func (aw *AggregateWindow) Add(v float64) {
+	aw.sw.Lock()
+	defer aw.sw.Unlock()
+
+	if !aw.sw.countAdd() {
+		return
+	}
+
+	aw.aggregators[aw.sw.pos].Add(v)
+	aw.sw.increment(aw.sw.pos, 0, 1)
+}
+
+// Aggregate returns a new aggregator into which the aggregators of all buckets
+// over the specified window are merged. This can be type asserted to access
+// results beyond Value.
+func (aw *AggregateWindow) Aggregate(window time.Duration) Aggregator {
+	merged := aw.factory()
+
+	aw.sw.RLock()
+	defer aw.sw.RUnlock()
+
+	for i, n := 0, aw.sw.buckets(window); i < n; i++ {
+		merged.Merge(aw.aggregators[aw.sw.index(i)])
+	}
+
+	return merged
+}
+
+// Value returns the value of the merged aggregators over the specified window.
+func (aw *AggregateWindow) Value(window time.Duration) float64 {
+	return aw.Aggregate(window).Value()
+}
+
+// Count returns the number of values added over the specified window.
+func (aw *AggregateWindow) Count(window time.Duration) int64 {
+	_, count := aw.sw.Total(window)
+	return count
+}
+
+// Debug returns the DebugStats of this aggregate window.
+func (aw *AggregateWindow) Debug() DebugStats {
+	return aw.sw.Debug()
+}
+
+// Stop the shifter of this aggregate window. A stopped AggregateWindow cannot
+// be started again.
+func (aw *AggregateWindow) Stop() {
+	aw.sw.Stop()
+}
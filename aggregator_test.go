@@ -0,0 +1,77 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rangeAggregator keeps track of the spread between the smallest and largest
+// value.
+type rangeAggregator struct {
+	min, max float64
+	empty    bool
+}
+
+func newRangeAggregator() Aggregator {
+	return &rangeAggregator{empty: true}
+}
+
+func (a *rangeAggregator) Add(v float64) {
+	if a.empty || v < a.min {
+		a.min = v
+	}
+	if a.empty || v > a.max {
+		a.max = v
+	}
+	a.empty = false
+}
+
+func (a *rangeAggregator) Merge(other Aggregator) {
+	if o := other.(*rangeAggregator); !o.empty {
+		a.Add(o.min)
+		a.Add(o.max)
+	}
+}
+
+func (a *rangeAggregator) Reset() {
+	*a = rangeAggregator{empty: true}
+}
+
+func (a *rangeAggregator) Value() float64 {
+	return a.max - a.min
+}
+
+func TestNewAggregateWindow(t *testing.T) {
+	_, err := NewAggregateWindow(10*time.Second, time.Second, nil)
+	assert.EqualError(t, err, "aggregator factory cannot be nil")
+}
+
+func TestAggregateWindow(t *testing.T) {
+	aw := MustNewAggregateWindow(3*time.Second, time.Second, newRangeAggregator)
+	defer aw.Stop()
+
+	assert.Equal(t, 0.0, aw.Value(3*time.Second))
+
+	aw.Add(10)
+	aw.Add(4)
+
+	aw.sw.Lock()
+	aw.sw.shift()
+	aw.sw.Unlock()
+	aw.Add(12)
+
+	assert.Equal(t, 0.0, aw.Value(time.Second))
+	assert.Equal(t, 8.0, aw.Value(3*time.Second))
+	assert.Equal(t, int64(3), aw.Count(3*time.Second))
+	assert.Equal(t, 4.0, aw.Aggregate(3*time.Second).(*rangeAggregator).min)
+
+	aw.sw.Lock()
+	aw.sw.shift()
+	aw.sw.shift()
+	aw.sw.Unlock()
+
+	assert.Equal(t, 0.0, aw.Value(3*time.Second))
+	assert.Equal(t, int64(1), aw.Count(3*time.Second))
+}
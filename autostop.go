@@ -0,0 +1,16 @@
+package average
+
+// WithAutoStop stops the shifter of a window once the window is no longer
+// reachable, so that windows whose owner forgets to call Stop don't leak a
+// goroutine and a ticker. The shifter only holds a weak reference to the
+// window, and a cleanup function stops it after the window is garbage
+// collected. Calling Stop explicitly remains the preferred way to release a
+// window, as garbage collection may take a while to notice it.
+//
+// This option requires Go 1.24 or later, and has no effect on older versions.
+func WithAutoStop() Option {
+	return func(sw *SlidingWindow) error {
+		sw.autoStop = true
+		return nil
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !go1.24
+
+package average
+
+import "time"
+
+// startWeakShifter starts a regular shifter, as weak references require Go
+// 1.24.
+func startWeakShifter(sw *SlidingWindow, phase time.Duration) {
+	go sw.shifter(phase)
+}
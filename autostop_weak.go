@@ -0,0 +1,66 @@
+//go:build go1.24
+
+package average
+
+import (
+	"runtime"
+	"time"
+	"weak"
+)
+
+// startWeakShifter starts a shifter that doesn't keep sw reachable.
+func startWeakShifter(sw *SlidingWindow, phase time.Duration) {
+	ref := weak.Make(sw)
+	granularity, stopC := sw.granularity, sw.stopC
+
+	// Once sw is unreachable, nobody can call Stop anymore, so the stop
+	// channel can be closed to release the shifter right away. Stop cancels
+	// the cleanup, as it runs outside of any testing/synctest bubble, which
+	// must not touch the channels of a window that was created in one.
+	cleanup := runtime.AddCleanup(sw, func(c chan struct{}) { close(c) }, stopC)
+	sw.stopCleanup = cleanup.Stop
+
+	go func() {
+		if !waitPhase(phase, stopC) {
+			if sw := ref.Value(); sw != nil {
+				sw.halt()
+			}
+			return
+		}
+		if phase > 0 && !shiftWeak(ref) {
+			return
+		}
+
+		ticker := time.NewTicker(granularity)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if !shiftWeak(ref) {
+					return
+				}
+
+			case <-stopC:
+				if sw := ref.Value(); sw != nil {
+					sw.halt()
+				}
+				return
+			}
+		}
+	}()
+}
+
+// shiftWeak rotates the buckets of the window that ref points to. It returns
+// false if the window is no longer reachable.
+func shiftWeak(ref weak.Pointer[SlidingWindow]) bool {
+	sw := ref.Value()
+	if sw == nil {
+		return false
+	}
+
+	sw.Lock()
+	sw.rotate()
+	sw.unlockAndEvict()
+	return true
+}
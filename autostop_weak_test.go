@@ -0,0 +1,41 @@
+//go:build go1.24
+
+package average
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAutoStop(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	func() {
+		sw := MustNew(10*time.Second, 10*time.Millisecond, WithAutoStop())
+		sw.Add(1)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.True(t, runtime.NumGoroutine() <= before)
+}
+
+func TestWithAutoStopStop(t *testing.T) {
+	sw := MustNew(10*time.Second, 10*time.Millisecond, WithAutoStop())
+	c := sw.Subscribe(1, DropNewest)
+
+	sw.Add(1)
+	time.Sleep(30 * time.Millisecond)
+	sw.Stop()
+
+	for range c {
+	}
+	assert.True(t, sw.stopped)
+}
@@ -0,0 +1,152 @@
+package average
+
+import "time"
+
+// Availability is a sliding time window that tracks whether a service was
+// available. It measures availability in two ways: Uptime returns the
+// percentage of successful checks that were added with Add, while TimeUptime
+// returns the percentage of time that the service was up according to the
+// state that is set with Set, which suits heartbeat-style checks that only
+// report changes.
+type Availability struct {
+	sw     *SlidingWindow // Sums the successful checks.
+	upTime []time.Duration
+	known  []time.Duration // The time for which the state was known.
+	isSet  bool
+	up     bool
+	since  time.Time // The time up to which the state has been accounted for.
+}
+
+// MustNewAvailability returns a new Availability, but panics if an error
+// occurs.
+func MustNewAvailability(window, granularity time.Duration) *Availability {
+	a, err := NewAvailability(window, granularity)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return a
+}
+
+// NewAvailability returns a new Availability.
+func NewAvailability(window, granularity time.Duration) (*Availability, error) {
+	sw, err := newSlidingWindow(window, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	a := newAvailability(sw)
+	sw.startShifter()
+	return a, nil
+}
+
+// newAvailability returns an Availability that tracks its data in sw.
+func newAvailability(sw *SlidingWindow) *Availability {
+	a := &Availability{
+		sw:     sw,
+		upTime: make([]time.Duration, sw.len()),
+		known:  make([]time.Duration, sw.len()),
+	}
+	sw.onClear = a.clear
+
+	return a
+}
+
+func (a *Availability) clear(pos int) {
+	a.upTime[pos], a.known[pos] = 0, 0
+}
+
+// Add records the result of a check.
+func (a *Availability) Add(ok bool) {
+	if ok {
+		a.sw.Add(1)
+	} else {
+		a.sw.Add(0)
+	}
+}
+
+// Set records that the service is up or down from now on, until the next call
+// to Set. The time before the first call to Set doesn't count towards
+// TimeUptime.
+func (a *Availability) Set(up bool) {
+	a.sw.Lock()
+	defer a.sw.Unlock()
+
+	if a.sw.stopped {
+		return
+	}
+
+	a.account(a.sw.now())
+	a.isSet, a.up = true, up
+}
+
+// Uptime returns the percentage of successful checks over the specified
+// window, or 0 if no checks were added.
+func (a *Availability) Uptime(window time.Duration) float64 {
+	return a.sw.Average(window) * 100
+}
+
+// TimeUptime returns the percentage of time over the specified window during
+// which the service was up, out of the time during which its state was known,
+// or 0 if its state was never set.
+func (a *Availability) TimeUptime(window time.Duration) float64 {
+	a.sw.Lock()
+	defer a.sw.Unlock()
+
+	if !a.sw.stopped {
+		a.account(a.sw.now())
+	}
+
+	var up, known time.Duration
+	for i, n := 0, a.sw.buckets(window); i < n; i++ {
+		pos := a.sw.index(i)
+		up += a.upTime[pos]
+		known += a.known[pos]
+	}
+
+	if known == 0 {
+		return 0
+	}
+
+	return float64(up) / float64(known) * 100
+}
+
+// account attributes the time since the state was last accounted for up to
+// now to the buckets that it overlaps. It must be called with the lock held.
+func (a *Availability) account(now time.Time) {
+	since := a.since
+	a.since = now
+	if !a.isSet {
+		return
+	}
+
+	end := now
+	for age := 0; age < a.sw.size && end.After(since); age++ {
+		start := a.sw.start.Add(-time.Duration(age) * a.sw.granularity)
+		from := start
+		if since.After(start) {
+			from = since
+		}
+
+		if d := end.Sub(from); d > 0 {
+			pos := a.sw.index(age)
+			a.known[pos] += d
+			if a.up {
+				a.upTime[pos] += d
+			}
+		}
+
+		end = start
+	}
+}
+
+// Debug returns the DebugStats of this availability window.
+func (a *Availability) Debug() DebugStats {
+	return a.sw.Debug()
+}
+
+// Stop the shifter of this availability window. A stopped Availability cannot
+// be started again.
+func (a *Availability) Stop() {
+	a.sw.Stop()
+}
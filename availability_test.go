@@ -0,0 +1,52 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAvailability(t *testing.T) {
+	_, err := NewAvailability(0, time.Second)
+	assert.EqualError(t, err, "window cannot be 0")
+}
+
+func TestAvailabilityUptime(t *testing.T) {
+	a := MustNewAvailability(4*time.Second, time.Second)
+	defer a.Stop()
+
+	assert.Equal(t, 0.0, a.Uptime(4*time.Second))
+
+	a.Add(true)
+	a.Add(true)
+	a.Add(true)
+	a.Add(false)
+	assert.Equal(t, 75.0, a.Uptime(4*time.Second))
+}
+
+func TestAvailabilityTimeUptime(t *testing.T) {
+	sw, err := newSlidingWindow(4*time.Second, time.Second, WithManualClock())
+	assert.NoError(t, err)
+	defer sw.Stop()
+
+	a := newAvailability(sw)
+	assert.Equal(t, 0.0, a.TimeUptime(4*time.Second))
+
+	a.Set(true)
+	sw.Advance(1500 * time.Millisecond)
+	a.Set(false)
+	sw.Advance(time.Second)
+
+	assert.Equal(t, 60.0, a.TimeUptime(4*time.Second))
+	assert.InDelta(t, 33.33, a.TimeUptime(2*time.Second), 0.01)
+	assert.Equal(t, 0.0, a.TimeUptime(time.Second))
+
+	// Time that is no longer part of the window doesn't count.
+	sw.Advance(10 * time.Second)
+	assert.Equal(t, 0.0, a.TimeUptime(4*time.Second))
+
+	a.Set(true)
+	sw.Advance(time.Second)
+	assert.InDelta(t, 28.57, a.TimeUptime(4*time.Second), 0.01)
+}
@@ -0,0 +1,57 @@
+//go:build go1.25
+
+// Package averagetest helps to test code that depends on sliding windows in
+// virtual time, without real sleeps.
+//
+// Tests run in a testing/synctest bubble, whose clock only advances when all
+// goroutines in it are blocked. The shifters of windows that are created in
+// the bubble use that clock, so sleeping for an hour rotates an hour's worth
+// of buckets instantly:
+//
+//	averagetest.Run(t, func(t *testing.T) {
+//		sw := averagetest.New(t, time.Minute, time.Second)
+//		sw.Add(1)
+//
+//		averagetest.Sleep(time.Minute)
+//		total, _ := sw.Total(time.Minute) // 0, as the value expired.
+//	})
+package averagetest
+
+import (
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/prep/average"
+)
+
+// Run runs f in a new bubble with a virtual clock. All windows that are
+// created in f have to be stopped before f returns, as their shifters would
+// otherwise keep the bubble alive. New takes care of that. This includes
+// windows created WithAutoStop, whose shifters are otherwise stopped by the
+// garbage collector from outside of the bubble.
+func Run(t *testing.T, f func(t *testing.T)) {
+	synctest.Test(t, f)
+}
+
+// New returns a new SlidingWindow that is stopped when the test finishes. It
+// has to be called in a bubble, like the one of Run, for the window to use
+// its virtual clock.
+func New(t testing.TB, window, granularity time.Duration, opts ...average.Option) *average.SlidingWindow {
+	t.Helper()
+
+	sw, err := average.New(window, granularity, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(sw.Stop)
+	return sw
+}
+
+// Sleep advances the virtual clock of the current bubble by d, and waits until
+// the shifters of all windows in it have rotated their buckets accordingly.
+func Sleep(d time.Duration) {
+	time.Sleep(d)
+	synctest.Wait()
+}
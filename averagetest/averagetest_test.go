@@ -0,0 +1,62 @@
+//go:build go1.25
+
+package averagetest
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/prep/average"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	Run(t, func(t *testing.T) {
+		sw := New(t, time.Minute, time.Second)
+		sw.Add(1)
+
+		Sleep(30 * time.Second)
+		sw.Add(2)
+
+		total, count := sw.Total(time.Minute)
+		assert.Equal(t, 3.0, total)
+		assert.Equal(t, int64(2), count)
+		assert.Equal(t, []int64{1}, sw.Counts(time.Second))
+
+		Sleep(30 * time.Second)
+
+		total, _ = sw.Total(time.Minute)
+		assert.Equal(t, 2.0, total)
+	})
+}
+
+func TestRunOptions(t *testing.T) {
+	Run(t, func(t *testing.T) {
+		sw := New(t, time.Minute, time.Second, average.WithRandomPhase(), average.WithDriftCorrection())
+		sw.Add(1)
+
+		Sleep(time.Hour)
+
+		total, _ := sw.Total(time.Minute)
+		assert.Equal(t, 0.0, total)
+		assert.Equal(t, uint64(0), sw.Debug().MissedTicks)
+		assert.True(t, sw.Debug().Rotations >= 3600)
+	})
+}
+
+func TestRunAutoStop(t *testing.T) {
+	Run(t, func(t *testing.T) {
+		sw := New(t, time.Minute, time.Second, average.WithAutoStop())
+		Sleep(time.Minute)
+
+		assert.Equal(t, 60, len(sw.Snapshot().Samples))
+	})
+
+	// The stopped window is collected outside of the bubble, which must not
+	// run its cleanup.
+	for i := 0; i < 5; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,69 @@
+package average
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// WithBound detects when the value of a bucket exceeds bound in either
+// direction, which is how long-lived counters of large values would otherwise
+// lose precision silently: a float64 only represents integers exactly up to
+// 1<<53, and a window created WithCompactStorage up to 1<<24. If saturate is
+// true, the value of a bucket is clamped to the bound. Either way, Overflowed
+// reports which windows were affected.
+func WithBound(bound float64, saturate bool) Option {
+	return func(sw *SlidingWindow) error {
+		if !(bound > 0) {
+			return errors.New("bound has to be positive")
+		}
+
+		sw.bound = bound
+		sw.saturate = saturate
+		sw.overflows = make([]bool, sw.len())
+		return nil
+	}
+}
+
+// checkBound flags the bucket at the specified position if its value exceeds
+// the bound, and saturates it if configured to. It must be called with the
+// lock held.
+func (sw *SlidingWindow) checkBound(pos int) {
+	v := sw.sum(pos)
+	if math.Abs(v) <= sw.bound {
+		return
+	}
+
+	sw.overflows[pos] = true
+	if sw.saturate {
+		sw.set(pos, math.Copysign(sw.bound, v), sw.count(pos))
+	}
+}
+
+// Overflowed returns true if the value of a bucket in the specified window
+// exceeded the bound of a window created WithBound, or if the total of the
+// window exceeds it. It always returns false for other windows.
+func (sw *SlidingWindow) Overflowed(window time.Duration) bool {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	if sw.overflows == nil {
+		return false
+	}
+
+	var total float64
+	for i, n := 0, sw.buckets(window); i < n; i++ {
+		if sw.open(i) {
+			continue
+		}
+
+		pos := sw.index(i)
+		if sw.overflows[pos] {
+			return true
+		}
+
+		total += sw.sum(pos)
+	}
+
+	return math.Abs(total) > sw.bound
+}
@@ -0,0 +1,76 @@
+package average
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithBound(t *testing.T) {
+	_, err := New(4*time.Second, time.Second, WithBound(0, true))
+	assert.EqualError(t, err, "bound has to be positive")
+
+	_, err = New(4*time.Second, time.Second, WithBound(math.NaN(), true))
+	assert.EqualError(t, err, "bound has to be positive")
+}
+
+func TestWithBoundSaturate(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithBound(10, true))
+	defer sw.Stop()
+
+	sw.Add(6)
+	assert.False(t, sw.Overflowed(4*time.Second))
+
+	sw.Add(6)
+	total, count := sw.Total(4 * time.Second)
+	assert.Equal(t, 10.0, total)
+	assert.Equal(t, int64(2), count)
+	assert.True(t, sw.Overflowed(4*time.Second))
+
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+	sw.Add(-25)
+
+	total, _ = sw.Total(time.Second)
+	assert.Equal(t, -10.0, total)
+
+	// The flag expires along with the bucket that overflowed.
+	sw.Lock()
+	sw.shift()
+	sw.shift()
+	sw.shift()
+	sw.shift()
+	sw.Unlock()
+	assert.False(t, sw.Overflowed(4*time.Second))
+}
+
+func TestWithBoundFlag(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithBound(10, false))
+	defer sw.Stop()
+
+	sw.AddN(8, 2)
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+	sw.Add(8)
+
+	// No bucket exceeds the bound, but the total of the window does.
+	assert.False(t, sw.Overflowed(time.Second))
+	assert.True(t, sw.Overflowed(4*time.Second))
+
+	sw.Add(8)
+	total, _ := sw.Total(time.Second)
+	assert.Equal(t, 16.0, total)
+	assert.True(t, sw.Overflowed(time.Second))
+}
+
+func TestOverflowedWithoutBound(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Add(math.MaxFloat64)
+	assert.False(t, sw.Overflowed(4*time.Second))
+}
@@ -0,0 +1,56 @@
+package average
+
+import (
+	"sort"
+	"time"
+)
+
+// BucketPercentile returns the p-th percentile, with p between 0 and 100, of
+// the averages of the buckets in the specified window, using the nearest-rank
+// method. This answers questions like "what did the worst second of the last
+// minute look like" without a reservoir or a sketch. Buckets without samples
+// don't have an average and are skipped. It returns 0 if no bucket in the
+// window has an average.
+func (sw *SlidingWindow) BucketPercentile(window time.Duration, p float64) float64 {
+	sw.RLock()
+	n := sw.buckets(window)
+	averages := make([]float64, 0, n)
+	for i := 0; i < n; i++ {
+		if avg, ok := sw.bucketAverage(sw.index(i)); ok && !sw.open(i) {
+			averages = append(averages, avg)
+		}
+	}
+	sw.RUnlock()
+
+	if len(averages) == 0 {
+		return 0
+	}
+
+	sort.Float64s(averages)
+	return nearestRank(averages, p)
+}
+
+// bucketAverage returns the average of the bucket at the specified position,
+// and whether it has one. Buckets of windows created WithoutCounts average to
+// their sum, and those of other aggregations than AggregateSum to their value.
+// It must be called with the lock held.
+func (sw *SlidingWindow) bucketAverage(pos int) (float64, bool) {
+	switch {
+	case sw.valid != nil:
+		return sw.sum(pos), sw.valid[pos]
+	case sw.weights != nil:
+		if sw.weights[pos] == 0 {
+			return 0, false
+		}
+		return sw.sum(pos) / sw.weights[pos], true
+	case !sw.hasCounts():
+		return sw.sum(pos), true
+	}
+
+	count := sw.count(pos)
+	if count == 0 {
+		return 0, false
+	}
+
+	return sw.sum(pos) / float64(count), true
+}
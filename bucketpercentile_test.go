@@ -0,0 +1,47 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketPercentile(t *testing.T) {
+	sw := &SlidingWindow{
+		window:      10 * time.Second,
+		granularity: time.Second,
+		samples:     []float64{20, 4, 5, 0, 0, 0, 0, 0, 4, 10},
+		counts:      []int64{10, 2, 5, 0, 0, 0, 0, 0, 4, 2},
+		pos:         2,
+		size:        10,
+	}
+
+	// The bucket averages are 1, 2, 2, 1 and 5.
+	assert.Equal(t, 0.0, sw.BucketPercentile(0, 50))
+	assert.Equal(t, 1.0, sw.BucketPercentile(time.Second, 100))
+	assert.Equal(t, 2.0, sw.BucketPercentile(3*time.Second, 50))
+	assert.Equal(t, 1.0, sw.BucketPercentile(10*time.Second, 0))
+	assert.Equal(t, 2.0, sw.BucketPercentile(10*time.Second, 50))
+	assert.Equal(t, 5.0, sw.BucketPercentile(10*time.Second, 90))
+}
+
+func TestBucketPercentileWithoutCounts(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithoutCounts(), WithManualClock())
+	defer sw.Stop()
+
+	sw.Add(3)
+	sw.Advance(time.Second)
+	sw.Add(7)
+
+	assert.Equal(t, 7.0, sw.BucketPercentile(4*time.Second, 100))
+	assert.Equal(t, 3.0, sw.BucketPercentile(4*time.Second, 50))
+}
+
+func TestBucketPercentileNegativeWindow(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Add(1)
+	assert.Equal(t, 0.0, sw.BucketPercentile(-time.Second, 50))
+}
@@ -0,0 +1,143 @@
+package average
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// AlertTransition is the outcome of a BurnRateAlert update.
+type AlertTransition int
+
+const (
+	// AlertUnchanged means the alert stayed firing or resolved.
+	AlertUnchanged AlertTransition = iota
+	// AlertFiring means the alert started firing.
+	AlertFiring
+	// AlertResolved means the alert stopped firing.
+	AlertResolved
+)
+
+// String returns the name of the transition.
+func (t AlertTransition) String() string {
+	switch t {
+	case AlertFiring:
+		return "firing"
+	case AlertResolved:
+		return "resolved"
+	}
+
+	return "unchanged"
+}
+
+// BurnRateWindow is a pair of horizons over which the burn rate of an error
+// budget is compared to a factor, like a burn rate of 14.4 over both the last
+// hour and the last 5 minutes. The long horizon makes sure that enough of the
+// budget was spent to alert, and the short one that it is still being spent.
+type BurnRateWindow struct {
+	Long   time.Duration
+	Short  time.Duration
+	Factor float64
+}
+
+// BurnRateAlert implements multi-window, multi-burn-rate alerting on an error
+// rate. It fires when the burn rate exceeds the factor of a BurnRateWindow
+// over both of its horizons, for any of its windows, and resolves once that
+// no longer holds for any of them.
+//
+// The error rate is the average of a window to which every request adds 1 if
+// it failed and 0 if it succeeded, and the burn rate is the error rate
+// relative to the error budget of the objective: a burn rate of 1 spends
+// exactly the budget over the period of the objective.
+type BurnRateAlert struct {
+	sw       *SlidingWindow
+	budget   float64
+	windows  []BurnRateWindow
+	onChange func(AlertTransition)
+
+	mu     sync.Mutex
+	firing bool
+}
+
+// NewBurnRateAlert returns a new BurnRateAlert for the error rate in sw and an
+// objective between 0 and 1, like 0.999 for 99.9% of the requests to succeed.
+// If onChange is not nil, it is called for every transition that Update
+// detects. The horizons of the windows can't be larger than sw, as sw doesn't
+// keep the data to compute the burn rate over them.
+func NewBurnRateAlert(sw *SlidingWindow, objective float64, windows []BurnRateWindow, onChange func(AlertTransition)) (*BurnRateAlert, error) {
+	if sw == nil {
+		return nil, errors.New("window cannot be nil")
+	}
+	if objective <= 0 || objective >= 1 {
+		return nil, errors.New("objective has to be between 0 and 1")
+	}
+	if len(windows) == 0 {
+		return nil, errors.New("at least one burn rate window is required")
+	}
+	for _, w := range windows {
+		if w.Short <= 0 || w.Short >= w.Long {
+			return nil, errors.New("short horizon has to be shorter than the long horizon")
+		}
+		if w.Long > sw.window {
+			return nil, errors.New("long horizon is larger than the window")
+		}
+		if w.Factor <= 0 {
+			return nil, errors.New("burn rate factor has to be positive")
+		}
+	}
+
+	return &BurnRateAlert{
+		sw:       sw,
+		budget:   1 - objective,
+		windows:  append([]BurnRateWindow(nil), windows...),
+		onChange: onChange,
+	}, nil
+}
+
+// BurnRate returns the burn rate of the error budget over the specified
+// window.
+func (a *BurnRateAlert) BurnRate(window time.Duration) float64 {
+	return a.sw.Average(window) / a.budget
+}
+
+// Update evaluates the burn rates and returns whether the alert started or
+// stopped firing since the previous update.
+func (a *BurnRateAlert) Update() AlertTransition {
+	a.sw.RLock()
+	firing := false
+	for _, w := range a.windows {
+		if a.sw.average(w.Long)/a.budget > w.Factor && a.sw.average(w.Short)/a.budget > w.Factor {
+			firing = true
+			break
+		}
+	}
+	a.sw.RUnlock()
+
+	a.mu.Lock()
+	previous := a.firing
+	a.firing = firing
+	a.mu.Unlock()
+
+	if firing == previous {
+		return AlertUnchanged
+	}
+
+	transition := AlertResolved
+	if firing {
+		transition = AlertFiring
+	}
+
+	if a.onChange != nil {
+		a.onChange(transition)
+	}
+
+	return transition
+}
+
+// Firing returns true if the alert was firing as of the most recent update.
+func (a *BurnRateAlert) Firing() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.firing
+}
@@ -0,0 +1,78 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBurnRateAlert(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+
+	windows := []BurnRateWindow{{Long: 10 * time.Second, Short: 2 * time.Second, Factor: 2}}
+
+	_, err := NewBurnRateAlert(nil, 0.9, windows, nil)
+	assert.EqualError(t, err, "window cannot be nil")
+
+	_, err = NewBurnRateAlert(sw, 1, windows, nil)
+	assert.EqualError(t, err, "objective has to be between 0 and 1")
+
+	_, err = NewBurnRateAlert(sw, 0.9, nil, nil)
+	assert.EqualError(t, err, "at least one burn rate window is required")
+
+	_, err = NewBurnRateAlert(sw, 0.9, []BurnRateWindow{{Long: time.Second, Short: 2 * time.Second, Factor: 2}}, nil)
+	assert.EqualError(t, err, "short horizon has to be shorter than the long horizon")
+
+	_, err = NewBurnRateAlert(sw, 0.9, []BurnRateWindow{{Long: 20 * time.Second, Short: 2 * time.Second, Factor: 2}}, nil)
+	assert.EqualError(t, err, "long horizon is larger than the window")
+
+	_, err = NewBurnRateAlert(sw, 0.9, []BurnRateWindow{{Long: 10 * time.Second, Short: 2 * time.Second}}, nil)
+	assert.EqualError(t, err, "burn rate factor has to be positive")
+}
+
+func TestBurnRateAlert(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+
+	var transitions []AlertTransition
+	a, err := NewBurnRateAlert(sw, 0.9, []BurnRateWindow{
+		{Long: 10 * time.Second, Short: 2 * time.Second, Factor: 2},
+	}, func(tr AlertTransition) {
+		transitions = append(transitions, tr)
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, AlertUnchanged, a.Update())
+	assert.False(t, a.Firing())
+
+	sw.Add(1)
+	for i := 0; i < 9; i++ {
+		sw.Add(0)
+	}
+	assert.InDelta(t, 1.0, a.BurnRate(10*time.Second), 1e-9)
+	assert.Equal(t, AlertUnchanged, a.Update())
+
+	sw.Add(1)
+	sw.Add(1)
+	assert.Equal(t, AlertFiring, a.Update())
+	assert.True(t, a.Firing())
+	assert.Equal(t, AlertUnchanged, a.Update())
+
+	// Once the errors stop, the short horizon resolves the alert even though
+	// the long horizon still burns faster than the factor.
+	sw.Lock()
+	sw.shift()
+	sw.shift()
+	sw.Unlock()
+	sw.Add(0)
+
+	assert.Greater(t, a.BurnRate(10*time.Second), 2.0)
+	assert.Equal(t, AlertResolved, a.Update())
+	assert.False(t, a.Firing())
+
+	assert.Equal(t, []AlertTransition{AlertFiring, AlertResolved}, transitions)
+	assert.Equal(t, "firing", AlertFiring.String())
+	assert.Equal(t, "unchanged", AlertUnchanged.String())
+}
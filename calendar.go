@@ -0,0 +1,244 @@
+package average
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// CalendarPeriod is the length of the buckets of a CalendarWindow.
+type CalendarPeriod int
+
+const (
+	// CalendarDay buckets span a day, from midnight to midnight. Due to
+	// daylight saving time, a day can last 23 or 25 hours.
+	CalendarDay CalendarPeriod = iota
+	// CalendarWeek buckets span a week, from midnight on Monday to midnight
+	// on the next Monday.
+	CalendarWeek
+)
+
+// CalendarWindow is a sliding window whose buckets are aligned to the
+// calendar of a location rather than to fixed spans of time, like the last 7
+// days up to today, where every day starts at local midnight. A
+// CalendarWindow doesn't run a goroutine, as its buckets only rotate when the
+// window is used.
+type CalendarWindow struct {
+	mu      sync.Mutex
+	period  CalendarPeriod
+	loc     *time.Location
+	samples []float64
+	counts  []int64
+	pos     int
+	size    int       // The number of buckets in use.
+	start   time.Time // The start of the current bucket.
+}
+
+// MustNewCalendarWindow returns a new CalendarWindow, but panics if an error
+// occurs.
+func MustNewCalendarWindow(period CalendarPeriod, n int, loc *time.Location) *CalendarWindow {
+	cw, err := NewCalendarWindow(period, n, loc)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return cw
+}
+
+// NewCalendarWindow returns a new CalendarWindow of n buckets of the specified
+// period in location loc, whose current bucket is the one that contains the
+// current time.
+func NewCalendarWindow(period CalendarPeriod, n int, loc *time.Location) (*CalendarWindow, error) {
+	return newCalendarWindow(period, n, loc, time.Now())
+}
+
+// newCalendarWindow returns a new CalendarWindow whose current bucket is the
+// one that contains now.
+func newCalendarWindow(period CalendarPeriod, n int, loc *time.Location, now time.Time) (*CalendarWindow, error) {
+	switch {
+	case period != CalendarDay && period != CalendarWeek:
+		return nil, errors.New("unknown calendar period")
+	case n < 1:
+		return nil, errors.New("window has to have at least 1 bucket")
+	case loc == nil:
+		return nil, errors.New("location cannot be nil")
+	}
+
+	cw := &CalendarWindow{
+		period:  period,
+		loc:     loc,
+		samples: make([]float64, n),
+		counts:  make([]int64, n),
+		size:    1,
+	}
+	cw.start = cw.truncate(now)
+
+	return cw, nil
+}
+
+// truncate returns the start of the bucket that contains t.
+func (cw *CalendarWindow) truncate(t time.Time) time.Time {
+	y, m, d := t.In(cw.loc).Date()
+	if cw.period == CalendarWeek {
+		d -= (int(time.Date(y, m, d, 0, 0, 0, 0, cw.loc).Weekday()) + 6) % 7
+	}
+
+	return time.Date(y, m, d, 0, 0, 0, 0, cw.loc)
+}
+
+// offset returns the start of the bucket that is n buckets after the one that
+// starts at start, or before it if n is negative.
+func (cw *CalendarWindow) offset(start time.Time, n int) time.Time {
+	if cw.period == CalendarWeek {
+		n *= 7
+	}
+
+	y, m, d := start.Date()
+	return time.Date(y, m, d+n, 0, 0, 0, 0, cw.loc)
+}
+
+// advance rotates the buckets until the current one contains t. It must be
+// called with the lock held.
+func (cw *CalendarWindow) advance(t time.Time) {
+	for i := 0; i < len(cw.samples); i++ {
+		next := cw.offset(cw.start, 1)
+		if t.Before(next) {
+			return
+		}
+
+		cw.start = next
+		if cw.pos++; cw.pos == len(cw.samples) {
+			cw.pos = 0
+		}
+		if cw.size < len(cw.samples) {
+			cw.size++
+		}
+		cw.samples[cw.pos], cw.counts[cw.pos] = 0, 0
+	}
+
+	// All buckets have been rotated, so the window is empty and the current
+	// bucket can start at t right away.
+	if !t.Before(cw.offset(cw.start, 1)) {
+		cw.start = cw.truncate(t)
+	}
+}
+
+// index returns the position of the bucket that is age buckets older than the
+// current one.
+func (cw *CalendarWindow) index(age int) int {
+	pos := cw.pos - age
+	if pos < 0 {
+		pos += len(cw.samples)
+	}
+
+	return pos
+}
+
+// Add increments the value of the current bucket.
+func (cw *CalendarWindow) Add(v float64) {
+	cw.AddAt(time.Now(), v)
+}
+
+// AddAt increments the value of the bucket that contains t. Buckets rotate if
+// t is later than the current bucket. AddAt returns false if t is older than
+// the oldest bucket of the window.
+func (cw *CalendarWindow) AddAt(t time.Time, v float64) bool {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	cw.advance(t)
+
+	age := 0
+	for start := cw.start; t.Before(start); start = cw.offset(start, -1) {
+		if age++; age >= len(cw.samples) {
+			return false
+		}
+	}
+
+	if age >= cw.size {
+		cw.size = age + 1
+	}
+
+	pos := cw.index(age)
+	cw.samples[pos] += v
+	cw.counts[pos]++
+	return true
+}
+
+// TotalPeriods returns the sum of all values over the n most recent buckets,
+// as well as the number of samples. Unlike the Total of other windows, it
+// takes a number of calendar periods rather than a time.Duration, as the
+// length of a period varies.
+func (cw *CalendarWindow) TotalPeriods(n int) (float64, int64) {
+	return cw.TotalPeriodsAt(time.Now(), n)
+}
+
+// TotalPeriodsAt returns the sum of all values over the n most recent buckets
+// up to the bucket that contains now, as well as the number of samples.
+func (cw *CalendarWindow) TotalPeriodsAt(now time.Time, n int) (float64, int64) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	cw.advance(now)
+	if n > cw.size {
+		n = cw.size
+	}
+
+	var total float64
+	var count int64
+	for age := 0; age < n; age++ {
+		pos := cw.index(age)
+		total += cw.samples[pos]
+		count += cw.counts[pos]
+	}
+
+	return total, count
+}
+
+// AveragePeriods returns the unweighted mean of the samples in the n most
+// recent buckets. Like TotalPeriods, it takes a number of calendar periods
+// rather than a time.Duration.
+func (cw *CalendarWindow) AveragePeriods(n int) float64 {
+	return cw.AveragePeriodsAt(time.Now(), n)
+}
+
+// AveragePeriodsAt returns the unweighted mean of the samples in the n most
+// recent buckets up to the bucket that contains now.
+func (cw *CalendarWindow) AveragePeriodsAt(now time.Time, n int) float64 {
+	total, count := cw.TotalPeriodsAt(now, n)
+	if count == 0 {
+		return 0
+	}
+
+	return total / float64(count)
+}
+
+// Buckets returns the buckets that are in use, from the current to the oldest
+// one. The start and end of every bucket are in the location of the window, and
+// the current bucket ends in the future.
+func (cw *CalendarWindow) Buckets() []BucketResult {
+	return cw.BucketsAt(time.Now())
+}
+
+// BucketsAt returns the buckets that are in use up to the bucket that contains
+// now, like Buckets.
+func (cw *CalendarWindow) BucketsAt(now time.Time) []BucketResult {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	cw.advance(now)
+
+	buckets := make([]BucketResult, cw.size)
+	for age := 0; age < cw.size; age++ {
+		pos := cw.index(age)
+		start := cw.offset(cw.start, -age)
+		buckets[age] = BucketResult{
+			Sum:   cw.samples[pos],
+			Count: cw.counts[pos],
+			Start: start,
+			End:   cw.offset(start, 1),
+		}
+	}
+
+	return buckets
+}
@@ -0,0 +1,111 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s is not available: %s", name, err)
+	}
+
+	return loc
+}
+
+func TestNewCalendarWindow(t *testing.T) {
+	_, err := NewCalendarWindow(CalendarPeriod(2), 7, time.UTC)
+	assert.EqualError(t, err, "unknown calendar period")
+
+	_, err = NewCalendarWindow(CalendarDay, 0, time.UTC)
+	assert.EqualError(t, err, "window has to have at least 1 bucket")
+
+	_, err = NewCalendarWindow(CalendarDay, 7, nil)
+	assert.EqualError(t, err, "location cannot be nil")
+
+	assert.Panics(t, func() { MustNewCalendarWindow(CalendarDay, 0, time.UTC) })
+}
+
+func TestCalendarWindowDST(t *testing.T) {
+	loc := loadLocation(t, "Europe/Amsterdam")
+
+	// Daylight saving time starts on March 31 and ends on October 27, 2024.
+	now := time.Date(2024, 3, 30, 12, 0, 0, 0, loc)
+	cw, err := newCalendarWindow(CalendarDay, 3, loc, now)
+	assert.NoError(t, err)
+
+	assert.True(t, cw.AddAt(now, 1))
+	assert.True(t, cw.AddAt(time.Date(2024, 3, 31, 23, 59, 0, 0, loc), 2))
+	assert.True(t, cw.AddAt(time.Date(2024, 4, 1, 0, 0, 0, 0, loc), 4))
+
+	buckets := cw.BucketsAt(time.Date(2024, 4, 1, 1, 0, 0, 0, loc))
+	if assert.Len(t, buckets, 3) {
+		assert.Equal(t, 23*time.Hour, buckets[1].End.Sub(buckets[1].Start))
+		assert.Equal(t, 24*time.Hour, buckets[2].End.Sub(buckets[2].Start))
+		assert.Equal(t, time.Date(2024, 3, 31, 0, 0, 0, 0, loc), buckets[1].Start)
+		assert.Equal(t, []float64{4, 2, 1}, []float64{buckets[0].Sum, buckets[1].Sum, buckets[2].Sum})
+	}
+
+	cw, err = newCalendarWindow(CalendarDay, 3, loc, time.Date(2024, 10, 27, 12, 0, 0, 0, loc))
+	assert.NoError(t, err)
+
+	buckets = cw.BucketsAt(time.Date(2024, 10, 27, 23, 0, 0, 0, loc))
+	if assert.Len(t, buckets, 1) {
+		assert.Equal(t, 25*time.Hour, buckets[0].End.Sub(buckets[0].Start))
+	}
+}
+
+func TestCalendarWindowTotal(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cw, err := newCalendarWindow(CalendarDay, 3, time.UTC, now)
+	assert.NoError(t, err)
+
+	cw.AddAt(now, 1)
+	cw.AddAt(now.Add(24*time.Hour), 2)
+	cw.AddAt(now.Add(48*time.Hour), 3)
+
+	total, count := cw.TotalPeriodsAt(now.Add(48*time.Hour), 2)
+	assert.Equal(t, 5.0, total)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, 2.0, cw.AveragePeriodsAt(now.Add(48*time.Hour), 3))
+
+	// Values can be added to older buckets that are still in the window.
+	assert.True(t, cw.AddAt(now.Add(-12*time.Hour), 5))
+	assert.False(t, cw.AddAt(now.Add(-13*time.Hour), 5))
+
+	total, _ = cw.TotalPeriodsAt(now.Add(48*time.Hour), 3)
+	assert.Equal(t, 11.0, total)
+
+	// The first day expires at midnight.
+	total, _ = cw.TotalPeriodsAt(time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 3)
+	assert.Equal(t, 5.0, total)
+
+	total, count = cw.TotalPeriodsAt(now.AddDate(1, 0, 0), 3)
+	assert.Equal(t, 0.0, total)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, 0.0, cw.AveragePeriodsAt(now.AddDate(1, 0, 0), 3))
+
+	buckets := cw.BucketsAt(now.AddDate(1, 0, 0))
+	if assert.Len(t, buckets, 3) {
+		assert.Equal(t, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), buckets[0].Start)
+	}
+}
+
+func TestCalendarWindowWeek(t *testing.T) {
+	// March 6, 2024 is a Wednesday.
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	cw, err := newCalendarWindow(CalendarWeek, 2, time.UTC, now)
+	assert.NoError(t, err)
+
+	cw.AddAt(now, 1)
+	cw.AddAt(time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), 2)
+
+	buckets := cw.BucketsAt(time.Date(2024, 3, 17, 23, 0, 0, 0, time.UTC))
+	assert.Equal(t, []BucketResult{
+		{Sum: 2, Count: 1, Start: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC)},
+		{Sum: 1, Count: 1, Start: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)},
+	}, buckets)
+}
@@ -0,0 +1,109 @@
+package average
+
+import (
+	"math"
+	"time"
+)
+
+// z95 is the z-score of a two-sided 95% confidence interval.
+const z95 = 1.959963984540054
+
+// Summary holds the number, mean and variance of the values of a window over
+// a period, which is all that is needed to compare the means of two windows
+// statistically.
+type Summary struct {
+	Count       int64
+	Mean        float64
+	Variance    float64 // The sample variance of the values.
+	HasVariance bool    // Whether the variance is known.
+}
+
+// Summary returns the number, mean and sample variance of the values over the
+// specified window. The variance is only known for windows created
+// WithMoments with at least 2 values, and is 0 for other windows.
+func (sw *SlidingWindow) Summary(window time.Duration) Summary {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	total, count, n := sw.total(window)
+	s := Summary{Count: count}
+	if count > 0 {
+		s.Mean = total / float64(count)
+	}
+
+	if sw.moments != nil {
+		var m moments
+		for i := 0; i < n; i++ {
+			if !sw.open(i) {
+				m.merge(sw.moments[sw.index(i)])
+			}
+		}
+		if m.count > 1 {
+			s.Variance = m.m2 / (m.count - 1)
+			s.HasVariance = true
+		}
+	}
+
+	return s
+}
+
+// Summaries returns a summary of the specified window of every registered
+// window by name.
+func (r *Registry) Summaries(window time.Duration) map[string]Summary {
+	summaries := make(map[string]Summary)
+	r.Each(func(name string, sw *SlidingWindow) {
+		summaries[name] = sw.Summary(window)
+	})
+
+	return summaries
+}
+
+// GroupComparison is the statistical comparison of the means of a label in two
+// groups of windows.
+type GroupComparison struct {
+	// Difference is the mean of the first group minus the mean of the second.
+	Difference float64
+	// StdErr is the standard error of the difference.
+	StdErr float64
+	// Lower and Upper are the bounds of the 95% confidence interval of the
+	// difference.
+	Lower, Upper float64
+	// Significant is true if the confidence interval doesn't contain 0.
+	Significant bool
+}
+
+// CompareGroups compares the means of every label that both groups have, like
+// the windows of canary pods against those of baseline pods, with the normal
+// approximation of Welch's unequal variances test. This allows automated
+// canary analysis: a significant, positive difference in latency means the
+// canary is slower. Labels for which either group doesn't know the variance,
+// because its windows weren't created WithMoments or have fewer than 2
+// values, are left out, as the difference of their means can't tell a change
+// from noise.
+func CompareGroups(a, b map[string]Summary) map[string]GroupComparison {
+	comparisons := make(map[string]GroupComparison)
+	for label, sa := range a {
+		sb, ok := b[label]
+		if !ok || !sa.comparable() || !sb.comparable() {
+			continue
+		}
+
+		c := GroupComparison{
+			Difference: sa.Mean - sb.Mean,
+			StdErr:     math.Sqrt(sa.Variance/float64(sa.Count) + sb.Variance/float64(sb.Count)),
+		}
+		c.Lower = c.Difference - z95*c.StdErr
+		c.Upper = c.Difference + z95*c.StdErr
+		c.Significant = c.Lower > 0 || c.Upper < 0
+
+		comparisons[label] = c
+	}
+
+	return comparisons
+}
+
+// comparable returns whether s has the values and the variance that a
+// comparison requires.
+func (s Summary) comparable() bool {
+	return s.Count > 1 && s.HasVariance
+}
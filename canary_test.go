@@ -0,0 +1,100 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithMoments())
+	defer sw.Stop()
+
+	assert.Equal(t, Summary{}, sw.Summary(4*time.Second))
+
+	sw.Add(2)
+	sw.Add(4)
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+	sw.Add(6)
+
+	s := sw.Summary(4 * time.Second)
+	assert.Equal(t, int64(3), s.Count)
+	assert.Equal(t, 4.0, s.Mean)
+	assert.InDelta(t, 4.0, s.Variance, 1e-9)
+	assert.True(t, s.HasVariance)
+
+	plain := MustNew(4*time.Second, time.Second)
+	defer plain.Stop()
+	plain.Add(2)
+	plain.Add(4)
+	assert.Equal(t, Summary{Count: 2, Mean: 3}, plain.Summary(4*time.Second))
+}
+
+func TestCompareGroups(t *testing.T) {
+	canary := map[string]Summary{
+		"latency": {Count: 100, Mean: 110, Variance: 400, HasVariance: true},
+		"errors":  {Count: 100, Mean: 0.02, Variance: 0.02, HasVariance: true},
+		"new":     {Count: 10, Mean: 1, Variance: 1, HasVariance: true},
+		"empty":   {},
+	}
+	baseline := map[string]Summary{
+		"latency": {Count: 100, Mean: 100, Variance: 400, HasVariance: true},
+		"errors":  {Count: 100, Mean: 0.01, Variance: 0.01, HasVariance: true},
+		"empty":   {Count: 1, Mean: 1},
+	}
+
+	c := CompareGroups(canary, baseline)
+	assert.Len(t, c, 2)
+
+	latency := c["latency"]
+	assert.Equal(t, 10.0, latency.Difference)
+	assert.InDelta(t, 2.828, latency.StdErr, 0.001)
+	assert.InDelta(t, 4.456, latency.Lower, 0.001)
+	assert.InDelta(t, 15.544, latency.Upper, 0.001)
+	assert.True(t, latency.Significant)
+
+	assert.False(t, c["errors"].Significant)
+}
+
+func TestCompareGroupsWithoutVariance(t *testing.T) {
+	// Without variance, or with a single value, any difference would look
+	// significant, so those labels are left out.
+	canary := map[string]Summary{
+		"plain":  {Count: 100, Mean: 110},
+		"single": {Count: 1, Mean: 110, HasVariance: true},
+		"zero":   {Count: 100, Mean: 110, HasVariance: true},
+	}
+	baseline := map[string]Summary{
+		"plain":  {Count: 100, Mean: 100},
+		"single": {Count: 100, Mean: 100, Variance: 400, HasVariance: true},
+		"zero":   {Count: 100, Mean: 100, HasVariance: true},
+	}
+
+	c := CompareGroups(canary, baseline)
+	assert.Len(t, c, 1)
+	assert.Equal(t, 10.0, c["zero"].Difference)
+
+	// Summaries of windows without moments are left out as well.
+	a := MustNew(4*time.Second, time.Second)
+	defer a.Stop()
+	b := MustNew(4*time.Second, time.Second)
+	defer b.Stop()
+	a.Add(1)
+	a.Add(2)
+	b.Add(10)
+	b.Add(20)
+	assert.Empty(t, CompareGroups(map[string]Summary{"x": a.Summary(4 * time.Second)}, map[string]Summary{"x": b.Summary(4 * time.Second)}))
+}
+
+func TestRegistrySummaries(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+	sw.Add(3)
+
+	r := NewRegistry()
+	assert.NoError(t, r.Register("requests", sw))
+	assert.Equal(t, map[string]Summary{"requests": {Count: 1, Mean: 3}}, r.Summaries(4*time.Second))
+}
@@ -0,0 +1,126 @@
+package average
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+// checkpointMagic and checkpointVersion identify the checkpoint format that
+// WriteTo writes:
+//
+//	magic       [4]byte "AVGW"
+//	version     uint8
+//	window      int64 (nanoseconds)
+//	granularity int64 (nanoseconds)
+//	time        int64 (Unix nanoseconds at capture)
+//	start       int64 (Unix nanoseconds at the start of the current bucket)
+//	buckets     uint32
+//	sample      float64, repeated for every bucket from newest to oldest
+//	count       int64, repeated for every bucket from newest to oldest
+//
+// All integers and floats are encoded in little-endian byte order.
+const (
+	checkpointMagic   = "AVGW"
+	checkpointVersion = 1
+)
+
+type checkpointHeader struct {
+	Magic       [4]byte
+	Version     uint8
+	Window      int64
+	Granularity int64
+	Time        int64
+	Start       int64
+	Buckets     uint32
+}
+
+// WriteTo writes a checkpoint of this window to w, so it can be restored with
+// ReadFrom by another (or a restarted) process.
+func (sw *SlidingWindow) WriteTo(w io.Writer) (int64, error) {
+	s := sw.Snapshot()
+
+	header := checkpointHeader{
+		Version:     checkpointVersion,
+		Window:      int64(s.Window),
+		Granularity: int64(s.Granularity),
+		Time:        s.Time.UnixNano(),
+		Start:       s.Start.UnixNano(),
+		Buckets:     uint32(len(s.Samples)),
+	}
+	copy(header.Magic[:], checkpointMagic)
+
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, header)
+	for _, v := range s.Samples {
+		binary.Write(&buf, binary.LittleEndian, math.Float64bits(v))
+	}
+	binary.Write(&buf, binary.LittleEndian, s.Counts)
+
+	return buf.WriteTo(w)
+}
+
+// ReadFrom restores this window from a checkpoint written by WriteTo. The
+// checkpoint has to be of a window with the same size and granularity. Its
+// buckets are aged by the time that passed since the checkpoint was written,
+// so data that has expired since then is not restored.
+func (sw *SlidingWindow) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countingReader{r: r}
+
+	var header checkpointHeader
+	if err := binary.Read(cr, binary.LittleEndian, &header); err != nil {
+		return cr.n, err
+	}
+	if string(header.Magic[:]) != checkpointMagic {
+		return cr.n, errors.New("not a checkpoint")
+	}
+	if header.Version != checkpointVersion {
+		return cr.n, errors.New("unsupported checkpoint version")
+	}
+	if time.Duration(header.Window) != sw.window || time.Duration(header.Granularity) != sw.granularity {
+		return cr.n, errors.New("snapshot configuration does not match the window")
+	}
+	if int(header.Buckets) > sw.len() {
+		return cr.n, errors.New("snapshot has an invalid number of buckets")
+	}
+
+	s := Snapshot{
+		Window:      time.Duration(header.Window),
+		Granularity: time.Duration(header.Granularity),
+		Time:        time.Unix(0, header.Time),
+		Start:       time.Unix(0, header.Start),
+		Samples:     make([]float64, header.Buckets),
+		Counts:      make([]int64, header.Buckets),
+	}
+
+	bits := make([]uint64, header.Buckets)
+	if err := binary.Read(cr, binary.LittleEndian, bits); err != nil {
+		return cr.n, err
+	}
+	for i, b := range bits {
+		s.Samples[i] = math.Float64frombits(b)
+	}
+	if err := binary.Read(cr, binary.LittleEndian, s.Counts); err != nil {
+		return cr.n, err
+	}
+
+	sw.Lock()
+	defer sw.Unlock()
+
+	return cr.n, sw.restore(s)
+}
+
+// countingReader counts the number of bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
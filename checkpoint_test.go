@@ -0,0 +1,59 @@
+package average
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpoint(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Add(2)
+	sw.Add(3)
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+	sw.Add(5)
+
+	var buf bytes.Buffer
+	n, err := sw.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	restored := MustNew(10*time.Second, time.Second)
+	defer restored.Stop()
+
+	size := int64(buf.Len())
+	n, err = restored.ReadFrom(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, size, n)
+
+	total, count := restored.Total(10 * time.Second)
+	assert.Equal(t, 10.0, total)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestCheckpointErrors(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+
+	var buf bytes.Buffer
+	sw.WriteTo(&buf)
+	checkpoint := buf.Bytes()
+
+	other := MustNew(20*time.Second, time.Second)
+	defer other.Stop()
+
+	_, err := other.ReadFrom(bytes.NewReader(checkpoint))
+	assert.EqualError(t, err, "snapshot configuration does not match the window")
+
+	_, err = sw.ReadFrom(bytes.NewReader(bytes.Repeat([]byte("nope"), 16)))
+	assert.EqualError(t, err, "not a checkpoint")
+
+	_, err = sw.ReadFrom(bytes.NewReader(checkpoint[:len(checkpoint)-1]))
+	assert.Error(t, err)
+}
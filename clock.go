@@ -0,0 +1,77 @@
+package average
+
+import "time"
+
+// WithManualClock creates a window without a shifter, whose time only passes
+// when Advance or Tick is called. This lets tests and simulations drive a
+// window through hours of virtual time in microseconds, and lets hosts that
+// can't run a goroutine per window rotate the buckets themselves. The clock of
+// the window starts at the time it was created.
+func WithManualClock() Option {
+	return func(sw *SlidingWindow) error {
+		sw.manual = true
+		sw.virtual = sw.start
+		return nil
+	}
+}
+
+// Advance moves the clock of a window created WithManualClock forward by d,
+// and rotates the buckets as if d had elapsed. Time that doesn't add up to a
+// full bucket carries over to the next call, so advancing by half the
+// granularity twice rotates the buckets once. Advance has no effect on other
+// windows.
+func (sw *SlidingWindow) Advance(d time.Duration) {
+	sw.Lock()
+	defer sw.unlockAndEvict()
+
+	if sw.manual && !sw.stopped && d > 0 {
+		sw.advance(sw.virtual.Add(d))
+	}
+}
+
+// Tick moves the clock of a window created WithManualClock forward to now, and
+// rotates the buckets as if the time up to now had elapsed. It's meant for
+// hosts that drive the window from their own loop instead of a shifter
+// goroutine, like TinyGo and WebAssembly programs or game loops, which call
+// Tick with the current time on every iteration. Calls with a time that isn't
+// later than the previous one have no effect, and so do calls on other
+// windows.
+func (sw *SlidingWindow) Tick(now time.Time) {
+	sw.Lock()
+	defer sw.unlockAndEvict()
+
+	if sw.manual && !sw.stopped {
+		sw.advance(now)
+	}
+}
+
+// now returns the current time of this window.
+func (sw *SlidingWindow) now() time.Time {
+	if sw.manual {
+		return sw.virtual
+	}
+
+	return time.Now()
+}
+
+// advanceTo rotates the buckets until the current one contains t. It must be
+// called with the lock held.
+func (sw *SlidingWindow) advanceTo(t time.Time) {
+	steps := int64(t.Sub(sw.start) / sw.granularity)
+	if steps <= 0 {
+		return
+	}
+
+	// Once all buckets have been rotated, the window is empty, so there's no
+	// need to rotate again for every bucket that passed after that.
+	n := steps
+	if n > int64(sw.len()) {
+		n = int64(sw.len())
+	}
+	for i := int64(0); i < n; i++ {
+		sw.shift()
+	}
+
+	sw.start = sw.start.Add(time.Duration(steps-n) * sw.granularity)
+	sw.refreshView()
+}
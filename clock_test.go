@@ -0,0 +1,105 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdvance(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithManualClock())
+	defer sw.Stop()
+
+	sw.Add(1)
+	sw.Advance(500 * time.Millisecond)
+	sw.Add(2)
+	assert.Equal(t, []int64{2}, sw.Counts(4*time.Second))
+	assert.Equal(t, 500*time.Millisecond, sw.Coverage(4*time.Second))
+
+	// The remainder of the previous call carries over.
+	sw.Advance(500 * time.Millisecond)
+	sw.Add(3)
+	assert.Equal(t, []int64{1, 2}, sw.Counts(4*time.Second))
+
+	sw.Advance(2 * time.Second)
+	sw.Add(4)
+	assert.Equal(t, []int64{1, 0, 1, 2}, sw.Counts(4*time.Second))
+	assert.Equal(t, 10.0, sw.AveragePerBucket(4*time.Second)*4)
+	assert.True(t, sw.IsFull())
+
+	sw.Advance(-time.Second)
+	assert.Equal(t, []int64{1, 0, 1, 2}, sw.Counts(4*time.Second))
+}
+
+func TestAdvanceFar(t *testing.T) {
+	sw := MustNew(time.Minute, time.Second, WithManualClock(), WithGauge(true))
+	defer sw.Stop()
+
+	sw.Add(5)
+	results := sw.Subscribe(100, DropOldest)
+
+	start := sw.start
+	sw.Advance(24 * time.Hour)
+	assert.Equal(t, start.Add(24*time.Hour), sw.start)
+	assert.Equal(t, 5.0, sw.Average(time.Minute))
+
+	sw.Add(7)
+	assert.Equal(t, 7.0, sw.Aggregate(time.Minute))
+
+	first := <-results
+	assert.Equal(t, 5.0, first.Sum)
+	assert.Equal(t, start, first.Start)
+}
+
+func TestAdvanceShifter(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Add(1)
+	sw.Advance(time.Hour)
+	assert.Equal(t, []int64{1}, sw.Counts(4*time.Second))
+}
+
+func TestManualClockStop(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithManualClock())
+	c := sw.Subscribe(1, DropNewest)
+
+	sw.Stop()
+	sw.Stop()
+
+	_, ok := <-c
+	assert.False(t, ok)
+}
+
+func TestTick(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithManualClock())
+	defer sw.Stop()
+
+	start := sw.start
+	sw.Add(1)
+	sw.Tick(start.Add(500 * time.Millisecond))
+	sw.Add(2)
+	assert.Equal(t, []int64{2}, sw.Counts(4*time.Second))
+
+	sw.Tick(start.Add(2500 * time.Millisecond))
+	sw.Add(3)
+	assert.Equal(t, []int64{1, 0, 2}, sw.Counts(4*time.Second))
+	assert.Equal(t, start.Add(2*time.Second), sw.start)
+
+	// Going back in time doesn't rotate the buckets.
+	sw.Tick(start)
+	assert.Equal(t, start.Add(2*time.Second), sw.start)
+
+	total, _ := sw.Total(4 * time.Second)
+	assert.Equal(t, 6.0, total)
+}
+
+func TestTickShifter(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Add(1)
+	sw.Tick(time.Now().Add(time.Hour))
+	assert.Equal(t, []int64{1}, sw.Counts(4*time.Second))
+}
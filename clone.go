@@ -0,0 +1,91 @@
+package average
+
+import "time"
+
+// Clone returns an independent copy of this window with the same
+// configuration and data, but with its own lock and shifter. This allows a
+// reporting goroutine to take ownership of a point-in-time copy while the
+// original keeps accumulating. The shifter of the clone rotates when the
+// current bucket of the original ends, so their buckets stay aligned. The
+// DebugStats counters are copied as well. Subscriptions and the OnEvict
+// function are not copied, and the clone doesn't write to the WAL of the
+// original. The clone has to be stopped like a window returned by New, unless
+// the original was stopped, in which case the clone is stopped as well.
+func (sw *SlidingWindow) Clone() *SlidingWindow {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	clone := &SlidingWindow{
+		window:        sw.window,
+		granularity:   sw.granularity,
+		samples:       append([]float64(nil), sw.samples...),
+		counts:        append([]int64(nil), sw.counts...),
+		samples32:     append([]float32(nil), sw.samples32...),
+		counts32:      append([]uint32(nil), sw.counts32...),
+		mins:          append([]float64(nil), sw.mins...),
+		maxs:          append([]float64(nil), sw.maxs...),
+		reservoirSize: sw.reservoirSize,
+		estimates:     append([]p2(nil), sw.estimates...),
+		estimateP:     sw.estimateP,
+		weights:       append([]float64(nil), sw.weights...),
+		means:         append([]meanSums(nil), sw.means...),
+		moments:       append([]moments(nil), sw.moments...),
+		pos:           sw.pos,
+		size:          sw.size,
+		start:         sw.start,
+		autoStop:      sw.autoStop,
+		manual:        sw.manual,
+		virtual:       sw.virtual,
+		aggregation:   sw.aggregation,
+		rounding:      sw.rounding,
+		valid:         append([]bool(nil), sw.valid...),
+		carryForward:  sw.carryForward,
+		gauge:         sw.gauge.clone(),
+		maxBuckets:    sw.maxBuckets,
+		coarsen:       sw.coarsen,
+		driftCorrect:  sw.driftCorrect,
+		bound:         sw.bound,
+		saturate:      sw.saturate,
+		overflows:     append([]bool(nil), sw.overflows...),
+		randomPhase:   sw.randomPhase,
+		grace:         sw.grace,
+		unsealed:      sw.unsealed,
+		horizons:      append([]horizon(nil), sw.horizons...),
+		lockFree:      sw.lockFree,
+		limit:         sw.limit,
+		saturateLimit: sw.saturateLimit,
+		stopped:       sw.stopped,
+		adds:          sw.adds,
+		dropped:       sw.dropped,
+		rotations:     sw.rotations,
+		missedTicks:   sw.missedTicks,
+		lag:           sw.lag,
+		stopC:         make(chan struct{}),
+		doneC:         make(chan struct{}),
+	}
+
+	if sw.reservoirs != nil {
+		clone.reservoirs = make([][]float64, len(sw.reservoirs))
+		for i, res := range sw.reservoirs {
+			clone.reservoirs[i] = append(make([]float64, 0, sw.reservoirSize), res...)
+		}
+	}
+
+	// The current bucket already started, so the first rotation happens once
+	// it ends, right away if that moment has passed.
+	first := sw.start.Add(sw.granularity).Sub(time.Now())
+	if first <= 0 {
+		first = 1
+	}
+
+	clone.refreshView()
+	if clone.stopped {
+		// There is no shifter to stop, so Stop has nothing left to do.
+		clone.stopOnce.Do(func() {})
+		close(clone.doneC)
+		return clone
+	}
+
+	clone.startShifterAfter(first)
+	return clone
+}
@@ -0,0 +1,73 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClone(t *testing.T) {
+	sw := MustNew(3*time.Second, time.Second, WithExtrema(), WithReservoir(5))
+	defer sw.Stop()
+
+	sw.Add(2)
+	sw.Add(4)
+
+	clone := sw.Clone()
+	defer clone.Stop()
+
+	sw.Add(100)
+	clone.Add(6)
+
+	assert.Equal(t, 4.0, clone.Average(3*time.Second))
+	assert.Equal(t, 6.0, clone.Max(3*time.Second))
+	assert.Equal(t, []float64{2, 4, 6}, clone.Samples(3*time.Second))
+	assert.Equal(t, []float64{2, 4, 100}, sw.Samples(3*time.Second))
+
+	total, count := sw.Total(3 * time.Second)
+	assert.Equal(t, 106.0, total)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestCloneCompact(t *testing.T) {
+	sw := MustNew(3*time.Second, time.Second, WithCompactStorage(), WithoutCounts())
+	sw.Add(2)
+	sw.Stop()
+
+	clone := sw.Clone()
+	defer clone.Stop()
+
+	assert.Nil(t, clone.samples)
+	assert.Nil(t, clone.counts32)
+	assert.Equal(t, []float32{2, 0, 0}, clone.samples32)
+
+	// Cloning a stopped window returns a stopped one, which discards values
+	// and can be stopped again.
+	assert.True(t, clone.Stopped())
+	clone.Add(1)
+	assert.Equal(t, []float32{2, 0, 0}, clone.samples32)
+	assert.Equal(t, DebugStats{Adds: 1, Dropped: 1}, clone.Debug())
+	assert.Equal(t, DebugStats{Adds: 1}, sw.Debug())
+}
+
+func TestCloneAlignment(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithRandomPhase())
+	defer sw.Stop()
+
+	// Pretend that the current bucket is about to end.
+	sw.Lock()
+	sw.start = time.Now().Add(-900 * time.Millisecond)
+	sw.Unlock()
+
+	clone := sw.Clone()
+	defer clone.Stop()
+	assert.True(t, clone.randomPhase)
+
+	// The clone rotates when the current bucket ends, not one granularity
+	// after it was created.
+	time.Sleep(500 * time.Millisecond)
+	clone.RLock()
+	assert.Equal(t, 2, clone.size)
+	clone.RUnlock()
+}
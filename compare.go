@@ -0,0 +1,79 @@
+package average
+
+import "errors"
+
+// Difference is the difference between two snapshots with the same
+// configuration, like the windows of two instances of a service or the window
+// of a service before and after a deploy.
+type Difference struct {
+	// Samples and Counts are the differences per bucket, from the newest to
+	// the oldest bucket.
+	Samples []float64
+	Counts  []int64
+
+	// Total and Count are the differences between the totals of the
+	// snapshots, and Average between their averages.
+	Total   float64
+	Count   int64
+	Average float64
+}
+
+// Compare returns the difference a - b between two snapshots with the same
+// configuration, both per bucket and over the whole window. Buckets are
+// compared by their age, so that the newest bucket of a is compared to the
+// newest bucket of b and so on, no matter when the snapshots were taken.
+func Compare(a, b Snapshot) (Difference, error) {
+	d, err := Subtract(a, b)
+	if err != nil {
+		return Difference{}, err
+	}
+
+	total, count := d.Total(d.Window)
+	return Difference{
+		Samples: d.Samples,
+		Counts:  d.Counts,
+		Total:   total,
+		Count:   count,
+		Average: a.Average(a.Window) - b.Average(b.Window),
+	}, nil
+}
+
+// Subtract returns a snapshot with the buckets of b subtracted from the
+// buckets of a, which need to have the same configuration. Like Compare,
+// buckets are subtracted by their age. A bucket that only one of the
+// snapshots has counts as empty in the other. The result takes its times from
+// a. An error is returned if either snapshot has an invalid number of buckets.
+func Subtract(a, b Snapshot) (Snapshot, error) {
+	if !a.sameConfiguration(b) {
+		return Snapshot{}, errors.New("snapshots have a different configuration")
+	}
+	if err := a.checkBuckets(int(a.Window / a.Granularity)); err != nil {
+		return Snapshot{}, err
+	}
+	if err := b.checkBuckets(int(b.Window / b.Granularity)); err != nil {
+		return Snapshot{}, err
+	}
+
+	n := len(a.Samples)
+	if len(b.Samples) > n {
+		n = len(b.Samples)
+	}
+
+	d := Snapshot{
+		Window:      a.Window,
+		Granularity: a.Granularity,
+		Time:        a.Time,
+		Start:       a.Start,
+		Samples:     make([]float64, n),
+		Counts:      make([]int64, n),
+	}
+
+	copy(d.Samples, a.Samples)
+	copy(d.Counts, a.Counts)
+	for i := range b.Samples {
+		d.Samples[i] -= b.Samples[i]
+		d.Counts[i] -= b.Counts[i]
+	}
+
+	return d, nil
+}
@@ -0,0 +1,74 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	now := time.Now()
+	a := Snapshot{
+		Window:      4 * time.Second,
+		Granularity: time.Second,
+		Time:        now,
+		Start:       now,
+		Samples:     []float64{10, 6, 2},
+		Counts:      []int64{5, 3, 1},
+	}
+	b := Snapshot{
+		Window:      4 * time.Second,
+		Granularity: time.Second,
+		Time:        now.Add(-time.Hour),
+		Start:       now.Add(-time.Hour),
+		Samples:     []float64{4, 8},
+		Counts:      []int64{2, 4},
+	}
+
+	d, err := Compare(a, b)
+	assert.NoError(t, err)
+	assert.Equal(t, Difference{
+		Samples: []float64{6, -2, 2},
+		Counts:  []int64{3, -1, 1},
+		Total:   6,
+		Count:   3,
+		Average: 0,
+	}, d)
+
+	s, err := Subtract(b, a)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{-6, 2, -2}, s.Samples)
+	assert.Equal(t, []int64{-3, 1, -1}, s.Counts)
+	assert.Equal(t, b.Start, s.Start)
+	assert.Equal(t, b.Time, s.Time)
+}
+
+func TestCompareConfiguration(t *testing.T) {
+	a := Snapshot{Window: 4 * time.Second, Granularity: time.Second}
+	b := Snapshot{Window: 8 * time.Second, Granularity: time.Second}
+
+	_, err := Compare(a, b)
+	assert.EqualError(t, err, "snapshots have a different configuration")
+
+	_, err = Subtract(a, b)
+	assert.EqualError(t, err, "snapshots have a different configuration")
+}
+
+func TestCompareInvalidBuckets(t *testing.T) {
+	a := Snapshot{Window: 4 * time.Second, Granularity: time.Second, Samples: []float64{1, 2}, Counts: []int64{1}}
+	b := Snapshot{Window: 4 * time.Second, Granularity: time.Second, Samples: []float64{1}, Counts: []int64{1}}
+
+	_, err := Subtract(a, b)
+	assert.EqualError(t, err, "snapshot has an invalid number of buckets")
+
+	_, err = Compare(b, a)
+	assert.EqualError(t, err, "snapshot has an invalid number of buckets")
+
+	// A snapshot with more buckets than the window has is invalid as well.
+	a.Counts = []int64{1, 1}
+	b.Samples = make([]float64, 5)
+	b.Counts = make([]int64, 5)
+	_, err = Subtract(a, b)
+	assert.EqualError(t, err, "snapshot has an invalid number of buckets")
+}
@@ -0,0 +1,71 @@
+package average
+
+import "time"
+
+// Counts returns the number of samples in every bucket of the specified
+// window, newest first. This shows how the samples arrived over time, like in
+// bursts or at a steady pace. It returns nil for windows created WithoutCounts.
+func (sw *SlidingWindow) Counts(window time.Duration) []int64 {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	if !sw.hasCounts() {
+		return nil
+	}
+
+	counts := make([]int64, sw.buckets(window))
+	for i := range counts {
+		if !sw.open(i) {
+			counts[i] = sw.count(sw.index(i))
+		}
+	}
+
+	return counts
+}
+
+// AverageSamplesPerBucket returns the mean number of samples per bucket over
+// the specified window.
+func (sw *SlidingWindow) AverageSamplesPerBucket(window time.Duration) float64 {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	_, count, n := sw.total(window)
+	if n == 0 {
+		return 0
+	}
+
+	return float64(count) / float64(n)
+}
+
+// CopySamples copies the values and the sample counts of the buckets in use
+// into dst and dstCounts, newest first, and returns the number of buckets that
+// were copied. It copies at most len(dst) buckets, and the counts of at most
+// len(dstCounts) of those, so dstCounts may be nil. Unlike Snapshot, it
+// doesn't allocate, so the same slices can be reused for every call. The
+// counts of a window created WithoutCounts are all 0.
+func (sw *SlidingWindow) CopySamples(dst []float64, dstCounts []int64) (n int) {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	n = sw.size
+	if len(dst) < n {
+		n = len(dst)
+	}
+
+	for i := 0; i < n; i++ {
+		pos := sw.index(i)
+		if sw.open(i) {
+			dst[i] = 0
+		} else {
+			dst[i] = sw.sum(pos)
+		}
+		if i < len(dstCounts) {
+			dstCounts[i] = 0
+			if !sw.open(i) {
+				dstCounts[i] = sw.count(pos)
+			}
+		}
+	}
+
+	return n
+}
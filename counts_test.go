@@ -0,0 +1,86 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounts(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	assert.Equal(t, []int64{0}, sw.Counts(4*time.Second))
+	assert.Equal(t, 0.0, sw.AverageSamplesPerBucket(4*time.Second))
+
+	sw.AddN(10, 5)
+	sw.Lock()
+	sw.shift()
+	sw.shift()
+	sw.Unlock()
+	sw.Add(1)
+
+	assert.Equal(t, []int64{1, 0, 5}, sw.Counts(4*time.Second))
+	assert.Equal(t, []int64{1, 0}, sw.Counts(2*time.Second))
+	assert.Equal(t, 2.0, sw.AverageSamplesPerBucket(4*time.Second))
+	assert.Equal(t, 0.5, sw.AverageSamplesPerBucket(2*time.Second))
+}
+
+func TestCountsNegativeWindow(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Add(1)
+	assert.Equal(t, []int64{}, sw.Counts(-time.Second))
+	assert.Equal(t, 0.0, sw.AverageSamplesPerBucket(-time.Second))
+	assert.Equal(t, time.Duration(0), sw.Coverage(-time.Second))
+}
+
+func TestCountsWithoutCounts(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithoutCounts())
+	defer sw.Stop()
+
+	sw.Add(1)
+	assert.Nil(t, sw.Counts(4*time.Second))
+	assert.Equal(t, 0.0, sw.AverageSamplesPerBucket(4*time.Second))
+}
+
+func TestCopySamples(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Add(1)
+	sw.Add(2)
+	sw.Lock()
+	sw.shift()
+	sw.shift()
+	sw.Unlock()
+	sw.Add(4)
+
+	dst := make([]float64, 4)
+	counts := make([]int64, 4)
+	assert.Equal(t, 3, sw.CopySamples(dst, counts))
+	assert.Equal(t, []float64{4, 0, 3, 0}, dst)
+	assert.Equal(t, []int64{1, 0, 2, 0}, counts)
+
+	dst = make([]float64, 2)
+	assert.Equal(t, 2, sw.CopySamples(dst, nil))
+	assert.Equal(t, []float64{4, 0}, dst)
+
+	counts = make([]int64, 1)
+	assert.Equal(t, 3, sw.CopySamples(make([]float64, 3), counts))
+	assert.Equal(t, []int64{1}, counts)
+}
+
+func TestCopySamplesAllocs(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	dst := make([]float64, 4)
+	counts := make([]int64, 4)
+	allocs := testing.AllocsPerRun(100, func() {
+		sw.CopySamples(dst, counts)
+	})
+	assert.Equal(t, 0.0, allocs)
+}
@@ -0,0 +1,56 @@
+package average
+
+import "time"
+
+// IsFull returns true if all buckets of this window are in use, which is the
+// case once the window has rotated through all of its buckets since it was
+// created or Reset. Until then, queries over the whole window only cover the
+// time since then.
+func (sw *SlidingWindow) IsFull() bool {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	return sw.size >= sw.len()
+}
+
+// Coverage returns how much time the data of a query over the specified window
+// actually covers. Right after a window has been created, an Average over 10
+// minutes may only cover a few seconds. The current bucket counts for the time
+// that passed since it started.
+func (sw *SlidingWindow) Coverage(window time.Duration) time.Duration {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	return sw.coverage(sw.buckets(window))
+}
+
+// TotalCoverage returns the sum of all values over the specified window, the
+// number of samples, and how much time the data actually covers, like
+// Coverage. All three are determined under the same lock, so the buckets
+// can't rotate in between. A covered time of 0 tells a query that doesn't
+// cover any data, like Total(0), apart from a window without activity, and
+// lets callers normalize the total by the time it covers.
+func (sw *SlidingWindow) TotalCoverage(window time.Duration) (float64, int64, time.Duration) {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	total, count, n := sw.total(window)
+	return total, count, sw.coverage(n)
+}
+
+// coverage returns how much time the n most recent buckets cover. It must be
+// called with the lock held.
+func (sw *SlidingWindow) coverage(n int) time.Duration {
+	if n == 0 {
+		return 0
+	}
+
+	current := sw.now().Sub(sw.start)
+	if current > sw.granularity {
+		current = sw.granularity
+	} else if current < 0 {
+		current = 0
+	}
+
+	return time.Duration(n-1)*sw.granularity + current
+}
@@ -0,0 +1,71 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsFull(t *testing.T) {
+	sw := MustNew(3*time.Second, time.Second)
+	defer sw.Stop()
+
+	assert.False(t, sw.IsFull())
+
+	sw.Lock()
+	sw.shift()
+	sw.shift()
+	sw.Unlock()
+	assert.True(t, sw.IsFull())
+
+	sw.ResetData()
+	assert.True(t, sw.IsFull())
+
+	sw.Reset()
+	assert.False(t, sw.IsFull())
+}
+
+func TestCoverage(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Lock()
+	sw.start = time.Now().Add(-500 * time.Millisecond)
+	sw.Unlock()
+
+	assert.Equal(t, time.Duration(0), sw.Coverage(0))
+	assert.InDelta(t, float64(500*time.Millisecond), float64(sw.Coverage(10*time.Second)), float64(100*time.Millisecond))
+
+	sw.Lock()
+	sw.shift()
+	sw.shift()
+	sw.start = time.Now().Add(-5 * time.Second)
+	sw.Unlock()
+
+	assert.Equal(t, 2*time.Second, sw.Coverage(2*time.Second))
+	assert.Equal(t, 3*time.Second, sw.Coverage(10*time.Second))
+}
+
+func TestTotalCoverage(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second, WithManualClock())
+	defer sw.Stop()
+
+	total, count, covered := sw.TotalCoverage(0)
+	assert.Equal(t, 0.0, total)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, time.Duration(0), covered)
+
+	// A window without activity still covers time.
+	sw.Advance(2500 * time.Millisecond)
+	total, count, covered = sw.TotalCoverage(10 * time.Second)
+	assert.Equal(t, 0.0, total)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, 2500*time.Millisecond, covered)
+
+	sw.Add(4)
+	total, count, covered = sw.TotalCoverage(2 * time.Second)
+	assert.Equal(t, 4.0, total)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, 1500*time.Millisecond, covered)
+}
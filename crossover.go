@@ -0,0 +1,103 @@
+package average
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Signal is the outcome of a Crossover update.
+type Signal int
+
+const (
+	// NoCross means the fast average stayed on the same side of the slow
+	// average.
+	NoCross Signal = iota
+	// CrossAbove means the fast average crossed above the slow average.
+	CrossAbove
+	// CrossBelow means the fast average crossed below the slow average.
+	CrossBelow
+)
+
+// String returns the name of the signal.
+func (s Signal) String() string {
+	switch s {
+	case CrossAbove:
+		return "above"
+	case CrossBelow:
+		return "below"
+	}
+
+	return "none"
+}
+
+// Crossover detects when the average of a window over a short (fast) horizon
+// crosses the average over a long (slow) horizon, which is a common signal
+// that a trend is shifting.
+type Crossover struct {
+	sw         *SlidingWindow
+	fast, slow time.Duration
+	onCross    func(Signal)
+
+	mu    sync.Mutex
+	state Signal
+}
+
+// NewCrossover returns a new Crossover that compares the averages of sw over
+// the fast and slow horizons. If onCross is not nil, it is called for every
+// crossover that Update detects.
+func NewCrossover(sw *SlidingWindow, fast, slow time.Duration, onCross func(Signal)) (*Crossover, error) {
+	if sw == nil {
+		return nil, errors.New("window cannot be nil")
+	}
+	if fast <= 0 || fast >= slow {
+		return nil, errors.New("fast horizon has to be shorter than the slow horizon")
+	}
+
+	return &Crossover{sw: sw, fast: fast, slow: slow, onCross: onCross}, nil
+}
+
+// Update compares the current fast and slow averages and returns whether the
+// fast average crossed the slow one since the previous update. The first update
+// only establishes on which side the fast average is, and so does every update
+// while the averages are equal.
+func (c *Crossover) Update() Signal {
+	c.sw.RLock()
+	fast := c.sw.average(c.fast)
+	slow := c.sw.average(c.slow)
+	c.sw.RUnlock()
+
+	var state Signal
+	switch {
+	case fast > slow:
+		state = CrossAbove
+	case fast < slow:
+		state = CrossBelow
+	}
+
+	c.mu.Lock()
+	previous := c.state
+	if state != NoCross {
+		c.state = state
+	}
+	c.mu.Unlock()
+
+	if previous == NoCross || state == NoCross || state == previous {
+		return NoCross
+	}
+
+	if c.onCross != nil {
+		c.onCross(state)
+	}
+
+	return state
+}
+
+// Above returns true if the fast average was above the slow average as of the
+// most recent update.
+func (c *Crossover) Above() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.state == CrossAbove
+}
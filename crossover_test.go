@@ -0,0 +1,53 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCrossover(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+
+	_, err := NewCrossover(nil, time.Second, 10*time.Second, nil)
+	assert.EqualError(t, err, "window cannot be nil")
+
+	_, err = NewCrossover(sw, 10*time.Second, time.Second, nil)
+	assert.EqualError(t, err, "fast horizon has to be shorter than the slow horizon")
+}
+
+func TestCrossover(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+
+	var signals []Signal
+	c, err := NewCrossover(sw, time.Second, 10*time.Second, func(s Signal) {
+		signals = append(signals, s)
+	})
+	assert.NoError(t, err)
+
+	// Without data, both averages are equal.
+	assert.Equal(t, NoCross, c.Update())
+
+	sw.Add(10)
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+	sw.Add(20)
+
+	// The first side is established without a signal.
+	assert.Equal(t, NoCross, c.Update())
+	assert.True(t, c.Above())
+	assert.Equal(t, NoCross, c.Update())
+
+	sw.Add(-20)
+	assert.Equal(t, CrossBelow, c.Update())
+	assert.False(t, c.Above())
+
+	sw.Add(100)
+	assert.Equal(t, CrossAbove, c.Update())
+	assert.Equal(t, []Signal{CrossBelow, CrossAbove}, signals)
+	assert.Equal(t, "above", CrossAbove.String())
+}
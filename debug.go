@@ -0,0 +1,50 @@
+package average
+
+// DebugStats holds counters about the inner workings of a window since it was
+// created, so that operators can verify that a window behaves correctly in
+// production. The types that wrap a SlidingWindow, like a DistinctWindow, a
+// Meter or a Limiter, have a Debug method of their own, which returns the
+// counters of the window they wrap; those count every value that is added to
+// the wrapper as well. A QuotaTracker keeps a window per tenant and a
+// CalendarWindow doesn't wrap a SlidingWindow, so those don't have one.
+type DebugStats struct {
+	// Adds is the number of times a value was added to the window.
+	Adds uint64
+	// Dropped is the number of values that were discarded, because the
+	// window was stopped, because AddAt was too late for them, or because
+	// TryAdd rejected them.
+	Dropped uint64
+	// Rotations is the number of times the buckets rotated.
+	Rotations uint64
+	// MissedTicks is the number of rotations that the shifter fell behind on,
+	// for instance because the process was suspended or overloaded. Windows
+	// created WithDriftCorrection catch up on these, other windows lag
+	// behind by them.
+	MissedTicks uint64
+}
+
+// Debug returns the DebugStats of this window.
+func (sw *SlidingWindow) Debug() DebugStats {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	return DebugStats{
+		Adds:        sw.adds,
+		Dropped:     sw.dropped,
+		Rotations:   sw.rotations,
+		MissedTicks: sw.missedTicks,
+	}
+}
+
+// countAdd counts a value that a type that wraps this window adds to its own
+// buckets, and returns whether it may, which it may not once the window was
+// stopped. It must be called with the lock held.
+func (sw *SlidingWindow) countAdd() bool {
+	if sw.stopped {
+		sw.dropped++
+		return false
+	}
+
+	sw.adds++
+	return true
+}
@@ -0,0 +1,106 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebug(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sw := MustNewReplay(4*time.Second, time.Second, start)
+
+	sw.Add(1)
+	sw.AddN(2, 2)
+	assert.True(t, sw.AddAt(start.Add(2500*time.Millisecond), 3))
+	assert.False(t, sw.AddAt(start, 4))
+
+	sw.Stop()
+	sw.Add(5)
+
+	assert.Equal(t, DebugStats{Adds: 3, Dropped: 2, Rotations: 2}, sw.Debug())
+}
+
+func TestDebugMissedTicks(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Lock()
+	sw.start = time.Now().Add(-3500 * time.Millisecond)
+	sw.rotate()
+	sw.rotate()
+	sw.Unlock()
+
+	// The missed ticks are only counted once while the window lags behind.
+	assert.Equal(t, uint64(2), sw.Debug().MissedTicks)
+	assert.Equal(t, uint64(2), sw.Debug().Rotations)
+
+	drift := MustNew(10*time.Second, time.Second, WithDriftCorrection())
+	defer drift.Stop()
+
+	drift.Lock()
+	drift.start = time.Now().Add(-3500 * time.Millisecond)
+	drift.rotate()
+	drift.rotate()
+	drift.Unlock()
+
+	assert.Equal(t, DebugStats{Rotations: 3, MissedTicks: 2}, drift.Debug())
+}
+
+func TestDebugWrappers(t *testing.T) {
+	type wrapper interface {
+		Debug() DebugStats
+		Stop()
+	}
+
+	aw := MustNewAggregateWindow(3*time.Second, time.Second, newRangeAggregator)
+	a := MustNewAvailability(3*time.Second, time.Second)
+	dec := MustNewDecimalWindow(3*time.Second, time.Second, 2)
+	dw := MustNewDistinctWindow(3*time.Second, time.Second, 10)
+	hw := MustNewHistogramWindow(3*time.Second, time.Second, 1)
+	lw := MustNewLatencyWindow(3*time.Second, time.Second, time.Microsecond, time.Minute, 3)
+	pw := MustNewPairedWindow(3*time.Second, time.Second)
+	tw := MustNewTopKWindow(3*time.Second, time.Second, 2)
+	vw := MustNewVectorWindow(3*time.Second, time.Second, "x")
+	m := NewMeter()
+	tiered := MustNewTieredWindow(10*time.Second, time.Second, 4*time.Second, 2*time.Second)
+
+	add := func() {
+		aw.Add(1)
+		a.Add(true)
+		dec.AddUnits(1)
+		dw.Add("a")
+		hw.Add(1)
+		lw.Record(time.Millisecond)
+		pw.Add(1, 2)
+		tw.Add("a", 1)
+		vw.AddValues(1)
+		m.Mark(1)
+		tiered.Add(1)
+	}
+
+	wrappers := []wrapper{aw, a, dec, dw, hw, lw, pw, tw, vw, m, tiered}
+	add()
+	for _, w := range wrappers {
+		w.Stop()
+	}
+	add()
+
+	for i, w := range wrappers {
+		stats := w.Debug()
+		assert.Equal(t, uint64(1), stats.Adds, "wrapper %d", i)
+		assert.Equal(t, uint64(1), stats.Dropped, "wrapper %d", i)
+	}
+}
+
+func TestDebugLimiter(t *testing.T) {
+	l := MustNewLimiter(10, 10*time.Second, time.Second, 10)
+	now := l.last
+
+	assert.True(t, l.AllowN(now, 2))
+	assert.True(t, l.AllowN(now.Add(2*time.Second), 1))
+	assert.False(t, l.AllowN(now, 1))
+
+	assert.Equal(t, DebugStats{Adds: 2, Dropped: 1, Rotations: 2}, l.Debug())
+}
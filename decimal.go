@@ -0,0 +1,146 @@
+package average
+
+import (
+	"errors"
+	"math/big"
+	"time"
+)
+
+// DecimalWindow is a sliding time window that sums decimal values, like
+// monetary amounts, exactly. Every value is stored as an integer number of
+// units of 10^-places, so the totals don't pick up the rounding errors that
+// summing float64 values does.
+type DecimalWindow struct {
+	sw     *SlidingWindow
+	sums   []big.Int
+	places int
+	scale  *big.Int // 10^places
+}
+
+// MustNewDecimalWindow returns a new DecimalWindow, but panics if an error
+// occurs.
+func MustNewDecimalWindow(window, granularity time.Duration, places int) *DecimalWindow {
+	dw, err := NewDecimalWindow(window, granularity, places)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return dw
+}
+
+// NewDecimalWindow returns a new DecimalWindow that keeps values exact to the
+// specified number of decimal places, like 2 for cents.
+func NewDecimalWindow(window, granularity time.Duration, places int) (*DecimalWindow, error) {
+	if places < 0 {
+		return nil, errors.New("decimal places cannot be negative")
+	}
+
+	sw, err := newSlidingWindow(window, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	dw := &DecimalWindow{
+		sw:     sw,
+		sums:   make([]big.Int, sw.len()),
+		places: places,
+		scale:  new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil),
+	}
+	sw.onClear = dw.clear
+
+	sw.startShifter()
+	return dw, nil
+}
+
+func (dw *DecimalWindow) clear(pos int) {
+	dw.sums[pos].SetInt64(0)
+}
+
+// Add adds the decimal value v, like "12.34" or "-0.5", to the current bucket.
+// It returns an error if v isn't a decimal number or has more decimal places
+// than this window keeps, and ErrStopped if the window is stopped.
+func (dw *DecimalWindow) Add(v string) error {
+	r, ok := new(big.Rat).SetString(v)
+	if !ok {
+		return errors.New("invalid decimal value " + v)
+	}
+
+	r.Mul(r, new(big.Rat).SetInt(dw.scale))
+	if !r.IsInt() {
+		return errors.New("decimal value " + v + " has too many decimal places")
+	}
+
+	return dw.add(r.Num())
+}
+
+// AddUnits adds n units of 10^-places to the current bucket, like 1234 cents
+// for a window with 2 decimal places. It returns ErrStopped if the window is
+// stopped.
+func (dw *DecimalWindow) AddUnits(n int64) error {
+	return dw.add(big.NewInt(n))
+}
+
+func (dw *DecimalWindow) add(units *big.Int) error {
+	dw.sw.Lock()
+	defer dw.sw.Unlock()
+
+	if !dw.sw.countAdd() {
+		return ErrStopped
+	}
+
+	dw.sums[dw.sw.pos].Add(&dw.sums[dw.sw.pos], units)
+	dw.sw.increment(dw.sw.pos, 0, 1)
+	return nil
+}
+
+// Total returns the exact sum of all values over the specified window with the
+// number of decimal places of this window, like "1234.50", as well as the
+// number of values.
+func (dw *DecimalWindow) Total(window time.Duration) (string, int64) {
+	units, count := dw.total(window)
+	return dw.format(new(big.Rat).SetInt(units)), count
+}
+
+// Average returns the mean of the specified window, rounded half away from
+// zero to the number of decimal places of this window.
+func (dw *DecimalWindow) Average(window time.Duration) string {
+	units, count := dw.total(window)
+	if count == 0 {
+		return dw.format(new(big.Rat))
+	}
+
+	return dw.format(new(big.Rat).SetFrac(units, big.NewInt(count)))
+}
+
+// total returns the sum of the specified window in units and the number of
+// values.
+func (dw *DecimalWindow) total(window time.Duration) (*big.Int, int64) {
+	dw.sw.RLock()
+	defer dw.sw.RUnlock()
+
+	units := new(big.Int)
+	var count int64
+	for i, n := 0, dw.sw.buckets(window); i < n; i++ {
+		pos := dw.sw.index(i)
+		units.Add(units, &dw.sums[pos])
+		count += dw.sw.count(pos)
+	}
+
+	return units, count
+}
+
+// format formats an amount of units as a decimal number.
+func (dw *DecimalWindow) format(units *big.Rat) string {
+	return units.Quo(units, new(big.Rat).SetInt(dw.scale)).FloatString(dw.places)
+}
+
+// Debug returns the DebugStats of this decimal window.
+func (dw *DecimalWindow) Debug() DebugStats {
+	return dw.sw.Debug()
+}
+
+// Stop the shifter of this decimal window. A stopped DecimalWindow cannot be
+// started again.
+func (dw *DecimalWindow) Stop() {
+	dw.sw.Stop()
+}
@@ -0,0 +1,71 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecimalWindow(t *testing.T) {
+	dw := MustNewDecimalWindow(4*time.Second, time.Second, 2)
+	defer dw.Stop()
+
+	total, count := dw.Total(4 * time.Second)
+	assert.Equal(t, "0.00", total)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, "0.00", dw.Average(4*time.Second))
+
+	// 0.1 + 0.2 isn't 0.3 in float64.
+	assert.NoError(t, dw.Add("0.1"))
+	assert.NoError(t, dw.Add("0.2"))
+	total, _ = dw.Total(time.Second)
+	assert.Equal(t, "0.30", total)
+
+	dw.sw.Lock()
+	dw.sw.shift()
+	dw.sw.Unlock()
+	assert.NoError(t, dw.Add("-1.5"))
+	assert.NoError(t, dw.AddUnits(5))
+
+	total, count = dw.Total(4 * time.Second)
+	assert.Equal(t, "-1.15", total)
+	assert.Equal(t, int64(4), count)
+	assert.Equal(t, "-0.29", dw.Average(4*time.Second))
+
+	total, count = dw.Total(time.Second)
+	assert.Equal(t, "-1.45", total)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, "-0.73", dw.Average(time.Second))
+}
+
+func TestDecimalWindowErrors(t *testing.T) {
+	_, err := NewDecimalWindow(4*time.Second, time.Second, -1)
+	assert.EqualError(t, err, "decimal places cannot be negative")
+
+	_, err = NewDecimalWindow(time.Second, time.Second, 2)
+	assert.EqualError(t, err, "window size has to be a multiplier of the granularity size")
+
+	dw := MustNewDecimalWindow(4*time.Second, time.Second, 2)
+	defer dw.Stop()
+
+	assert.EqualError(t, dw.Add("1.234"), "decimal value 1.234 has too many decimal places")
+	assert.EqualError(t, dw.Add("one"), "invalid decimal value one")
+
+	total, count := dw.Total(4 * time.Second)
+	assert.Equal(t, "0.00", total)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestDecimalWindowStopped(t *testing.T) {
+	dw := MustNewDecimalWindow(4*time.Second, time.Second, 2)
+	assert.NoError(t, dw.Add("1.25"))
+	dw.Stop()
+
+	assert.Equal(t, ErrStopped, dw.Add("1.25"))
+	assert.Equal(t, ErrStopped, dw.AddUnits(100))
+
+	total, count := dw.Total(4 * time.Second)
+	assert.Equal(t, "1.25", total)
+	assert.Equal(t, int64(1), count)
+}
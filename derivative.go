@@ -0,0 +1,35 @@
+package average
+
+import "time"
+
+// Derivative returns how fast the total of this window changes over the
+// specified window, in units per second. It splits the completed buckets in
+// range into a newer and an older half, and divides the difference between
+// the totals of both halves by the time between their midpoints. The current
+// bucket is left out, as it hasn't filled up yet, and so is the middle bucket
+// if the number of completed buckets is odd. A positive result means the rate
+// is accelerating, a negative one that it is slowing down, and a steady rate
+// returns 0. It returns 0 if the window covers fewer than 2 completed buckets.
+func (sw *SlidingWindow) Derivative(window time.Duration) float64 {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	n := sw.buckets(window) - 1
+	half := n / 2
+	if half <= 0 {
+		return 0
+	}
+
+	var newer, older float64
+	for i := 0; i < half; i++ {
+		if !sw.open(1 + i) {
+			newer += sw.sum(sw.index(1 + i))
+		}
+		if !sw.open(n - i) {
+			older += sw.sum(sw.index(n - i))
+		}
+	}
+
+	distance := (time.Duration(n-half) * sw.granularity).Seconds()
+	return (newer - older) / distance
+}
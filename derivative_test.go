@@ -0,0 +1,58 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDerivative(t *testing.T) {
+	sw := &SlidingWindow{
+		window:      4 * time.Second,
+		granularity: time.Second,
+		samples:     []float64{30, 5, 10, 20},
+		counts:      []int64{1, 1, 1, 1},
+		pos:         1,
+		size:        4,
+	}
+
+	// The completed buckets from old to new are 10, 20 and 30, so the total
+	// goes up by 10 per second. The current bucket is left out.
+	assert.Equal(t, 10.0, sw.Derivative(4*time.Second))
+	assert.Equal(t, 10.0, sw.Derivative(3*time.Second))
+	assert.Equal(t, 0.0, sw.Derivative(2*time.Second))
+	assert.Equal(t, 0.0, sw.Derivative(time.Second))
+	assert.Equal(t, 0.0, sw.Derivative(-time.Second))
+
+	sw.samples = []float64{10, 5, 30, 20}
+	assert.Equal(t, -10.0, sw.Derivative(4*time.Second))
+}
+
+func TestDerivativeGranularity(t *testing.T) {
+	sw := &SlidingWindow{
+		window:      2500 * time.Millisecond,
+		granularity: 500 * time.Millisecond,
+		samples:     []float64{1, 1, 3, 3, 0},
+		counts:      []int64{1, 1, 1, 1, 0},
+		pos:         4,
+		size:        5,
+	}
+
+	// The total of a second goes from 2 to 6, 1 second apart.
+	assert.Equal(t, 4.0, sw.Derivative(2500*time.Millisecond))
+}
+
+func TestDerivativeSteadyRate(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	sw := MustNewReplay(10*time.Second, time.Second, start)
+	defer sw.Stop()
+
+	// A value every 100ms, up to the middle of the current bucket.
+	for d := time.Duration(0); d < 7300*time.Millisecond; d += 100 * time.Millisecond {
+		sw.AddAt(start.Add(d), 1)
+	}
+
+	assert.InDelta(t, 0, sw.Derivative(10*time.Second), 1e-9)
+	assert.InDelta(t, 0, sw.Derivative(4*time.Second), 1e-9)
+}
@@ -0,0 +1,120 @@
+package average
+
+import "time"
+
+// DistinctWindow is a sliding time window that estimates the number of
+// distinct items that were added to it, such as the number of unique users
+// over the last 5 minutes. Every bucket keeps a HyperLogLog sketch, which are
+// merged when the cardinality of a subset of the window is requested.
+type DistinctWindow struct {
+	sw        *SlidingWindow
+	sketches  []*hyperLogLog
+	precision uint8
+}
+
+// MustNewDistinctWindow returns a new DistinctWindow, but panics if an error
+// occurs.
+func MustNewDistinctWindow(window, granularity time.Duration, precision uint8) *DistinctWindow {
+	dw, err := NewDistinctWindow(window, granularity, precision)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return dw
+}
+
+// NewDistinctWindow returns a new DistinctWindow. The precision has to be
+// between 4 and 16 and determines the standard error of the estimate, which is
+// roughly 1.04/sqrt(2^precision), as well as the 2^precision bytes that every
+// bucket uses once an item has been added to it.
+func NewDistinctWindow(window, granularity time.Duration, precision uint8) (*DistinctWindow, error) {
+	if _, err := newHyperLogLog(precision); err != nil {
+		return nil, err
+	}
+
+	sw, err := newSlidingWindow(window, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	dw := &DistinctWindow{
+		sw:        sw,
+		sketches:  make([]*hyperLogLog, sw.len()),
+		precision: precision,
+	}
+	sw.onClear = dw.clear
+
+	sw.startShifter()
+	return dw, nil
+}
+
+func (dw *DistinctWindow) clear(pos int) {
+	if s := dw.sketches[pos]; s != nil {
+		s.reset()
+	}
+}
+
+// Add records item in the current bucket.
+func (dw *DistinctWindow) Add(item string) {
+	hash := hashString(item)
+
+	dw.sw.Lock()
+	defer dw.sw.Unlock()
+
+	if !dw.sw.countAdd() {
+		return
+	}
+
+	s := dw.sketches[dw.sw.pos]
+	if s == nil {
+		s, _ = newHyperLogLog(dw.precision)
+		dw.sketches[dw.sw.pos] = s
+	}
+
+	s.add(hash)
+	dw.sw.increment(dw.sw.pos, 0, 1)
+}
+
+// Cardinality returns the estimated number of distinct items that were added
+// over the specified window.
+func (dw *DistinctWindow) Cardinality(window time.Duration) uint64 {
+	dw.sw.RLock()
+	defer dw.sw.RUnlock()
+
+	var merged *hyperLogLog
+	for i, n := 0, dw.sw.buckets(window); i < n; i++ {
+		s := dw.sketches[dw.sw.index(i)]
+		if s == nil {
+			continue
+		}
+
+		if merged == nil {
+			merged, _ = newHyperLogLog(dw.precision)
+		}
+		merged.merge(s)
+	}
+
+	if merged == nil {
+		return 0
+	}
+
+	return merged.cardinality()
+}
+
+// Count returns the number of items, including duplicates, that were added
+// over the specified window.
+func (dw *DistinctWindow) Count(window time.Duration) int64 {
+	_, count := dw.sw.Total(window)
+	return count
+}
+
+// Debug returns the DebugStats of this distinct window.
+func (dw *DistinctWindow) Debug() DebugStats {
+	return dw.sw.Debug()
+}
+
+// Stop the shifter of this distinct window. A stopped DistinctWindow cannot be
+// started again.
+func (dw *DistinctWindow) Stop() {
+	dw.sw.Stop()
+}
@@ -0,0 +1,58 @@
+package average
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDistinctWindow(t *testing.T) {
+	_, err := NewDistinctWindow(10*time.Second, time.Second, 20)
+	assert.EqualError(t, err, "precision has to be between 4 and 16")
+
+	_, err = NewDistinctWindow(0, time.Second, 10)
+	assert.EqualError(t, err, "window cannot be 0")
+}
+
+func TestDistinctWindowCardinality(t *testing.T) {
+	dw := MustNewDistinctWindow(3*time.Second, time.Second, 12)
+	defer dw.Stop()
+
+	assert.Equal(t, uint64(0), dw.Cardinality(3*time.Second))
+
+	for i := 0; i < 100; i++ {
+		dw.Add("user-" + strconv.Itoa(i%20))
+	}
+
+	dw.sw.Lock()
+	dw.sw.shift()
+	dw.sw.Unlock()
+
+	for i := 0; i < 100; i++ {
+		dw.Add("user-" + strconv.Itoa(10+i%20))
+	}
+
+	assert.Equal(t, uint64(20), dw.Cardinality(time.Second))
+	assert.Equal(t, uint64(30), dw.Cardinality(3*time.Second))
+	assert.Equal(t, int64(200), dw.Count(3*time.Second))
+
+	// Once the first bucket expires, its users no longer count.
+	dw.sw.Lock()
+	dw.sw.shift()
+	dw.sw.shift()
+	dw.sw.Unlock()
+
+	assert.Equal(t, uint64(20), dw.Cardinality(3*time.Second))
+}
+
+func TestDistinctWindowStopped(t *testing.T) {
+	dw := MustNewDistinctWindow(4*time.Second, time.Second, 10)
+	dw.Add("a")
+	dw.Stop()
+	dw.Add("b")
+
+	assert.Equal(t, uint64(1), dw.Cardinality(4*time.Second))
+	assert.Equal(t, int64(1), dw.Count(4*time.Second))
+}
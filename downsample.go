@@ -0,0 +1,44 @@
+package average
+
+import (
+	"errors"
+	"time"
+)
+
+// Downsample returns a copy of s with a coarser granularity, whose buckets
+// each combine a group of the original buckets, like a compact 10-bucket
+// summary of a 600-bucket window for a dashboard. The buckets are grouped by
+// age, so the newest bucket of the result combines the newest buckets of s.
+// The granularity has to be a multiplier of the granularity of s, and the
+// window of s a multiplier of the granularity. An error is returned if s has
+// an invalid number of buckets.
+func (s Snapshot) Downsample(granularity time.Duration) (Snapshot, error) {
+	if err := validate(s.Window, granularity); err != nil {
+		return Snapshot{}, err
+	}
+	if s.Granularity <= 0 || granularity%s.Granularity != 0 {
+		return Snapshot{}, errors.New("granularity has to be a multiplier of the snapshot granularity")
+	}
+	if err := s.checkBuckets(int(s.Window / s.Granularity)); err != nil {
+		return Snapshot{}, err
+	}
+
+	k := int(granularity / s.Granularity)
+	n := (len(s.Samples) + k - 1) / k
+
+	d := Snapshot{
+		Window:      s.Window,
+		Granularity: granularity,
+		Time:        s.Time,
+		Start:       s.Start.Add(-time.Duration(k-1) * s.Granularity),
+		Samples:     make([]float64, n),
+		Counts:      make([]int64, n),
+	}
+
+	for i := range s.Samples {
+		d.Samples[i/k] += s.Samples[i]
+		d.Counts[i/k] += s.Counts[i]
+	}
+
+	return d, nil
+}
@@ -0,0 +1,62 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownsample(t *testing.T) {
+	now := time.Now()
+	s := Snapshot{
+		Window:      6 * time.Second,
+		Granularity: time.Second,
+		Time:        now,
+		Start:       now,
+		Samples:     []float64{1, 2, 3, 4, 5},
+		Counts:      []int64{1, 1, 2, 2, 3},
+	}
+
+	d, err := s.Downsample(2 * time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, Snapshot{
+		Window:      6 * time.Second,
+		Granularity: 2 * time.Second,
+		Time:        now,
+		Start:       now.Add(-time.Second),
+		Samples:     []float64{3, 7, 5},
+		Counts:      []int64{2, 4, 3},
+	}, d)
+
+	total, count := d.Total(6 * time.Second)
+	assert.Equal(t, 15.0, total)
+	assert.Equal(t, int64(9), count)
+
+	d, err = s.Downsample(3 * time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{6, 9}, d.Samples)
+	assert.Equal(t, []int64{4, 5}, d.Counts)
+}
+
+func TestDownsampleErrors(t *testing.T) {
+	s := Snapshot{Window: 6 * time.Second, Granularity: 2 * time.Second}
+
+	_, err := s.Downsample(3 * time.Second)
+	assert.EqualError(t, err, "granularity has to be a multiplier of the snapshot granularity")
+
+	_, err = s.Downsample(4 * time.Second)
+	assert.EqualError(t, err, "window size has to be a multiplier of the granularity size")
+
+	_, err = s.Downsample(0)
+	assert.EqualError(t, err, "granularity cannot be 0")
+
+	// Fewer counts than samples, and more buckets than the window has.
+	s = Snapshot{Window: 8 * time.Second, Granularity: 2 * time.Second, Samples: []float64{1, 2}, Counts: []int64{1}}
+	_, err = s.Downsample(4 * time.Second)
+	assert.EqualError(t, err, "snapshot has an invalid number of buckets")
+
+	s.Samples, s.Counts = make([]float64, 5), make([]int64, 5)
+	_, err = s.Downsample(4 * time.Second)
+	assert.EqualError(t, err, "snapshot has an invalid number of buckets")
+}
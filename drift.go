@@ -0,0 +1,43 @@
+package average
+
+import "time"
+
+// WithDriftCorrection rotates the buckets based on the time that has passed
+// since the window was created, instead of by one bucket on every tick of the
+// shifter. A ticker drifts and drops ticks when the shifter falls behind,
+// which smears the data of windows with a very fine granularity, like
+// microseconds, across the wrong buckets. With this option every tick moves
+// the current bucket to the one that contains the current time, and clears
+// any buckets that were skipped along the way.
+func WithDriftCorrection() Option {
+	return func(sw *SlidingWindow) error {
+		sw.driftCorrect = true
+		return nil
+	}
+}
+
+// rotate moves the current position forward for a tick of the shifter, and
+// keeps track of the ticks that the shifter missed. It must be called with
+// the lock held.
+func (sw *SlidingWindow) rotate() {
+	now := time.Now()
+	steps := int64(now.Sub(sw.start) / sw.granularity)
+
+	if sw.driftCorrect {
+		if steps > 1 {
+			sw.missedTicks += uint64(steps - 1)
+		}
+
+		sw.advanceTo(now)
+		return
+	}
+
+	// Without drift correction, a missed tick leaves the current bucket
+	// behind for good, so only an increase of the lag is a missed tick.
+	sw.shift()
+	sw.refreshView()
+	if lag := steps - 1; lag > sw.lag {
+		sw.missedTicks += uint64(lag - sw.lag)
+		sw.lag = lag
+	}
+}
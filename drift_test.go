@@ -0,0 +1,56 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithDriftCorrection(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second, WithDriftCorrection())
+	defer sw.Stop()
+
+	sw.Lock()
+	start := time.Now().Add(-3500 * time.Millisecond)
+	sw.start = start
+	sw.add(1, 1)
+
+	// A late tick skips the buckets that passed in the meantime.
+	sw.rotate()
+	sw.add(2, 1)
+	assert.Equal(t, start.Add(3*time.Second), sw.start)
+	assert.Equal(t, 3, sw.pos)
+	assert.Equal(t, 4, sw.size)
+
+	// An early tick doesn't rotate at all.
+	sw.rotate()
+	assert.Equal(t, 3, sw.pos)
+	sw.Unlock()
+
+	assert.Equal(t, []int64{1, 0, 0, 1}, sw.Counts(10*time.Second))
+}
+
+func TestWithoutDriftCorrection(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Lock()
+	sw.start = time.Now().Add(-3500 * time.Millisecond)
+	sw.rotate()
+	assert.Equal(t, 1, sw.pos)
+	sw.Unlock()
+}
+
+func TestWithDriftCorrectionShifter(t *testing.T) {
+	sw := MustNew(time.Millisecond, 10*time.Microsecond, WithDriftCorrection())
+	defer sw.Stop()
+
+	time.Sleep(20 * time.Millisecond)
+
+	sw.RLock()
+	defer sw.RUnlock()
+
+	assert.Equal(t, sw.len(), sw.size)
+	assert.True(t, time.Since(sw.start) < 10*time.Millisecond)
+}
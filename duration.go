@@ -0,0 +1,49 @@
+package average
+
+import "time"
+
+// AddDuration adds the duration d, in nanoseconds, to the current sample.
+func (sw *SlidingWindow) AddDuration(d time.Duration) {
+	sw.Add(float64(d))
+}
+
+// AverageDuration returns the mean of the durations added over the specified
+// window.
+func (sw *SlidingWindow) AverageDuration(window time.Duration) time.Duration {
+	return time.Duration(sw.Average(window))
+}
+
+// MaxDuration returns the longest duration added over the specified window. It
+// requires the window to be created with WithExtrema, and returns 0 otherwise.
+func (sw *SlidingWindow) MaxDuration(window time.Duration) time.Duration {
+	return time.Duration(sw.Max(window))
+}
+
+// Time runs f and adds the time it took to the current sample.
+func (sw *SlidingWindow) Time(f func()) time.Duration {
+	s := sw.Stopwatch()
+	f()
+	return s.Stop()
+}
+
+// Stopwatch returns a running Stopwatch that adds the time that elapsed to this
+// window when it is stopped. This is convenient to time a function:
+//
+//	defer sw.Stopwatch().Stop()
+func (sw *SlidingWindow) Stopwatch() Stopwatch {
+	return Stopwatch{sw: sw, start: time.Now()}
+}
+
+// Stopwatch measures the time between its creation and a call to Stop.
+type Stopwatch struct {
+	sw    *SlidingWindow
+	start time.Time
+}
+
+// Stop adds the time that elapsed since the stopwatch was started to its
+// window, and returns it.
+func (s Stopwatch) Stop() time.Duration {
+	d := time.Since(s.start)
+	s.sw.AddDuration(d)
+	return d
+}
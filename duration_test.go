@@ -0,0 +1,36 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDurations(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second, WithExtrema())
+	defer sw.Stop()
+
+	sw.AddDuration(10 * time.Millisecond)
+	sw.AddDuration(30 * time.Millisecond)
+
+	assert.Equal(t, 20*time.Millisecond, sw.AverageDuration(10*time.Second))
+	assert.Equal(t, 30*time.Millisecond, sw.MaxDuration(10*time.Second))
+}
+
+func TestTime(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+
+	d := sw.Time(func() { time.Sleep(5 * time.Millisecond) })
+	assert.True(t, d >= 5*time.Millisecond)
+
+	func() {
+		defer sw.Stopwatch().Stop()
+		time.Sleep(5 * time.Millisecond)
+	}()
+
+	total, count := sw.Total(10 * time.Second)
+	assert.Equal(t, int64(2), count)
+	assert.True(t, time.Duration(total) >= 10*time.Millisecond)
+}
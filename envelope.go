@@ -0,0 +1,35 @@
+package average
+
+import "time"
+
+// Envelope returns the smallest value, the average and the largest value of
+// every bucket in the specified window, newest first, in three slices of the
+// same length. This is the data to chart the average with a shaded band
+// between the extrema around it. Buckets without samples are 0 in all three
+// slices. The extrema are only kept by windows created WithExtrema, so for
+// other windows mins and maxs are nil.
+func (sw *SlidingWindow) Envelope(window time.Duration) (mins, avgs, maxs []float64) {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	n := sw.buckets(window)
+	avgs = make([]float64, n)
+	if sw.mins != nil {
+		mins = make([]float64, n)
+		maxs = make([]float64, n)
+	}
+
+	for i := 0; i < n; i++ {
+		if sw.open(i) {
+			continue
+		}
+
+		pos := sw.index(i)
+		avgs[i], _ = sw.bucketAverage(pos)
+		if mins != nil && sw.count(pos) > 0 {
+			mins[i], maxs[i] = sw.mins[pos], sw.maxs[pos]
+		}
+	}
+
+	return mins, avgs, maxs
+}
@@ -0,0 +1,44 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelope(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithExtrema())
+	defer sw.Stop()
+
+	sw.Add(1)
+	sw.Add(5)
+	sw.Lock()
+	sw.shift()
+	sw.shift()
+	sw.Unlock()
+	sw.Add(-2)
+
+	mins, avgs, maxs := sw.Envelope(4 * time.Second)
+	assert.Equal(t, []float64{-2, 0, 1}, mins)
+	assert.Equal(t, []float64{-2, 0, 3}, avgs)
+	assert.Equal(t, []float64{-2, 0, 5}, maxs)
+
+	mins, avgs, maxs = sw.Envelope(time.Second)
+	assert.Equal(t, []float64{-2}, mins)
+	assert.Equal(t, []float64{-2}, avgs)
+	assert.Equal(t, []float64{-2}, maxs)
+}
+
+func TestEnvelopeWithoutExtrema(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Add(4)
+	sw.Add(2)
+
+	mins, avgs, maxs := sw.Envelope(4 * time.Second)
+	assert.Nil(t, mins)
+	assert.Equal(t, []float64{3}, avgs)
+	assert.Nil(t, maxs)
+}
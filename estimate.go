@@ -0,0 +1,78 @@
+package average
+
+import (
+	"errors"
+	"time"
+)
+
+// WithPercentileEstimate estimates the p-th percentile, with p between 0 and
+// 100 exclusive, of the values in every bucket with a P² estimator. Unlike
+// WithReservoir, this uses a small, fixed amount of memory per bucket no
+// matter how many values are added, at the cost of only estimating a single
+// percentile that has to be chosen upfront.
+func WithPercentileEstimate(p float64) Option {
+	return func(sw *SlidingWindow) error {
+		if p <= 0 || p >= 100 {
+			return errors.New("percentile has to be between 0 and 100")
+		}
+
+		sw.estimates = make([]p2, sw.len())
+		for i := range sw.estimates {
+			sw.estimates[i] = newP2(p / 100)
+		}
+		sw.estimateP = p
+		return nil
+	}
+}
+
+// PercentileEstimate returns the percentile that the window estimates, as
+// passed to WithPercentileEstimate, and false if the window was not created
+// with it.
+func (sw *SlidingWindow) PercentileEstimate() (float64, bool) {
+	if sw.estimates == nil {
+		return 0, false
+	}
+
+	return sw.estimateP, true
+}
+
+// EstimatedPercentile returns the estimate of the percentile that was passed
+// to WithPercentileEstimate over the specified window. This is the mean of the
+// estimates of the buckets in the window, weighted by their number of samples,
+// which is an approximation when the values vary between buckets. It returns
+// 0 if there are no values or the window was not created with
+// WithPercentileEstimate.
+func (sw *SlidingWindow) EstimatedPercentile(window time.Duration) float64 {
+	v, _ := sw.EstimatedPercentileOK(window)
+	return v
+}
+
+// EstimatedPercentileOK returns the estimate like EstimatedPercentile, and
+// whether there was any value to estimate it from, so that an estimate of 0
+// can be told apart from a window without values.
+func (sw *SlidingWindow) EstimatedPercentileOK(window time.Duration) (float64, bool) {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	if sw.estimates == nil {
+		return 0, false
+	}
+
+	var total float64
+	var count int
+	for i, n := 0, sw.buckets(window); i < n; i++ {
+		if sw.open(i) {
+			continue
+		}
+
+		e := &sw.estimates[sw.index(i)]
+		total += e.value() * float64(e.count)
+		count += e.count
+	}
+
+	if count == 0 {
+		return 0, false
+	}
+
+	return total / float64(count), true
+}
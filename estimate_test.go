@@ -0,0 +1,70 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithPercentileEstimate(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithPercentileEstimate(95))
+	defer sw.Stop()
+
+	assert.Equal(t, 0.0, sw.EstimatedPercentile(4*time.Second))
+
+	for i := 1; i <= 1000; i++ {
+		sw.Add(float64(i))
+	}
+	assert.InDelta(t, 950, sw.EstimatedPercentile(time.Second), 5)
+
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+	for i := 1; i <= 1000; i++ {
+		sw.Add(float64(i) + 1000)
+	}
+
+	assert.InDelta(t, 1950, sw.EstimatedPercentile(time.Second), 5)
+	assert.InDelta(t, 1450, sw.EstimatedPercentile(4*time.Second), 5)
+
+	sw.Reset()
+	assert.Equal(t, 0.0, sw.EstimatedPercentile(4*time.Second))
+}
+
+func TestWithPercentileEstimateErrors(t *testing.T) {
+	_, err := New(4*time.Second, time.Second, WithPercentileEstimate(0))
+	assert.EqualError(t, err, "percentile has to be between 0 and 100")
+
+	_, err = New(4*time.Second, time.Second, WithPercentileEstimate(100))
+	assert.EqualError(t, err, "percentile has to be between 0 and 100")
+
+	_, err = New(4*time.Second, time.Second, WithoutCounts(), WithPercentileEstimate(50))
+	assert.EqualError(t, err, "percentile estimates require sample counts")
+
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+	sw.Add(1)
+	assert.Equal(t, 0.0, sw.EstimatedPercentile(4*time.Second))
+}
+
+func TestEstimatedPercentileOK(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithPercentileEstimate(99))
+	defer sw.Stop()
+
+	p, ok := sw.PercentileEstimate()
+	assert.True(t, ok)
+	assert.Equal(t, 99.0, p)
+
+	_, ok = sw.EstimatedPercentileOK(4 * time.Second)
+	assert.False(t, ok)
+	sw.Add(0)
+	v, ok := sw.EstimatedPercentileOK(4 * time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 0.0, v)
+
+	plain := MustNew(4*time.Second, time.Second)
+	defer plain.Stop()
+	_, ok = plain.PercentileEstimate()
+	assert.False(t, ok)
+}
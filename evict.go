@@ -0,0 +1,81 @@
+package average
+
+import (
+	"errors"
+	"time"
+)
+
+// WithOnEvict calls fn with every bucket that falls off the end of the window,
+// right before it is cleared, so that its data can be archived to long-term
+// storage. Buckets that nothing was added to are not passed to fn, but those
+// whose values add up to 0 are. The function is called by the
+// goroutine that rotated the buckets, after it released the lock of the
+// window, so it may call methods on the window. It should return quickly,
+// though, as the shifter waits for it. For the same reason, fn must not call
+// Stop or StopAndSnapshot of a window with a shifter, as those wait for the
+// shifter to exit, which never happens while it waits for fn. To stop the
+// window from fn, call Stop from a new goroutine.
+func WithOnEvict(fn func(BucketResult)) Option {
+	return func(sw *SlidingWindow) error {
+		if fn == nil {
+			return errors.New("evict function cannot be nil")
+		}
+
+		sw.onEvict = fn
+		sw.written = make([]bool, sw.len())
+		return nil
+	}
+}
+
+// evict queues the bucket at the specified position, which was the oldest in
+// the window before the current bucket moved to it, for the OnEvict function,
+// or passes it to onEvictLocked right away. It must be called with the lock
+// held.
+func (sw *SlidingWindow) evict(pos int) {
+	// A bucket's value doesn't tell whether it is empty, as the values of a
+	// window without sample counts may cancel each other out.
+	if !sw.written[pos] {
+		return
+	}
+
+	start := sw.start.Add(-time.Duration(sw.len()) * sw.granularity)
+	result := BucketResult{
+		Sum:   sw.sum(pos),
+		Count: sw.count(pos),
+		Start: start,
+		End:   start.Add(sw.granularity),
+	}
+	if sw.onEvictLocked != nil {
+		sw.onEvictLocked(result)
+		return
+	}
+
+	sw.evicted = append(sw.evicted, result)
+}
+
+// unlockAndEvict releases the lock and passes the buckets that were completed
+// while it was held to the WAL, and those that were evicted to the OnEvict
+// function.
+func (sw *SlidingWindow) unlockAndEvict() {
+	evicted, sealed := sw.evicted, sw.sealed
+	sw.evicted, sw.sealed = nil, nil
+
+	// The lock of the WAL is taken before the lock of the window is
+	// released, so buckets are appended in the order they were completed,
+	// even when several goroutines rotate the window.
+	if len(sealed) > 0 {
+		sw.wal.mu.Lock()
+	}
+	sw.Unlock()
+
+	if len(sealed) > 0 {
+		for _, b := range sealed {
+			sw.wal.append(b)
+		}
+		sw.wal.mu.Unlock()
+	}
+
+	for _, b := range evicted {
+		sw.onEvict(b)
+	}
+}
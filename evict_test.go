@@ -0,0 +1,107 @@
+package average
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithOnEvict(t *testing.T) {
+	var evicted []BucketResult
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var sw *SlidingWindow
+	sw = MustNewReplay(3*time.Second, time.Second, start, WithOnEvict(func(b BucketResult) {
+		// The lock of the window is released by now.
+		sw.Total(3 * time.Second)
+
+		evicted = append(evicted, b)
+	}))
+	defer sw.Stop()
+
+	sw.AddN(10, 2)
+	sw.AddAt(start.Add(2*time.Second), 1)
+	assert.Empty(t, evicted)
+
+	sw.AddAt(start.Add(3*time.Second), 1)
+	assert.Equal(t, []BucketResult{
+		{Sum: 10, Count: 2, Start: start, End: start.Add(time.Second)},
+	}, evicted)
+
+	// Empty buckets are not evicted.
+	sw.AddAt(start.Add(4*time.Second), 1)
+	assert.Len(t, evicted, 1)
+
+	sw.AdvanceTo(start.Add(time.Hour))
+	sw.AddAt(start.Add(time.Hour), 1)
+	assert.Equal(t, []BucketResult{
+		{Sum: 10, Count: 2, Start: start, End: start.Add(time.Second)},
+		{Sum: 1, Count: 1, Start: start.Add(2 * time.Second), End: start.Add(3 * time.Second)},
+		{Sum: 1, Count: 1, Start: start.Add(3 * time.Second), End: start.Add(4 * time.Second)},
+		{Sum: 1, Count: 1, Start: start.Add(4 * time.Second), End: start.Add(5 * time.Second)},
+	}, evicted)
+}
+
+func TestWithOnEvictCanceledOut(t *testing.T) {
+	var evicted []BucketResult
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	sw := MustNewReplay(2*time.Second, time.Second, start, WithoutCounts(), WithOnEvict(func(b BucketResult) {
+		evicted = append(evicted, b)
+	}))
+	defer sw.Stop()
+
+	// A bucket whose values add up to 0 isn't empty.
+	sw.Add(2)
+	sw.Add(-2)
+	sw.AdvanceTo(start.Add(3 * time.Second))
+	assert.Equal(t, []BucketResult{
+		{Start: start, End: start.Add(time.Second)},
+	}, evicted)
+}
+
+func TestWithOnEvictNil(t *testing.T) {
+	_, err := New(3*time.Second, time.Second, WithOnEvict(nil))
+	assert.EqualError(t, err, "evict function cannot be nil")
+}
+
+func TestWithOnEvictStop(t *testing.T) {
+	stoppedC := make(chan struct{})
+	var once sync.Once
+
+	var sw *SlidingWindow
+	sw = MustNew(2*time.Millisecond, time.Millisecond, WithOnEvict(func(b BucketResult) {
+		// The shifter waits for this function, so Stop has to be called
+		// from another goroutine.
+		once.Do(func() {
+			go func() {
+				sw.Stop()
+				close(stoppedC)
+			}()
+		})
+	}))
+	sw.Add(1)
+
+	select {
+	case <-stoppedC:
+		assert.True(t, sw.Stopped())
+	case <-time.After(5 * time.Second):
+		t.Fatal("window was not stopped")
+	}
+}
+
+func TestWithOnEvictStopManualClock(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Windows without a shifter can be stopped from fn directly.
+	var sw *SlidingWindow
+	sw = MustNewReplay(2*time.Second, time.Second, start, WithOnEvict(func(b BucketResult) {
+		sw.Stop()
+	}))
+
+	sw.AddAt(start, 1)
+	sw.AddAt(start.Add(2*time.Second), 1)
+	assert.True(t, sw.Stopped())
+}
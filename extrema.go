@@ -0,0 +1,77 @@
+package average
+
+import "time"
+
+// WithExtrema keeps track of the smallest and largest value added to every
+// bucket, which doubles the memory use of the window.
+func WithExtrema() Option {
+	return func(sw *SlidingWindow) error {
+		sw.mins = make([]float64, sw.len())
+		sw.maxs = make([]float64, sw.len())
+		return nil
+	}
+}
+
+// extrema updates the smallest and largest value of the current bucket with v.
+// It must be called with the lock held, after the count of the current bucket
+// has been incremented by n.
+func (sw *SlidingWindow) extrema(v float64, n int64) {
+	pos := sw.pos
+	if sw.count(pos) == n {
+		sw.mins[pos], sw.maxs[pos] = v, v
+		return
+	}
+
+	if v < sw.mins[pos] {
+		sw.mins[pos] = v
+	}
+	if v > sw.maxs[pos] {
+		sw.maxs[pos] = v
+	}
+}
+
+// Min returns the smallest value added over the specified window. It returns 0
+// if no values were added, or if the window was not created with WithExtrema.
+func (sw *SlidingWindow) Min(window time.Duration) float64 {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	min, _ := sw.extremes(window)
+	return min
+}
+
+// Max returns the largest value added over the specified window. It returns 0
+// if no values were added, or if the window was not created with WithExtrema.
+func (sw *SlidingWindow) Max(window time.Duration) float64 {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	_, max := sw.extremes(window)
+	return max
+}
+
+// extremes returns the smallest and largest value added over the specified
+// window. It must be called with the lock held.
+func (sw *SlidingWindow) extremes(window time.Duration) (min, max float64) {
+	if sw.mins == nil {
+		return 0, 0
+	}
+
+	found := false
+	for i, n := 0, sw.buckets(window); i < n; i++ {
+		pos := sw.index(i)
+		if sw.count(pos) == 0 || sw.open(i) {
+			continue
+		}
+
+		if !found || sw.mins[pos] < min {
+			min = sw.mins[pos]
+		}
+		if !found || sw.maxs[pos] > max {
+			max = sw.maxs[pos]
+		}
+		found = true
+	}
+
+	return min, max
+}
@@ -0,0 +1,46 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinMax(t *testing.T) {
+	sw := MustNew(3*time.Second, time.Second, WithExtrema())
+	defer sw.Stop()
+
+	assert.Equal(t, 0.0, sw.Min(3*time.Second))
+	assert.Equal(t, 0.0, sw.Max(3*time.Second))
+
+	sw.Add(5)
+	sw.Add(-2)
+	sw.Add(3)
+
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+	sw.Add(7)
+
+	assert.Equal(t, 7.0, sw.Min(time.Second))
+	assert.Equal(t, 7.0, sw.Max(time.Second))
+	assert.Equal(t, -2.0, sw.Min(3*time.Second))
+	assert.Equal(t, 7.0, sw.Max(3*time.Second))
+
+	sw.Lock()
+	sw.shift()
+	sw.shift()
+	sw.Unlock()
+
+	assert.Equal(t, 7.0, sw.Min(3*time.Second))
+}
+
+func TestMinMaxWithoutExtrema(t *testing.T) {
+	sw := MustNew(3*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Add(5)
+	assert.Equal(t, 0.0, sw.Min(3*time.Second))
+	assert.Equal(t, 0.0, sw.Max(3*time.Second))
+}
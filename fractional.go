@@ -0,0 +1,81 @@
+package average
+
+import (
+	"math"
+	"time"
+)
+
+// WithFractionalCounts keeps track of the total weight of the samples in every
+// bucket, for importance-weighted sampling where a sample may represent a
+// fraction of an event, or several events. Samples are added with a weight by
+// AddWeighted, and Average divides the weighted sum of the window by the total
+// weight rather than by the number of samples. Add and AddN count as weight 1
+// per sample. The total weight is not part of snapshots, so a restored window
+// assumes a weight of 1 per sample.
+func WithFractionalCounts() Option {
+	return func(sw *SlidingWindow) error {
+		sw.weights = make([]float64, sw.len())
+		return nil
+	}
+}
+
+// AddWeighted adds the value v with the specified weight, like 0.25 for a
+// sample that represents a quarter of an event. The bucket holds the weighted
+// value v*weight, so that Total returns the weighted sum, while the optional
+// per-bucket state that tracks individual values, like the extrema, records v.
+// For windows that weren't created WithFractionalCounts, AddWeighted is the
+// same as Add. A sample with a weight that isn't positive and finite is
+// ignored, as it would skew every later average of the window.
+func (sw *SlidingWindow) AddWeighted(v, weight float64) {
+	if !(weight > 0) || math.IsInf(weight, 1) {
+		return
+	}
+
+	sw.Lock()
+	defer sw.Unlock()
+
+	if sw.weights == nil || sw.valid != nil || sw.stopped {
+		sw.add(v, 1)
+		return
+	}
+
+	sw.adds++
+	sw.increment(sw.pos, v*weight, 1)
+	sw.weights[sw.pos] += weight
+	if sw.overflows != nil {
+		sw.checkBound(sw.pos)
+	}
+	sw.observe(v, 1)
+}
+
+// TotalWeight returns the weighted sum of all values over the specified
+// window, as well as their total weight. For windows that weren't created
+// WithFractionalCounts, the weight is the number of samples.
+func (sw *SlidingWindow) TotalWeight(window time.Duration) (float64, float64) {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	if sw.weights == nil {
+		total, count, _ := sw.total(window)
+		return total, float64(count)
+	}
+
+	return sw.totalWeight(window)
+}
+
+// totalWeight returns the sum of all values over the specified window and
+// their total weight. It must be called with the lock held.
+func (sw *SlidingWindow) totalWeight(window time.Duration) (float64, float64) {
+	var total, weight float64
+	for i, n := 0, sw.buckets(window); i < n; i++ {
+		if sw.open(i) {
+			continue
+		}
+
+		pos := sw.index(i)
+		total += sw.sum(pos)
+		weight += sw.weights[pos]
+	}
+
+	return total, weight
+}
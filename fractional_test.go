@@ -0,0 +1,80 @@
+package average
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithFractionalCounts(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithFractionalCounts(), WithExtrema())
+	defer sw.Stop()
+
+	assert.Equal(t, 0.0, sw.Average(4*time.Second))
+
+	sw.AddWeighted(8, 0.25)
+	sw.AddWeighted(4, 0.5)
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+	sw.Add(1)
+	sw.AddN(6, 2)
+
+	total, weight := sw.TotalWeight(4 * time.Second)
+	assert.Equal(t, 11.0, total)
+	assert.Equal(t, 3.75, weight)
+	assert.Equal(t, 11/3.75, sw.Average(4*time.Second))
+	assert.Equal(t, 7/3.0, sw.Average(time.Second))
+
+	total, count := sw.Total(4 * time.Second)
+	assert.Equal(t, 11.0, total)
+	assert.Equal(t, int64(5), count)
+
+	assert.Equal(t, 8.0, sw.Max(4*time.Second))
+	assert.Equal(t, 1.0, sw.Min(4*time.Second))
+
+	sw.ResetData()
+	_, weight = sw.TotalWeight(4 * time.Second)
+	assert.Equal(t, 0.0, weight)
+}
+
+func TestAddWeightedInvalidWeight(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithFractionalCounts())
+	defer sw.Stop()
+
+	sw.AddWeighted(2, 0.5)
+	for _, weight := range []float64{0, -1, math.NaN(), math.Inf(1), math.Inf(-1)} {
+		sw.AddWeighted(100, weight)
+	}
+
+	total, weight := sw.TotalWeight(4 * time.Second)
+	assert.Equal(t, 1.0, total)
+	assert.Equal(t, 0.5, weight)
+	assert.Equal(t, 2.0, sw.Average(4*time.Second))
+	assert.Equal(t, uint64(1), sw.Debug().Adds)
+}
+
+func TestWithFractionalCountsRestore(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.AddWeighted(3, 0.5)
+	total, weight := sw.TotalWeight(4 * time.Second)
+	assert.Equal(t, 3.0, total)
+	assert.Equal(t, 1.0, weight)
+
+	restored := MustNew(4*time.Second, time.Second, WithFractionalCounts())
+	defer restored.Stop()
+
+	restored.Lock()
+	assert.NoError(t, restored.restore(sw.Snapshot()))
+	restored.Unlock()
+	assert.Equal(t, 3.0, restored.Average(4*time.Second))
+}
+
+func TestWithFractionalCountsWithoutCounts(t *testing.T) {
+	_, err := New(4*time.Second, time.Second, WithoutCounts(), WithFractionalCounts())
+	assert.EqualError(t, err, "fractional counts require sample counts")
+}
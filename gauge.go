@@ -0,0 +1,125 @@
+package average
+
+import "time"
+
+// WithGauge makes the window track a gauge, like a queue depth or a
+// temperature, rather than sum up values. Like WithAggregation(AggregateLast),
+// every bucket stores the last value that was added to it. Average returns
+// the time-weighted mean of the gauge: every value counts for as long as it
+// held, until the next value was added, including the buckets without a value
+// in between. The time before the first value doesn't count. If carryForward
+// is true, a new bucket also starts out with the value of the previous one, so
+// that Aggregate and the other reads of the bucket values don't read a gauge
+// which doesn't change for a while as absent.
+//
+// The buckets of a snapshot don't tell how long their values held, so a
+// restored window averages the values of its buckets until new values are
+// added.
+func WithGauge(carryForward bool) Option {
+	return func(sw *SlidingWindow) error {
+		if err := WithAggregation(AggregateLast)(sw); err != nil {
+			return err
+		}
+
+		sw.carryForward = carryForward
+		sw.gauge = &gauge{
+			areas: make([]float64, sw.len()),
+			times: make([]time.Duration, sw.len()),
+		}
+		return nil
+	}
+}
+
+// gauge keeps track of how long the values of a gauge held.
+type gauge struct {
+	value float64   // The current value, if known.
+	known bool      // Whether a value was added.
+	since time.Time // Up to when the current value was credited.
+	areas []float64 // The values of every bucket, times the seconds they held.
+	times []time.Duration
+}
+
+// clone returns a deep copy of g.
+func (g *gauge) clone() *gauge {
+	if g == nil {
+		return nil
+	}
+
+	c := *g
+	c.areas = append([]float64(nil), g.areas...)
+	c.times = append([]time.Duration(nil), g.times...)
+	return &c
+}
+
+// carry copies the value of the previous bucket into the current one. It must
+// be called with the lock held.
+func (sw *SlidingWindow) carry() {
+	if prev := sw.index(1); sw.valid[prev] {
+		sw.set(sw.pos, sw.sum(prev), 0)
+		sw.valid[sw.pos] = true
+	}
+}
+
+// accrue credits the current value of the gauge to the current bucket for the
+// time up to t, which is capped at the end of the bucket. It must be called
+// with the lock held.
+func (sw *SlidingWindow) accrue(t time.Time) {
+	g := sw.gauge
+	if end := sw.start.Add(sw.granularity); t.After(end) {
+		t = end
+	}
+
+	since := g.since
+	if since.Before(sw.start) {
+		since = sw.start
+	}
+
+	if d := t.Sub(since); g.known && d > 0 {
+		g.areas[sw.pos] += g.value * d.Seconds()
+		g.times[sw.pos] += d
+	}
+	g.since = t
+}
+
+// gaugeAverage returns the time-weighted mean of the gauge over the specified
+// window, and false if no value held during it. It must be called with the
+// lock held.
+func (sw *SlidingWindow) gaugeAverage(window time.Duration) (float64, bool) {
+	g := sw.gauge
+	n := sw.buckets(window)
+	if n == 0 {
+		return 0, false
+	}
+
+	var area float64
+	var held time.Duration
+	for i := 0; i < n; i++ {
+		if sw.open(i) {
+			continue
+		}
+
+		pos := sw.index(i)
+		area += g.areas[pos]
+		held += g.times[pos]
+	}
+
+	// The current value has held since it was last credited.
+	end := sw.now()
+	if limit := sw.start.Add(sw.granularity); end.After(limit) {
+		end = limit
+	}
+	since := g.since
+	if since.Before(sw.start) {
+		since = sw.start
+	}
+	if d := end.Sub(since); g.known && d > 0 {
+		area += g.value * d.Seconds()
+		held += d
+	}
+
+	if held <= 0 {
+		return 0, false
+	}
+
+	return area / held.Seconds(), true
+}
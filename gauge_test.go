@@ -0,0 +1,112 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithGauge(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sw := MustNewReplay(20*time.Second, time.Second, start, WithGauge(false))
+	defer sw.Stop()
+
+	_, ok := sw.AverageOK(20 * time.Second)
+	assert.False(t, ok)
+
+	// Within a bucket, every value counts for as long as it held.
+	sw.AddAt(start, 4)
+	sw.AddAt(start.Add(250*time.Millisecond), 8)
+	sw.AdvanceTo(start.Add(time.Second))
+	assert.Equal(t, 7.0, sw.Average(20*time.Second))
+
+	total, count := sw.Total(20 * time.Second)
+	assert.Equal(t, 8.0, total)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestWithGaugeLate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sw := MustNewReplay(20*time.Second, time.Second, start, WithGauge(false), WithGracePeriod(2*time.Second))
+	defer sw.Stop()
+
+	sw.AddAt(start, 4)
+	sw.AdvanceTo(start.Add(1500 * time.Millisecond))
+
+	// A late value lands in its bucket, but the gauge keeps its current
+	// value, which holds on as before.
+	assert.True(t, sw.AddAt(start.Add(500*time.Millisecond), 100))
+	sw.RLock()
+	assert.Equal(t, 4.0, sw.gauge.value)
+	sw.RUnlock()
+
+	sw.AdvanceTo(start.Add(4 * time.Second))
+	assert.Equal(t, 4.0, sw.Average(20*time.Second))
+}
+
+func TestWithGaugeGap(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sw := MustNewReplay(20*time.Second, time.Second, start, WithGauge(false))
+	defer sw.Stop()
+
+	// 10 holds through the nine empty buckets before it drops to 0.
+	sw.AddAt(start, 10)
+	sw.AddAt(start.Add(10*time.Second), 0)
+	sw.AdvanceTo(start.Add(11 * time.Second))
+
+	assert.InDelta(t, 100.0/11, sw.Average(20*time.Second), 1e-9)
+	assert.Equal(t, 7.5, sw.Average(5*time.Second))
+	assert.Equal(t, 0.0, sw.Average(2*time.Second))
+
+	// The buckets without a value still count the value that held.
+	assert.InDelta(t, 10.0*8/9, sw.Average(10*time.Second), 1e-9)
+}
+
+func TestWithGaugeCarryForward(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sw := MustNewReplay(4*time.Second, time.Second, start, WithGauge(true))
+	defer sw.Stop()
+
+	sw.AdvanceTo(start.Add(time.Second))
+
+	// Nothing is carried forward before the first value.
+	assert.Equal(t, 0.0, sw.Aggregate(time.Second))
+
+	sw.AddAt(start.Add(time.Second), 2)
+	sw.AdvanceTo(start.Add(3 * time.Second))
+	assert.Equal(t, 2.0, sw.Aggregate(time.Second))
+	assert.Equal(t, 2.0, sw.Average(4*time.Second))
+
+	_, count := sw.Total(4 * time.Second)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestWithGaugeReset(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sw := MustNewReplay(4*time.Second, time.Second, start, WithGauge(false))
+	defer sw.Stop()
+
+	sw.AddAt(start, 2)
+	sw.AdvanceTo(start.Add(2 * time.Second))
+	s := sw.Snapshot()
+
+	sw.Reset()
+	sw.AdvanceTo(start.Add(3 * time.Second))
+	_, ok := sw.AverageOK(4 * time.Second)
+	assert.False(t, ok)
+
+	// A restored window doesn't know how long its values held, so it
+	// averages its buckets instead.
+	assert.NoError(t, sw.restore(s))
+	assert.Equal(t, 2.0, sw.Average(4*time.Second))
+}
+
+func TestWithGaugeAddN(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithGauge(false), WithCompactStorage())
+	defer sw.Stop()
+
+	sw.AddN(30, 3)
+	assert.InDelta(t, 10.0, sw.Average(time.Second), 1e-9)
+	assert.InDelta(t, 10.0, sw.WeightedAverage(time.Second, LinearWeights), 1e-9)
+}
@@ -0,0 +1,32 @@
+package average
+
+import (
+	"bytes"
+	"encoding/gob"
+)
+
+// GobEncode encodes a snapshot of this window, so it can be passed through
+// gob-based RPC. Like a Snapshot, it does not include optional per-bucket
+// state, such as the extrema or reservoirs.
+func (sw *SlidingWindow) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(sw.Snapshot()); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GobDecode decodes a window that was encoded with GobEncode. When decoding
+// into a zero SlidingWindow, it is configured after the encoded window and its
+// shifter is started, so it has to be stopped like a window returned by New.
+// Otherwise, the window needs to have the same configuration as the encoded
+// one, and its buckets are replaced.
+func (sw *SlidingWindow) GobDecode(data []byte) error {
+	var s Snapshot
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&s); err != nil {
+		return err
+	}
+
+	return sw.load(s)
+}
@@ -0,0 +1,101 @@
+package average
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGob(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Add(4)
+	sw.Add(6)
+
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(sw))
+
+	var decoded *SlidingWindow
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+	defer decoded.Stop()
+
+	assert.Equal(t, 10*time.Second, decoded.window)
+	assert.Equal(t, time.Second, decoded.granularity)
+	assert.Equal(t, 5.0, decoded.Average(10*time.Second))
+
+	// The decoded window is fully functional.
+	decoded.Add(2)
+	total, count := decoded.Total(10 * time.Second)
+	assert.Equal(t, 12.0, total)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestGobDecodeInvalid(t *testing.T) {
+	s := Snapshot{
+		Window:      4 * time.Second,
+		Granularity: time.Second,
+		Start:       time.Now(),
+		Samples:     []float64{1, 2},
+		Counts:      []int64{1},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(s))
+
+	var sw SlidingWindow
+	assert.EqualError(t, sw.GobDecode(buf.Bytes()), "snapshot has an invalid number of buckets")
+
+	stopped := make(chan struct{})
+	go func() {
+		sw.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop blocked")
+	}
+	assert.True(t, sw.Stopped())
+}
+
+func TestGobZeroTime(t *testing.T) {
+	s := Snapshot{
+		Window:      4 * time.Second,
+		Granularity: time.Second,
+		Samples:     []float64{1},
+		Counts:      []int64{1},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&buf).Encode(s))
+
+	var decoded Snapshot
+	assert.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+	assert.True(t, decoded.Time.IsZero())
+	assert.True(t, decoded.Start.IsZero())
+}
+
+func TestGobDecodeIntoWindow(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+	sw.Add(3)
+
+	data, err := sw.GobEncode()
+	assert.NoError(t, err)
+
+	other := MustNew(10*time.Second, time.Second)
+	defer other.Stop()
+	other.Add(100)
+
+	assert.NoError(t, other.GobDecode(data))
+	assert.Equal(t, 3.0, other.Average(10*time.Second))
+
+	mismatch := MustNew(20*time.Second, time.Second)
+	defer mismatch.Stop()
+	assert.EqualError(t, mismatch.GobDecode(data), "snapshot configuration does not match the window")
+}
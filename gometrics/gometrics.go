@@ -0,0 +1,287 @@
+// Package gometrics adapts the windows of package average to the Histogram,
+// Meter and Timer interfaces of github.com/rcrowley/go-metrics, so that
+// existing instrumentation and reporters, like those for Graphite or Librato,
+// work unchanged on top of sliding windows.
+//
+// The statistics of a Histogram cover its whole window. Count, Min, Max, Mean
+// and Sum are exact, while percentiles, the variance and the standard
+// deviation are derived from a reservoir of values, just like the samples of
+// go-metrics.
+package gometrics
+
+import (
+	"math"
+	"time"
+
+	"github.com/prep/average"
+	metrics "github.com/rcrowley/go-metrics"
+)
+
+// Histogram is a metrics.Histogram backed by a sliding window.
+type Histogram struct {
+	sw     *average.SlidingWindow
+	window time.Duration
+	frozen bool
+}
+
+// NewHistogram returns a new Histogram over the specified window that keeps
+// up to reservoirSize values per bucket for its percentiles. On Go 1.24 and
+// later, its window is stopped once the histogram is no longer reachable, but
+// calling Stop releases it sooner.
+func NewHistogram(window, granularity time.Duration, reservoirSize int) (*Histogram, error) {
+	sw, err := average.New(window, granularity,
+		average.WithExtrema(),
+		average.WithReservoir(reservoirSize),
+		average.WithAutoStop(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Histogram{sw: sw, window: window}, nil
+}
+
+// Clear removes all values from the histogram.
+func (h *Histogram) Clear() {
+	if h.frozen {
+		panic("Clear called on a HistogramSnapshot")
+	}
+
+	h.sw.Reset()
+}
+
+// Count returns the number of values in the window.
+func (h *Histogram) Count() int64 {
+	_, count := h.sw.Total(h.window)
+	return count
+}
+
+// Max returns the largest value in the window.
+func (h *Histogram) Max() int64 {
+	return int64(h.sw.Max(h.window))
+}
+
+// Mean returns the mean of the values in the window.
+func (h *Histogram) Mean() float64 {
+	return h.sw.Average(h.window)
+}
+
+// Min returns the smallest value in the window.
+func (h *Histogram) Min() int64 {
+	return int64(h.sw.Min(h.window))
+}
+
+// Percentile returns the p-th quantile, with p between 0 and 1, of the values
+// in the window.
+func (h *Histogram) Percentile(p float64) float64 {
+	v, _ := h.sw.Percentile(h.window, p*100)
+	return v
+}
+
+// Percentiles returns the quantiles ps of the values in the window.
+func (h *Histogram) Percentiles(ps []float64) []float64 {
+	values := make([]float64, len(ps))
+	for i, p := range ps {
+		values[i] = h.Percentile(p)
+	}
+
+	return values
+}
+
+// Sample returns a snapshot of the reservoir of the histogram.
+func (h *Histogram) Sample() metrics.Sample {
+	samples := h.sw.Samples(h.window)
+	values := make([]int64, len(samples))
+	for i, v := range samples {
+		values[i] = int64(v)
+	}
+
+	return metrics.NewSampleSnapshot(h.Count(), values)
+}
+
+// Snapshot returns a read-only copy of the histogram.
+func (h *Histogram) Snapshot() metrics.Histogram {
+	if h.frozen {
+		return h
+	}
+
+	// A stopped window keeps its data, but discards new values.
+	sw := h.sw.Clone()
+	sw.Stop()
+
+	return &Histogram{sw: sw, window: h.window, frozen: true}
+}
+
+// StdDev returns the standard deviation of the values in the reservoir.
+func (h *Histogram) StdDev() float64 {
+	return math.Sqrt(h.Variance())
+}
+
+// Sum returns the sum of the values in the window.
+func (h *Histogram) Sum() int64 {
+	total, _ := h.sw.Total(h.window)
+	return int64(total)
+}
+
+// Update adds the value v to the histogram.
+func (h *Histogram) Update(v int64) {
+	if h.frozen {
+		panic("Update called on a HistogramSnapshot")
+	}
+
+	h.sw.Add(float64(v))
+}
+
+// Variance returns the variance of the values in the reservoir.
+func (h *Histogram) Variance() float64 {
+	values := h.sw.Samples(h.window)
+	if len(values) == 0 {
+		return 0
+	}
+
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	var sum float64
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+
+	return sum / float64(len(values))
+}
+
+// Stop the window of the histogram.
+func (h *Histogram) Stop() {
+	h.sw.Stop()
+}
+
+// Meter is a metrics.Meter backed by an average.Meter.
+type Meter struct {
+	m *average.Meter
+}
+
+// NewMeter returns a new Meter, which has to be stopped once it is no longer
+// used.
+func NewMeter() *Meter {
+	return &Meter{m: average.NewMeter()}
+}
+
+// Count returns the number of events that were marked.
+func (m *Meter) Count() int64 { return m.m.Count() }
+
+// Mark records the occurrence of n events.
+func (m *Meter) Mark(n int64) { m.m.Mark(n) }
+
+// Rate1 returns the number of events per second over the last minute.
+func (m *Meter) Rate1() float64 { return m.m.Rate1() }
+
+// Rate5 returns the number of events per second over the last 5 minutes.
+func (m *Meter) Rate5() float64 { return m.m.Rate5() }
+
+// Rate15 returns the number of events per second over the last 15 minutes.
+func (m *Meter) Rate15() float64 { return m.m.Rate15() }
+
+// RateMean returns the number of events per second since the meter was
+// created.
+func (m *Meter) RateMean() float64 { return m.m.RateMean() }
+
+// Snapshot returns a read-only copy of the meter.
+func (m *Meter) Snapshot() metrics.Meter {
+	return &meterSnapshot{
+		count:    m.Count(),
+		rate1:    m.Rate1(),
+		rate5:    m.Rate5(),
+		rate15:   m.Rate15(),
+		rateMean: m.RateMean(),
+	}
+}
+
+// Stop the meter.
+func (m *Meter) Stop() { m.m.Stop() }
+
+// meterSnapshot is a read-only copy of a Meter.
+type meterSnapshot struct {
+	count                          int64
+	rate1, rate5, rate15, rateMean float64
+}
+
+func (m *meterSnapshot) Count() int64            { return m.count }
+func (m *meterSnapshot) Mark(n int64)            { panic("Mark called on a MeterSnapshot") }
+func (m *meterSnapshot) Rate1() float64          { return m.rate1 }
+func (m *meterSnapshot) Rate5() float64          { return m.rate5 }
+func (m *meterSnapshot) Rate15() float64         { return m.rate15 }
+func (m *meterSnapshot) RateMean() float64       { return m.rateMean }
+func (m *meterSnapshot) Snapshot() metrics.Meter { return m }
+func (m *meterSnapshot) Stop()                   {}
+
+// Timer is a metrics.Timer that combines a Histogram of durations in
+// nanoseconds with a Meter of their rate.
+type Timer struct {
+	*Histogram
+	meter metrics.Meter
+}
+
+// NewTimer returns a new Timer with a histogram over the specified window,
+// which has to be stopped once it is no longer used.
+func NewTimer(window, granularity time.Duration, reservoirSize int) (*Timer, error) {
+	h, err := NewHistogram(window, granularity, reservoirSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Timer{Histogram: h, meter: NewMeter()}, nil
+}
+
+// Rate1 returns the number of events per second over the last minute.
+func (t *Timer) Rate1() float64 { return t.meter.Rate1() }
+
+// Rate5 returns the number of events per second over the last 5 minutes.
+func (t *Timer) Rate5() float64 { return t.meter.Rate5() }
+
+// Rate15 returns the number of events per second over the last 15 minutes.
+func (t *Timer) Rate15() float64 { return t.meter.Rate15() }
+
+// RateMean returns the number of events per second since the timer was
+// created.
+func (t *Timer) RateMean() float64 { return t.meter.RateMean() }
+
+// Snapshot returns a read-only copy of the timer.
+func (t *Timer) Snapshot() metrics.Timer {
+	return &Timer{
+		Histogram: t.Histogram.Snapshot().(*Histogram),
+		meter:     t.meter.Snapshot(),
+	}
+}
+
+// Stop the histogram and the meter of the timer.
+func (t *Timer) Stop() {
+	t.Histogram.Stop()
+	t.meter.Stop()
+}
+
+// Time records the duration of f.
+func (t *Timer) Time(f func()) {
+	start := time.Now()
+	f()
+	t.UpdateSince(start)
+}
+
+// Update records the duration d.
+func (t *Timer) Update(d time.Duration) {
+	t.Histogram.Update(int64(d))
+	t.meter.Mark(1)
+}
+
+// UpdateSince records the duration since start.
+func (t *Timer) UpdateSince(start time.Time) {
+	t.Update(time.Since(start))
+}
+
+var (
+	_ metrics.Histogram = (*Histogram)(nil)
+	_ metrics.Meter     = (*Meter)(nil)
+	_ metrics.Timer     = (*Timer)(nil)
+)
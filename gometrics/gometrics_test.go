@@ -0,0 +1,90 @@
+package gometrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram(t *testing.T) {
+	h, err := NewHistogram(time.Minute, time.Second, 100)
+	assert.NoError(t, err)
+	defer h.Stop()
+
+	for _, v := range []int64{4, 2, 8, 6} {
+		h.Update(v)
+	}
+
+	assert.Equal(t, int64(4), h.Count())
+	assert.Equal(t, int64(2), h.Min())
+	assert.Equal(t, int64(8), h.Max())
+	assert.Equal(t, int64(20), h.Sum())
+	assert.Equal(t, 5.0, h.Mean())
+	assert.Equal(t, 5.0, h.Variance())
+	assert.Equal(t, 4.0, h.Percentile(0.5))
+	assert.Equal(t, []float64{2, 8}, h.Percentiles([]float64{0, 1}))
+	assert.Equal(t, 4, h.Sample().Size())
+	assert.Equal(t, int64(4), h.Sample().Count())
+
+	s := h.Snapshot()
+	h.Update(10)
+	assert.Equal(t, int64(4), s.Count())
+	assert.Equal(t, int64(5), h.Count())
+	assert.Equal(t, s, s.Snapshot())
+	assert.Panics(t, func() { s.Update(1) })
+	assert.Panics(t, func() { s.Clear() })
+
+	h.Clear()
+	assert.Equal(t, int64(0), h.Count())
+	assert.Equal(t, 0.0, h.StdDev())
+}
+
+func TestNewHistogramErrors(t *testing.T) {
+	_, err := NewHistogram(time.Minute, time.Second, 0)
+	assert.EqualError(t, err, "reservoir size has to be at least 1")
+
+	_, err = NewTimer(time.Second, time.Second, 100)
+	assert.EqualError(t, err, "window size has to be a multiplier of the granularity size")
+}
+
+func TestMeter(t *testing.T) {
+	m := NewMeter()
+	defer m.Stop()
+
+	m.Mark(3)
+	s := m.Snapshot()
+	m.Mark(3)
+
+	assert.Equal(t, int64(6), m.Count())
+	assert.Equal(t, int64(3), s.Count())
+	// The rates are divided by the part of the first bucket of 5 seconds that
+	// has elapsed.
+	assert.True(t, s.Rate1() >= 3.0/5)
+	assert.True(t, s.Rate5() >= 3.0/5)
+	assert.True(t, s.Rate15() >= 3.0/5)
+	assert.True(t, s.RateMean() > 0)
+	assert.Panics(t, func() { s.Mark(1) })
+}
+
+func TestTimer(t *testing.T) {
+	tm, err := NewTimer(time.Minute, time.Second, 100)
+	assert.NoError(t, err)
+	defer tm.Stop()
+
+	tm.Update(2 * time.Millisecond)
+	tm.Time(func() {})
+
+	assert.Equal(t, int64(2), tm.Count())
+	assert.Equal(t, int64(2*time.Millisecond), tm.Max())
+	assert.True(t, tm.Rate1() >= 2.0/5)
+
+	s := tm.Snapshot()
+	rate := s.Rate1()
+	tm.Update(time.Second)
+	assert.Equal(t, int64(2), s.Count())
+	assert.True(t, rate >= 2.0/5)
+	assert.Equal(t, rate, s.Rate1())
+	assert.Panics(t, func() { s.Update(time.Second) })
+	s.Stop()
+}
@@ -0,0 +1,82 @@
+package average
+
+import (
+	"errors"
+	"time"
+)
+
+// WithGracePeriod lets AddAt add events to buckets that already rotated, as
+// long as the bucket was rotated less than grace ago by the clock of the
+// window. This way, near-real-time pipelines whose events arrive slightly out
+// of order don't lose the events at the edge of a bucket. It only affects
+// windows with a manual clock, like those created by NewReplay.
+//
+// A rotated bucket stays open for late events until its grace period has
+// passed, and is left out of Total, Average, Snapshot and the other reads
+// until then, as if it were empty, so reads don't change after the fact. Once
+// the grace period has passed, the bucket is sealed: it is included in reads
+// again, and passed to subscribers and the WAL along with its late events.
+// Stopping the window seals all open buckets right away.
+func WithGracePeriod(grace time.Duration) Option {
+	return func(sw *SlidingWindow) error {
+		if grace <= 0 {
+			return errors.New("grace period has to be positive")
+		}
+
+		sw.grace = grace
+		return nil
+	}
+}
+
+// keepsOpen returns whether rotated buckets stay open for the grace period.
+func (sw *SlidingWindow) keepsOpen() bool {
+	return sw.grace > 0 && sw.manual
+}
+
+// open returns whether the bucket that is age buckets older than the current
+// one rotated, but wasn't sealed yet. Reads skip open buckets. It must be
+// called with the lock held.
+func (sw *SlidingWindow) open(age int) bool {
+	return age > 0 && age <= sw.unsealed
+}
+
+// sealLate seals the open buckets whose grace period has passed by the clock
+// of the window, or all of them, oldest first. It must be called with the lock
+// held.
+func (sw *SlidingWindow) sealLate(all bool) {
+	unsealed := sw.unsealed
+	for ; sw.unsealed > 0; sw.unsealed-- {
+		end := sw.start.Add(-time.Duration(sw.unsealed-1) * sw.granularity)
+		if !all && sw.virtual.Sub(end) < sw.grace {
+			break
+		}
+
+		sw.complete(sw.unsealed)
+	}
+
+	if sw.unsealed != unsealed {
+		sw.refreshView()
+	}
+}
+
+// addLate adds v to the open bucket that contains t, if there is one, and
+// returns whether it did. A late value of a gauge is folded into its bucket,
+// but doesn't become the current value of the gauge, nor is the current value
+// credited to the late bucket. It must be called with the lock held.
+func (sw *SlidingWindow) addLate(t time.Time, v float64) bool {
+	age := int((sw.start.Sub(t)-1)/sw.granularity) + 1
+	if !sw.open(age) {
+		return false
+	}
+
+	// Adding always happens to the current bucket, so the late bucket takes
+	// its place for the duration of the add.
+	pos, g := sw.pos, sw.gauge
+	sw.detachView()
+	sw.pos, sw.gauge = sw.index(age), nil
+	sw.add(v, 1)
+	sw.pos, sw.gauge = pos, g
+	sw.rescanHorizons(true)
+	sw.refreshView()
+	return true
+}
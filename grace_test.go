@@ -0,0 +1,119 @@
+package average
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithGracePeriod(t *testing.T) {
+	_, err := New(4*time.Second, time.Second, WithGracePeriod(0))
+	assert.EqualError(t, err, "grace period has to be positive")
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sw := MustNewReplay(4*time.Second, time.Second, start, WithGracePeriod(500*time.Millisecond), WithExtrema())
+	defer sw.Stop()
+
+	assert.True(t, sw.AddAt(start.Add(500*time.Millisecond), 1))
+	assert.True(t, sw.AddAt(start.Add(1200*time.Millisecond), 2))
+
+	// The first bucket ended 200ms ago, so it's still writable, but left out
+	// of reads until it's sealed.
+	assert.True(t, sw.AddAt(start.Add(900*time.Millisecond), 3))
+	assert.Equal(t, []int64{1, 0}, sw.Counts(4*time.Second))
+	assert.Equal(t, 2.0, sw.Max(4*time.Second))
+
+	// Once the grace period has passed, it's sealed.
+	assert.True(t, sw.AddAt(start.Add(1500*time.Millisecond), 4))
+	assert.False(t, sw.AddAt(start.Add(999*time.Millisecond), 5))
+	assert.False(t, sw.AddAt(start.Add(-time.Second), 5))
+	assert.Equal(t, []int64{2, 2}, sw.Counts(4*time.Second))
+	assert.Equal(t, 4.0, sw.Max(4*time.Second))
+
+	total, count := sw.Total(4 * time.Second)
+	assert.Equal(t, 10.0, total)
+	assert.Equal(t, int64(4), count)
+}
+
+func TestWithGracePeriodReads(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, opt := range []Option{WithExtrema(), WithLockFreeReads()} {
+		sw := MustNewReplay(4*time.Second, time.Second, start, WithGracePeriod(500*time.Millisecond), opt)
+		assert.NoError(t, sw.RegisterHorizon(4*time.Second))
+
+		assert.True(t, sw.AddAt(start.Add(500*time.Millisecond), 1))
+		assert.True(t, sw.AddAt(start.Add(1200*time.Millisecond), 2))
+
+		// A late event within the grace period doesn't show up in reads
+		// until its bucket is sealed.
+		assert.True(t, sw.AddAt(start.Add(900*time.Millisecond), 3))
+		total, _ := sw.Total(4 * time.Second)
+		assert.Equal(t, 2.0, total)
+		assert.Equal(t, 2.0, sw.Average(4*time.Second))
+		assert.Equal(t, []float64{2, 0}, sw.Snapshot().Samples)
+
+		sw.AdvanceTo(start.Add(1500 * time.Millisecond))
+		total, _ = sw.Total(4 * time.Second)
+		assert.Equal(t, 6.0, total)
+		assert.Equal(t, []float64{2, 4}, sw.Snapshot().Samples)
+		sw.Stop()
+	}
+}
+
+func TestWithGracePeriodSeal(t *testing.T) {
+	var buf bytes.Buffer
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sw := MustNewReplay(4*time.Second, time.Second, start, WithGracePeriod(500*time.Millisecond), WithWAL(NewWAL(&buf)))
+	c := sw.Subscribe(4, DropNewest)
+
+	sw.AddAt(start.Add(500*time.Millisecond), 1)
+	sw.AddAt(start.Add(1200*time.Millisecond), 2)
+	sw.AddAt(start.Add(900*time.Millisecond), 3)
+
+	// The rotated bucket is passed on once it's sealed, with its late event.
+	assert.Len(t, c, 0)
+	assert.Empty(t, buf.String())
+
+	sw.AdvanceTo(start.Add(1500 * time.Millisecond))
+	assert.Equal(t, BucketResult{Sum: 4, Count: 2, Start: start, End: start.Add(time.Second)}, <-c)
+	assert.Equal(t, "2024-01-01T00:00:00Z 2024-01-01T00:00:01Z 4 2\n", buf.String())
+
+	// Stopping seals the open buckets right away.
+	sw.AddAt(start.Add(2100*time.Millisecond), 5)
+	sw.AddAt(start.Add(1800*time.Millisecond), 6)
+	sw.Stop()
+
+	assert.Equal(t, BucketResult{Sum: 8, Count: 2, Start: start.Add(time.Second), End: start.Add(2 * time.Second)}, <-c)
+	_, ok := <-c
+	assert.False(t, ok)
+	assert.Equal(t, "2024-01-01T00:00:00Z 2024-01-01T00:00:01Z 4 2\n"+
+		"2024-01-01T00:00:01Z 2024-01-01T00:00:02Z 8 2\n"+
+		"2024-01-01T00:00:02Z 2024-01-01T00:00:02.1Z 5 1\n", buf.String())
+}
+
+func TestWithGracePeriodWrap(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sw := MustNewReplay(2*time.Second, time.Second, start, WithGracePeriod(10*time.Second))
+	defer sw.Stop()
+	c := sw.Subscribe(4, DropNewest)
+
+	// The grace period outlasts the window, so buckets are sealed before
+	// they're cleared.
+	sw.AddAt(start, 1)
+	sw.AddAt(start.Add(time.Second), 2)
+	assert.Len(t, c, 0)
+	sw.AddAt(start.Add(2*time.Second), 3)
+	assert.Equal(t, 1.0, (<-c).Sum)
+	assert.Equal(t, []float64{3, 0}, sw.Snapshot().Samples)
+}
+
+func TestAddAtWithoutGracePeriod(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sw := MustNewReplay(4*time.Second, time.Second, start)
+	defer sw.Stop()
+
+	assert.True(t, sw.AddAt(start.Add(1200*time.Millisecond), 2))
+	assert.False(t, sw.AddAt(start.Add(900*time.Millisecond), 3))
+}
@@ -0,0 +1,163 @@
+package average
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+)
+
+// histogram is a minimal High Dynamic Range histogram. It records int64 values
+// between lowest and highest while keeping the relative error of each value
+// within the configured number of significant figures.
+type histogram struct {
+	unitMagnitude               uint
+	subBucketHalfCountMagnitude uint
+	subBucketCount              int
+	subBucketHalfCount          int
+	subBucketMask               int64
+	lowest                      int64
+	highest                     int64
+	total                       int64
+	counts                      []int64
+}
+
+// newHistogram returns a new histogram that tracks values between lowest and
+// highest with sigfigs significant figures.
+func newHistogram(lowest, highest int64, sigfigs int) (*histogram, error) {
+	if lowest < 1 {
+		return nil, errors.New("lowest trackable value has to be at least 1")
+	}
+	if highest < 2*lowest {
+		return nil, errors.New("highest trackable value has to be at least twice the lowest")
+	}
+	if sigfigs < 1 || sigfigs > 5 {
+		return nil, errors.New("significant figures have to be between 1 and 5")
+	}
+
+	largest := 2 * math.Pow10(sigfigs)
+	subBucketCountMagnitude := uint(math.Ceil(math.Log2(largest)))
+
+	h := &histogram{
+		unitMagnitude:               uint(bits.Len64(uint64(lowest)) - 1),
+		subBucketHalfCountMagnitude: subBucketCountMagnitude - 1,
+		subBucketCount:              1 << subBucketCountMagnitude,
+		lowest:                      lowest,
+		highest:                     highest,
+	}
+	h.subBucketHalfCount = h.subBucketCount / 2
+	h.subBucketMask = int64(h.subBucketCount-1) << h.unitMagnitude
+
+	bucketCount := 1
+	for smallest := int64(h.subBucketCount) << h.unitMagnitude; smallest <= highest; bucketCount++ {
+		if smallest > math.MaxInt64/2 {
+			bucketCount++
+			break
+		}
+		smallest <<= 1
+	}
+
+	h.counts = make([]int64, (bucketCount+1)*h.subBucketHalfCount)
+	return h, nil
+}
+
+// record adds n occurrences of v. Values outside of the trackable range are
+// clamped to it.
+func (h *histogram) record(v, n int64) {
+	if v < h.lowest {
+		v = h.lowest
+	} else if v > h.highest {
+		v = h.highest
+	}
+
+	h.counts[h.countsIndex(v)] += n
+	h.total += n
+}
+
+// reset removes all recorded values.
+func (h *histogram) reset() {
+	if h.total == 0 {
+		return
+	}
+
+	for i := range h.counts {
+		h.counts[i] = 0
+	}
+	h.total = 0
+}
+
+// merge adds the counts of other, which needs to have the same layout.
+func (h *histogram) merge(other *histogram) {
+	if other.total == 0 {
+		return
+	}
+
+	for i, c := range other.counts {
+		h.counts[i] += c
+	}
+	h.total += other.total
+}
+
+// valueAtQuantile returns the largest value that q percent of the recorded
+// values are smaller than or equal to.
+func (h *histogram) valueAtQuantile(q float64) int64 {
+	if h.total == 0 {
+		return 0
+	}
+	if q > 100 {
+		q = 100
+	}
+
+	target := int64(q/100*float64(h.total) + 0.5)
+	if target < 1 {
+		target = 1
+	}
+
+	var seen int64
+	for i, c := range h.counts {
+		if seen += c; seen >= target {
+			return h.highestEquivalentValue(h.valueFromCountsIndex(i))
+		}
+	}
+
+	return 0
+}
+
+func (h *histogram) bucketIndex(v int64) int {
+	pow2Ceiling := 64 - bits.LeadingZeros64(uint64(v|h.subBucketMask))
+	return pow2Ceiling - int(h.unitMagnitude) - int(h.subBucketHalfCountMagnitude+1)
+}
+
+func (h *histogram) subBucketIndex(v int64, bucketIdx int) int {
+	return int(v >> (uint(bucketIdx) + h.unitMagnitude))
+}
+
+func (h *histogram) countsIndex(v int64) int {
+	bucketIdx := h.bucketIndex(v)
+	subBucketIdx := h.subBucketIndex(v, bucketIdx)
+	return (bucketIdx+1)<<h.subBucketHalfCountMagnitude + subBucketIdx - h.subBucketHalfCount
+}
+
+func (h *histogram) valueFromCountsIndex(i int) int64 {
+	bucketIdx := (i >> h.subBucketHalfCountMagnitude) - 1
+	subBucketIdx := (i & (h.subBucketHalfCount - 1)) + h.subBucketHalfCount
+	if bucketIdx < 0 {
+		subBucketIdx -= h.subBucketHalfCount
+		bucketIdx = 0
+	}
+
+	return int64(subBucketIdx) << (uint(bucketIdx) + h.unitMagnitude)
+}
+
+// highestEquivalentValue returns the largest value that is recorded in the
+// same slot as v.
+func (h *histogram) highestEquivalentValue(v int64) int64 {
+	bucketIdx := h.bucketIndex(v)
+	subBucketIdx := h.subBucketIndex(v, bucketIdx)
+	lowest := int64(subBucketIdx) << (uint(bucketIdx) + h.unitMagnitude)
+
+	if subBucketIdx >= h.subBucketCount {
+		bucketIdx++
+	}
+
+	return lowest + int64(1)<<(h.unitMagnitude+uint(bucketIdx)) - 1
+}
@@ -0,0 +1,68 @@
+package average
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHistogram(t *testing.T) {
+	_, err := newHistogram(0, 100, 3)
+	assert.EqualError(t, err, "lowest trackable value has to be at least 1")
+
+	_, err = newHistogram(10, 15, 3)
+	assert.EqualError(t, err, "highest trackable value has to be at least twice the lowest")
+
+	_, err = newHistogram(1, 100, 6)
+	assert.EqualError(t, err, "significant figures have to be between 1 and 5")
+}
+
+func TestHistogramValueAtQuantile(t *testing.T) {
+	h, err := newHistogram(1, 3600*1000*1000, 3)
+	assert.NoError(t, err)
+
+	for i := int64(1); i <= 10000; i++ {
+		h.record(i*1000, 1)
+	}
+
+	assert.InDelta(t, 5000000, h.valueAtQuantile(50), 5000)
+	assert.InDelta(t, 9900000, h.valueAtQuantile(99), 9900)
+	assert.InDelta(t, 10000000, h.valueAtQuantile(100), 10000)
+	assert.InDelta(t, 1000, h.valueAtQuantile(0), 1)
+}
+
+func TestHistogramClamp(t *testing.T) {
+	h, _ := newHistogram(1, 1000, 2)
+
+	h.record(-5, 1)
+	h.record(5000, 1)
+
+	assert.Equal(t, int64(1), h.valueAtQuantile(50))
+	assert.InDelta(t, 1000, h.valueAtQuantile(100), 10)
+
+	// A value below lowest counts as lowest, not as 0.
+	h, _ = newHistogram(1000, 100000, 2)
+	lowest, _ := newHistogram(1000, 100000, 2)
+	h.record(5, 1)
+	lowest.record(1000, 1)
+
+	assert.Equal(t, lowest.valueAtQuantile(50), h.valueAtQuantile(50))
+	assert.NotEqual(t, int64(0), h.valueAtQuantile(50))
+}
+
+func TestHistogramMergeAndReset(t *testing.T) {
+	a, _ := newHistogram(1, 100000, 3)
+	b, _ := newHistogram(1, 100000, 3)
+
+	a.record(10, 3)
+	b.record(1000, 1)
+	a.merge(b)
+
+	assert.Equal(t, int64(4), a.total)
+	assert.Equal(t, int64(10), a.valueAtQuantile(75))
+	assert.Equal(t, int64(1000), a.valueAtQuantile(100))
+
+	a.reset()
+	assert.Equal(t, int64(0), a.total)
+	assert.Equal(t, int64(0), a.valueAtQuantile(50))
+}
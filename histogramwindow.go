@@ -0,0 +1,150 @@
+package average
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// HistogramWindow is a sliding time window that counts values per value
+// bucket, like the buckets of a Prometheus histogram. Every time bucket keeps
+// a count per value bucket, so that it can answer questions like how many
+// requests took between 100ms and 250ms over the last 5 minutes.
+type HistogramWindow struct {
+	sw     *SlidingWindow
+	bounds []float64
+	counts []int64 // The counts per value bucket of every time bucket in turn.
+}
+
+// MustNewHistogramWindow returns a new HistogramWindow, but panics if an error
+// occurs.
+func MustNewHistogramWindow(window, granularity time.Duration, bounds ...float64) *HistogramWindow {
+	hw, err := NewHistogramWindow(window, granularity, bounds...)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return hw
+}
+
+// NewHistogramWindow returns a new HistogramWindow with the specified upper
+// bounds of its value buckets, in increasing order. A value is counted in the
+// first bucket whose bound it doesn't exceed, and values that exceed every
+// bound are counted in an extra bucket without an upper bound.
+func NewHistogramWindow(window, granularity time.Duration, bounds ...float64) (*HistogramWindow, error) {
+	if len(bounds) == 0 {
+		return nil, errors.New("at least one bound is required")
+	}
+	for i, b := range bounds {
+		if math.IsNaN(b) || (i > 0 && b <= bounds[i-1]) {
+			return nil, errors.New("bounds have to be in increasing order")
+		}
+	}
+
+	sw, err := newSlidingWindow(window, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	hw := &HistogramWindow{
+		sw:     sw,
+		bounds: append([]float64(nil), bounds...),
+		counts: make([]int64, sw.len()*(len(bounds)+1)),
+	}
+	sw.onClear = hw.clear
+
+	sw.startShifter()
+	return hw, nil
+}
+
+func (hw *HistogramWindow) clear(pos int) {
+	row := hw.row(pos)
+	for i := range row {
+		row[i] = 0
+	}
+}
+
+// row returns the counts per value bucket of the time bucket at the specified
+// position.
+func (hw *HistogramWindow) row(pos int) []int64 {
+	n := len(hw.bounds) + 1
+	return hw.counts[pos*n : (pos+1)*n]
+}
+
+// Bounds returns the upper bounds of the value buckets of this window.
+func (hw *HistogramWindow) Bounds() []float64 {
+	return append([]float64(nil), hw.bounds...)
+}
+
+// Add counts v in its value bucket of the current time bucket.
+func (hw *HistogramWindow) Add(v float64) {
+	i := sort.SearchFloat64s(hw.bounds, v)
+
+	hw.sw.Lock()
+	defer hw.sw.Unlock()
+
+	if !hw.sw.countAdd() {
+		return
+	}
+
+	hw.row(hw.sw.pos)[i]++
+	hw.sw.increment(hw.sw.pos, v, 1)
+}
+
+// Buckets returns the number of values per value bucket over the specified
+// window. The last count is that of the values that exceed every bound.
+func (hw *HistogramWindow) Buckets(window time.Duration) []int64 {
+	hw.sw.RLock()
+	defer hw.sw.RUnlock()
+
+	buckets := make([]int64, len(hw.bounds)+1)
+	for i, n := 0, hw.sw.buckets(window); i < n; i++ {
+		for j, c := range hw.row(hw.sw.index(i)) {
+			buckets[j] += c
+		}
+	}
+
+	return buckets
+}
+
+// CountBetween returns the number of values over the specified window that
+// are larger than lower and at most upper. Only value buckets that lie within
+// that range entirely are counted, so lower and upper should be bounds of the
+// window. Use math.Inf(-1) for lower to count from the first bucket, and
+// math.Inf(1) for upper to count up to the last one.
+func (hw *HistogramWindow) CountBetween(window time.Duration, lower, upper float64) int64 {
+	var count int64
+	for i, c := range hw.Buckets(window) {
+		from, to := math.Inf(-1), math.Inf(1)
+		if i > 0 {
+			from = hw.bounds[i-1]
+		}
+		if i < len(hw.bounds) {
+			to = hw.bounds[i]
+		}
+
+		if from >= lower && to <= upper {
+			count += c
+		}
+	}
+
+	return count
+}
+
+// Total returns the sum of all values over the specified window, as well as
+// the number of values.
+func (hw *HistogramWindow) Total(window time.Duration) (float64, int64) {
+	return hw.sw.Total(window)
+}
+
+// Debug returns the DebugStats of this histogram window.
+func (hw *HistogramWindow) Debug() DebugStats {
+	return hw.sw.Debug()
+}
+
+// Stop the shifter of this histogram window. A stopped HistogramWindow cannot
+// be started again.
+func (hw *HistogramWindow) Stop() {
+	hw.sw.Stop()
+}
@@ -0,0 +1,68 @@
+package average
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHistogramWindow(t *testing.T) {
+	_, err := NewHistogramWindow(time.Minute, time.Second)
+	assert.EqualError(t, err, "at least one bound is required")
+
+	_, err = NewHistogramWindow(time.Minute, time.Second, 0.1, 0.1)
+	assert.EqualError(t, err, "bounds have to be in increasing order")
+
+	_, err = NewHistogramWindow(time.Minute, time.Second, math.NaN())
+	assert.EqualError(t, err, "bounds have to be in increasing order")
+
+	_, err = NewHistogramWindow(0, time.Second, 0.1)
+	assert.EqualError(t, err, "window cannot be 0")
+}
+
+func TestHistogramWindowBuckets(t *testing.T) {
+	hw := MustNewHistogramWindow(3*time.Second, time.Second, 0.1, 0.25, 0.5)
+	defer hw.Stop()
+
+	assert.Equal(t, []float64{0.1, 0.25, 0.5}, hw.Bounds())
+
+	hw.Add(0.05)
+	hw.Add(0.25)
+	hw.sw.Lock()
+	hw.sw.shift()
+	hw.sw.Unlock()
+	hw.Add(0.2)
+	hw.Add(0.3)
+	hw.Add(2)
+
+	assert.Equal(t, []int64{0, 1, 1, 1}, hw.Buckets(time.Second))
+	assert.Equal(t, []int64{1, 2, 1, 1}, hw.Buckets(3*time.Second))
+
+	assert.Equal(t, int64(2), hw.CountBetween(3*time.Second, 0.1, 0.25))
+	assert.Equal(t, int64(3), hw.CountBetween(3*time.Second, math.Inf(-1), 0.25))
+	assert.Equal(t, int64(2), hw.CountBetween(3*time.Second, 0.25, math.Inf(1)))
+	assert.Equal(t, int64(1), hw.CountBetween(time.Second, 0.1, 0.3))
+
+	total, count := hw.Total(3 * time.Second)
+	assert.InDelta(t, 2.8, total, 1e-9)
+	assert.Equal(t, int64(5), count)
+
+	// Once the first bucket expires, its values no longer count.
+	hw.sw.Lock()
+	hw.sw.shift()
+	hw.sw.shift()
+	hw.sw.Unlock()
+
+	assert.Equal(t, []int64{0, 1, 1, 1}, hw.Buckets(3*time.Second))
+}
+
+func TestHistogramWindowStopped(t *testing.T) {
+	hw := MustNewHistogramWindow(3*time.Second, time.Second, 1)
+	hw.Add(1)
+	hw.Stop()
+	hw.Add(2)
+
+	assert.Equal(t, []int64{1, 0}, hw.Buckets(3*time.Second))
+}
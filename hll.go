@@ -0,0 +1,104 @@
+package average
+
+import (
+	"errors"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// hyperLogLog is a HyperLogLog cardinality sketch with 2^precision registers.
+type hyperLogLog struct {
+	precision uint8
+	registers []uint8
+}
+
+// newHyperLogLog returns a new sketch. A higher precision lowers the standard
+// error, which is roughly 1.04/sqrt(2^precision), at the cost of memory.
+func newHyperLogLog(precision uint8) (*hyperLogLog, error) {
+	if precision < 4 || precision > 16 {
+		return nil, errors.New("precision has to be between 4 and 16")
+	}
+
+	return &hyperLogLog{
+		precision: precision,
+		registers: make([]uint8, 1<<precision),
+	}, nil
+}
+
+// hashString returns a well-distributed 64-bit hash of s.
+func hashString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+
+	// Finalize with the MurmurHash3 mixer, as FNV on its own doesn't spread
+	// short keys far enough over the high bits.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+
+	return x
+}
+
+// add records the item with the specified hash.
+func (s *hyperLogLog) add(hash uint64) {
+	idx := hash >> (64 - s.precision)
+	w := hash<<s.precision | 1<<(s.precision-1)
+
+	if rank := uint8(bits.LeadingZeros64(w) + 1); rank > s.registers[idx] {
+		s.registers[idx] = rank
+	}
+}
+
+// merge folds other, which needs to have the same precision, into s.
+func (s *hyperLogLog) merge(other *hyperLogLog) {
+	for i, r := range other.registers {
+		if r > s.registers[i] {
+			s.registers[i] = r
+		}
+	}
+}
+
+// reset removes all recorded items.
+func (s *hyperLogLog) reset() {
+	for i := range s.registers {
+		s.registers[i] = 0
+	}
+}
+
+// cardinality returns the estimated number of distinct items.
+func (s *hyperLogLog) cardinality() uint64 {
+	m := float64(len(s.registers))
+
+	var sum float64
+	var zeros int
+	for _, r := range s.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	var alpha float64
+	switch len(s.registers) {
+	case 16:
+		alpha = 0.673
+	case 32:
+		alpha = 0.697
+	case 64:
+		alpha = 0.709
+	default:
+		alpha = 0.7213 / (1 + 1.079/m)
+	}
+
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// Use linear counting for small cardinalities.
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	return uint64(estimate + 0.5)
+}
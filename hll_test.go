@@ -0,0 +1,47 @@
+package average
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHyperLogLog(t *testing.T) {
+	_, err := newHyperLogLog(3)
+	assert.EqualError(t, err, "precision has to be between 4 and 16")
+
+	_, err = newHyperLogLog(17)
+	assert.EqualError(t, err, "precision has to be between 4 and 16")
+}
+
+func TestHyperLogLogCardinality(t *testing.T) {
+	s, _ := newHyperLogLog(14)
+	assert.Equal(t, uint64(0), s.cardinality())
+
+	for i := 0; i < 100; i++ {
+		s.add(hashString(strconv.Itoa(i % 10)))
+	}
+	assert.Equal(t, uint64(10), s.cardinality())
+
+	for i := 0; i < 100000; i++ {
+		s.add(hashString(strconv.Itoa(i)))
+	}
+	assert.InDelta(t, 100000, s.cardinality(), 2000)
+}
+
+func TestHyperLogLogMerge(t *testing.T) {
+	a, _ := newHyperLogLog(12)
+	b, _ := newHyperLogLog(12)
+
+	for i := 0; i < 1000; i++ {
+		a.add(hashString(strconv.Itoa(i)))
+		b.add(hashString(strconv.Itoa(i + 500)))
+	}
+
+	a.merge(b)
+	assert.InDelta(t, 1500, a.cardinality(), 75)
+
+	a.reset()
+	assert.Equal(t, uint64(0), a.cardinality())
+}
@@ -0,0 +1,102 @@
+package average
+
+import (
+	"errors"
+	"time"
+)
+
+// horizon keeps the running sum of the completed buckets of a registered
+// window, so queries over it don't have to rescan its buckets.
+type horizon struct {
+	n         int // The number of buckets of the horizon.
+	sum       float64
+	count     int64
+	rotations int // The number of rotations since the sums were rescanned.
+}
+
+// RegisterHorizon makes queries over the specified window, like Average and
+// Total, take constant time instead of summing its buckets on every call. The
+// window is rounded to buckets like for any other query, so every window
+// that rounds to the same number of buckets benefits. Every rotation updates
+// the registered windows in constant time. The sums are rescanned after every
+// window length's worth of rotations, so rounding errors can't accumulate, but
+// queries over windows of compact storage may differ in the last bits from
+// an unregistered query.
+func (sw *SlidingWindow) RegisterHorizon(window time.Duration) error {
+	if window <= 0 {
+		return errors.New("horizon has to be positive")
+	}
+	if window > sw.window {
+		return errors.New("horizon is larger than the window")
+	}
+
+	sw.Lock()
+	defer sw.Unlock()
+
+	n := sw.round(window)
+	if n < 1 {
+		return errors.New("horizon is smaller than the granularity")
+	}
+	if sw.horizon(window) != nil {
+		return nil
+	}
+
+	sw.horizons = append(sw.horizons, horizon{n: n})
+	sw.rescan(&sw.horizons[len(sw.horizons)-1])
+	return nil
+}
+
+// horizon returns the registered horizon that covers the same buckets as the
+// specified window, or nil. It must be called with the lock held.
+func (sw *SlidingWindow) horizon(window time.Duration) *horizon {
+	if window > sw.window {
+		window = sw.window
+	}
+
+	n := sw.round(window)
+	for i := range sw.horizons {
+		if sw.horizons[i].n == n {
+			return &sw.horizons[i]
+		}
+	}
+
+	return nil
+}
+
+// rotateHorizons moves the current bucket into the completed buckets of every
+// horizon, and drops the bucket that falls out of it. It must be called with
+// the lock held, right before the current bucket moves on.
+func (sw *SlidingWindow) rotateHorizons() {
+	for i := range sw.horizons {
+		h := &sw.horizons[i]
+		if h.rotations++; h.rotations >= h.n {
+			// The rescan happens once the current bucket moved on.
+			continue
+		}
+
+		oldest := sw.index(h.n - 1)
+		h.sum += sw.sum(sw.pos) - sw.sum(oldest)
+		h.count += sw.count(sw.pos) - sw.count(oldest)
+	}
+}
+
+// rescanHorizons recomputes the sums of the horizons that are due, or of all
+// of them if all is true. It must be called with the lock held.
+func (sw *SlidingWindow) rescanHorizons(all bool) {
+	for i := range sw.horizons {
+		if all || sw.horizons[i].rotations >= sw.horizons[i].n {
+			sw.rescan(&sw.horizons[i])
+		}
+	}
+}
+
+// rescan sums the completed buckets of h. It must be called with the lock
+// held.
+func (sw *SlidingWindow) rescan(h *horizon) {
+	h.sum, h.count, h.rotations = 0, 0, 0
+	for age := 1; age < h.n; age++ {
+		pos := sw.index(age)
+		h.sum += sw.sum(pos)
+		h.count += sw.count(pos)
+	}
+}
@@ -0,0 +1,81 @@
+package average
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterHorizon(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+
+	assert.EqualError(t, sw.RegisterHorizon(0), "horizon has to be positive")
+	assert.EqualError(t, sw.RegisterHorizon(11*time.Second), "horizon is larger than the window")
+	assert.EqualError(t, sw.RegisterHorizon(time.Millisecond), "horizon is smaller than the granularity")
+
+	assert.NoError(t, sw.RegisterHorizon(5*time.Second))
+	assert.NoError(t, sw.RegisterHorizon(5500*time.Millisecond))
+	assert.Len(t, sw.horizons, 1)
+}
+
+func TestRegisterHorizonTotals(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	plain := MustNewReplay(10*time.Second, time.Second, start, WithGracePeriod(3*time.Second))
+	cached := MustNewReplay(10*time.Second, time.Second, start, WithGracePeriod(3*time.Second))
+	defer plain.Stop()
+	defer cached.Stop()
+
+	horizons := []time.Duration{time.Second, 3 * time.Second, 10 * time.Second}
+	for _, d := range horizons {
+		assert.NoError(t, cached.RegisterHorizon(d))
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	now := start
+	for i := 0; i < 500; i++ {
+		switch r := rng.Intn(20); {
+		case r == 0:
+			plain.Trim(4 * time.Second)
+			cached.Trim(4 * time.Second)
+		case r == 1:
+			plain.Reset()
+			cached.Reset()
+		case r < 5:
+			late := now.Add(-time.Duration(rng.Intn(4000)) * time.Millisecond)
+			v := float64(rng.Intn(10))
+			assert.Equal(t, plain.AddAt(late, v), cached.AddAt(late, v))
+		default:
+			now = now.Add(time.Duration(rng.Intn(1500)) * time.Millisecond)
+			v := float64(rng.Intn(10))
+			plain.AddAt(now, v)
+			cached.AddAt(now, v)
+		}
+
+		for _, d := range horizons {
+			total, count := plain.Total(d)
+			cachedTotal, cachedCount := cached.Total(d)
+			assert.Equal(t, total, cachedTotal, "step %d, horizon %s", i, d)
+			assert.Equal(t, count, cachedCount, "step %d, horizon %s", i, d)
+		}
+	}
+
+	// Restoring a snapshot rewrites all buckets.
+	other := MustNewReplay(10*time.Second, time.Second, now.Add(-5*time.Second))
+	for i := 5; i >= 0; i-- {
+		other.AddAt(now.Add(-time.Duration(i)*time.Second), float64(i))
+	}
+	cached.Lock()
+	assert.NoError(t, cached.restore(other.Snapshot()))
+	cached.Unlock()
+
+	s := cached.Snapshot()
+	for _, d := range horizons {
+		total, count := s.Total(d)
+		cachedTotal, cachedCount := cached.Total(d)
+		assert.Equal(t, total, cachedTotal, d)
+		assert.Equal(t, count, cachedCount, d)
+	}
+}
@@ -0,0 +1,168 @@
+// Package httpwindow shares sliding windows between the replicas of a service
+// over HTTP, so that rolling averages can be computed for the whole fleet
+// without a metrics backend.
+//
+// Every replica serves the snapshots of the windows in a registry with a
+// Handler, and a Client fetches the snapshot of a window from all replicas and
+// merges them into a single snapshot. Snapshots are encoded as JSON.
+package httpwindow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/prep/average"
+)
+
+// Handler serves the snapshots of the windows in a registry. A request with a
+// name query parameter, like "/windows?name=requests", returns the snapshot
+// of that window, and a request without one returns the snapshots of all
+// windows by name.
+type Handler struct {
+	registry *average.Registry
+}
+
+// NewHandler returns a new Handler for the windows in r, or in the
+// DefaultRegistry if r is nil.
+func NewHandler(r *average.Registry) *Handler {
+	if r == nil {
+		r = average.DefaultRegistry
+	}
+
+	return &Handler{registry: r}
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var v interface{}
+	if name := r.URL.Query().Get("name"); name != "" {
+		sw, ok := h.registry.Get(name)
+		if !ok {
+			http.Error(w, "unknown window "+name, http.StatusNotFound)
+			return
+		}
+
+		v = sw.Snapshot()
+	} else {
+		v = h.registry.Snapshots()
+	}
+
+	// Encode first, so that a snapshot that can't be encoded, like one with a
+	// NaN or an infinite value, results in an error instead of a partial body.
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// Client fetches the snapshots of a window from multiple replicas and merges
+// them.
+type Client struct {
+	client   *http.Client
+	replicas []string
+}
+
+// NewClient returns a new Client for the replicas at the specified URLs of
+// their Handlers, like "http://10.0.0.1:8080/windows". If client is nil,
+// http.DefaultClient is used.
+func NewClient(client *http.Client, replicas ...string) (*Client, error) {
+	if len(replicas) == 0 {
+		return nil, errors.New("at least one replica is required")
+	}
+	for _, replica := range replicas {
+		if _, err := url.Parse(replica); err != nil {
+			return nil, err
+		}
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Client{client: client, replicas: append([]string(nil), replicas...)}, nil
+}
+
+// Snapshot fetches the snapshot of the named window from every replica at
+// once, and returns their merged snapshot. The windows on all replicas need to
+// have the same configuration. An error is returned if any replica fails, as
+// a snapshot without it would silently undercount.
+func (c *Client) Snapshot(ctx context.Context, name string) (average.Snapshot, error) {
+	snapshots := make([]average.Snapshot, len(c.replicas))
+	errs := make([]error, len(c.replicas))
+
+	var wg sync.WaitGroup
+	for i, replica := range c.replicas {
+		wg.Add(1)
+		go func(i int, replica string) {
+			defer wg.Done()
+			snapshots[i], errs[i] = c.fetch(ctx, replica, name)
+		}(i, replica)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return average.Snapshot{}, err
+		}
+	}
+
+	merged := snapshots[0]
+	for _, s := range snapshots[1:] {
+		var err error
+		if merged, err = merged.Merge(s); err != nil {
+			return average.Snapshot{}, err
+		}
+	}
+
+	return merged, nil
+}
+
+// fetch returns the snapshot of the named window of a single replica.
+func (c *Client) fetch(ctx context.Context, replica, name string) (average.Snapshot, error) {
+	u, err := url.Parse(replica)
+	if err != nil {
+		return average.Snapshot{}, err
+	}
+
+	q := u.Query()
+	q.Set("name", name)
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return average.Snapshot{}, err
+	}
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return average.Snapshot{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return average.Snapshot{}, fmt.Errorf("replica %s returned %s", replica, resp.Status)
+	}
+
+	var s average.Snapshot
+	if err := json.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return average.Snapshot{}, fmt.Errorf("replica %s returned an invalid snapshot: %v", replica, err)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,96 @@
+package httpwindow
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prep/average"
+	"github.com/stretchr/testify/assert"
+)
+
+func newReplica(t *testing.T, v float64) (*average.SlidingWindow, *httptest.Server) {
+	sw := average.MustNew(time.Minute, time.Second)
+	sw.Add(v)
+
+	r := average.NewRegistry()
+	assert.NoError(t, r.Register("requests", sw))
+
+	return sw, httptest.NewServer(NewHandler(r))
+}
+
+func TestHandler(t *testing.T) {
+	sw, srv := newReplica(t, 3)
+	defer sw.Stop()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?name=requests")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var s average.Snapshot
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&s))
+	assert.Equal(t, time.Minute, s.Window)
+	assert.Equal(t, 3.0, s.Average(time.Minute))
+
+	resp, err = http.Get(srv.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var all map[string]average.Snapshot
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&all))
+	assert.Len(t, all, 1)
+	assert.Equal(t, []float64{3}, all["requests"].Samples)
+
+	resp, err = http.Get(srv.URL + "?name=unknown")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Post(srv.URL, "text/plain", nil)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestHandlerEncodeError(t *testing.T) {
+	sw, srv := newReplica(t, math.Inf(1))
+	defer sw.Stop()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?name=requests")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.NotEqual(t, "application/json", resp.Header.Get("Content-Type"))
+}
+
+func TestClient(t *testing.T) {
+	_, err := NewClient(nil)
+	assert.EqualError(t, err, "at least one replica is required")
+
+	sw1, srv1 := newReplica(t, 3)
+	defer sw1.Stop()
+	defer srv1.Close()
+
+	sw2, srv2 := newReplica(t, 5)
+	defer sw2.Stop()
+	defer srv2.Close()
+
+	c, err := NewClient(nil, srv1.URL, srv2.URL)
+	assert.NoError(t, err)
+
+	s, err := c.Snapshot(context.Background(), "requests")
+	assert.NoError(t, err)
+
+	total, count := s.Total(time.Minute)
+	assert.Equal(t, 8.0, total)
+	assert.Equal(t, int64(2), count)
+
+	_, err = c.Snapshot(context.Background(), "unknown")
+	assert.EqualError(t, err, "replica "+srv1.URL+" returned 404 Not Found")
+}
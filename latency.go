@@ -0,0 +1,131 @@
+package average
+
+import "time"
+
+// LatencyWindow is a sliding time window for durations. Next to the total and
+// average that a SlidingWindow provides, it keeps an HDR histogram per bucket
+// so that quantiles over a subset of the window can be determined with a
+// bounded relative error.
+type LatencyWindow struct {
+	sw      *SlidingWindow
+	hists   []*histogram
+	lowest  int64
+	highest int64
+	sigfigs int
+}
+
+// MustNewLatencyWindow returns a new LatencyWindow, but panics if an error
+// occurs.
+func MustNewLatencyWindow(window, granularity, lowest, highest time.Duration, sigfigs int) *LatencyWindow {
+	lw, err := NewLatencyWindow(window, granularity, lowest, highest, sigfigs)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return lw
+}
+
+// NewLatencyWindow returns a new LatencyWindow that tracks durations between
+// lowest and highest with sigfigs significant figures. Durations outside of
+// this range are clamped to it.
+//
+// The histograms of the buckets are allocated on first use. Their size grows
+// with the number of significant figures and the ratio between highest and
+// lowest, so keep both as small as the use case allows.
+func NewLatencyWindow(window, granularity, lowest, highest time.Duration, sigfigs int) (*LatencyWindow, error) {
+	if _, err := newHistogram(int64(lowest), int64(highest), sigfigs); err != nil {
+		return nil, err
+	}
+
+	sw, err := newSlidingWindow(window, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	lw := &LatencyWindow{
+		sw:      sw,
+		hists:   make([]*histogram, sw.len()),
+		lowest:  int64(lowest),
+		highest: int64(highest),
+		sigfigs: sigfigs,
+	}
+	sw.onClear = lw.clear
+
+	sw.startShifter()
+	return lw, nil
+}
+
+func (lw *LatencyWindow) clear(pos int) {
+	if h := lw.hists[pos]; h != nil {
+		h.reset()
+	}
+}
+
+// Record adds the duration d to the current bucket.
+func (lw *LatencyWindow) Record(d time.Duration) {
+	lw.sw.Lock()
+	defer lw.sw.Unlock()
+
+	if !lw.sw.countAdd() {
+		return
+	}
+
+	h := lw.hists[lw.sw.pos]
+	if h == nil {
+		h, _ = newHistogram(lw.lowest, lw.highest, lw.sigfigs)
+		lw.hists[lw.sw.pos] = h
+	}
+
+	h.record(int64(d), 1)
+	lw.sw.increment(lw.sw.pos, float64(d), 1)
+}
+
+// Average returns the mean of the durations recorded over the specified
+// window.
+func (lw *LatencyWindow) Average(window time.Duration) time.Duration {
+	return time.Duration(lw.sw.Average(window))
+}
+
+// Count returns the number of durations recorded over the specified window.
+func (lw *LatencyWindow) Count(window time.Duration) int64 {
+	_, count := lw.sw.Total(window)
+	return count
+}
+
+// ValueAtQuantile returns the duration that q percent of the durations recorded
+// over the specified window are smaller than or equal to. For instance, a q of
+// 99 returns the p99 latency.
+func (lw *LatencyWindow) ValueAtQuantile(window time.Duration, q float64) time.Duration {
+	lw.sw.RLock()
+	defer lw.sw.RUnlock()
+
+	var merged *histogram
+	for i, n := 0, lw.sw.buckets(window); i < n; i++ {
+		h := lw.hists[lw.sw.index(i)]
+		if h == nil || h.total == 0 {
+			continue
+		}
+
+		if merged == nil {
+			merged, _ = newHistogram(lw.lowest, lw.highest, lw.sigfigs)
+		}
+		merged.merge(h)
+	}
+
+	if merged == nil {
+		return 0
+	}
+
+	return time.Duration(merged.valueAtQuantile(q))
+}
+
+// Debug returns the DebugStats of this latency window.
+func (lw *LatencyWindow) Debug() DebugStats {
+	return lw.sw.Debug()
+}
+
+// Stop the shifter of this latency window. A stopped LatencyWindow cannot be
+// started again.
+func (lw *LatencyWindow) Stop() {
+	lw.sw.Stop()
+}
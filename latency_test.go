@@ -0,0 +1,52 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLatencyWindow(t *testing.T) {
+	_, err := NewLatencyWindow(time.Second, time.Second, time.Microsecond, time.Minute, 3)
+	assert.EqualError(t, err, "window size has to be a multiplier of the granularity size")
+
+	_, err = NewLatencyWindow(10*time.Second, time.Second, time.Microsecond, time.Minute, 0)
+	assert.EqualError(t, err, "significant figures have to be between 1 and 5")
+}
+
+func TestLatencyWindowValueAtQuantile(t *testing.T) {
+	lw := MustNewLatencyWindow(10*time.Second, time.Second, time.Microsecond, time.Minute, 3)
+	defer lw.Stop()
+
+	for i := 1; i <= 100; i++ {
+		lw.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	assert.Equal(t, int64(100), lw.Count(time.Second))
+	assert.Equal(t, 50500*time.Microsecond, lw.Average(time.Second))
+	assert.InDelta(t, float64(50*time.Millisecond), float64(lw.ValueAtQuantile(time.Second, 50)), float64(50*time.Microsecond))
+	assert.InDelta(t, float64(99*time.Millisecond), float64(lw.ValueAtQuantile(time.Second, 99)), float64(99*time.Microsecond))
+}
+
+func TestLatencyWindowShift(t *testing.T) {
+	lw := MustNewLatencyWindow(2*time.Second, time.Second, time.Microsecond, time.Minute, 3)
+	defer lw.Stop()
+
+	lw.Record(time.Millisecond)
+
+	lw.sw.Lock()
+	lw.sw.shift()
+	lw.sw.Unlock()
+	lw.Record(time.Second)
+
+	assert.InDelta(t, float64(time.Second), float64(lw.ValueAtQuantile(time.Second, 50)), float64(time.Millisecond))
+	assert.InDelta(t, float64(time.Millisecond), float64(lw.ValueAtQuantile(2*time.Second, 50)), float64(time.Microsecond))
+
+	lw.sw.Lock()
+	lw.sw.shift()
+	lw.sw.Unlock()
+
+	assert.Equal(t, time.Duration(0), lw.ValueAtQuantile(time.Second, 50))
+	assert.InDelta(t, float64(time.Second), float64(lw.ValueAtQuantile(2*time.Second, 50)), float64(time.Millisecond))
+}
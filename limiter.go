@@ -0,0 +1,305 @@
+package average
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Limiter is a rate limiter that combines a token bucket, which allows short
+// bursts, with a sliding window that enforces the sustained rate exactly: no
+// more than limit events are allowed over any window. Its API mirrors that of
+// golang.org/x/time/rate. A Limiter doesn't run a goroutine, as its window
+// only advances when the limiter is used.
+type Limiter struct {
+	mu      sync.Mutex
+	sw      *SlidingWindow
+	limit   float64
+	burst   int
+	rate    float64 // The number of tokens per second.
+	tokens  float64
+	last    time.Time
+	pending []*Reservation // Reservations that are due in the future, by time.
+}
+
+// Reservation holds the events that a Limiter will allow after a delay.
+type Reservation struct {
+	l  *Limiter
+	ok bool
+	n  int
+	at time.Time
+}
+
+// MustNewLimiter returns a new Limiter, but panics if an error occurs.
+func MustNewLimiter(limit int, window, granularity time.Duration, burst int) *Limiter {
+	l, err := NewLimiter(limit, window, granularity, burst)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return l
+}
+
+// NewLimiter returns a new Limiter that allows up to limit events over every
+// window, and up to burst events at once. The token bucket refills at the
+// sustained rate of limit events per window, and starts out full.
+func NewLimiter(limit int, window, granularity time.Duration, burst int) (*Limiter, error) {
+	if limit < 1 {
+		return nil, errors.New("limit has to be at least 1")
+	}
+	if burst < 1 {
+		return nil, errors.New("burst has to be at least 1")
+	}
+
+	now := time.Now()
+	sw, err := NewReplay(window, granularity, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Limiter{
+		sw:     sw,
+		limit:  float64(limit),
+		burst:  burst,
+		rate:   float64(limit) / window.Seconds(),
+		tokens: float64(burst),
+		last:   now,
+	}, nil
+}
+
+// Allow reports whether an event may happen now.
+func (l *Limiter) Allow() bool {
+	return l.AllowN(time.Now(), 1)
+}
+
+// AllowN reports whether n events may happen at time now. If so, they are
+// counted against the limiter. Events before the current bucket of the window
+// of the limiter can't be counted anymore, so those aren't allowed, and
+// neither is a negative number of events.
+func (l *Limiter) AllowN(now time.Time, n int) bool {
+	if n < 0 {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.advance(now)
+	if l.delay(now, n) != 0 || !l.sw.AddAt(now, float64(n)) {
+		return false
+	}
+
+	l.tokens -= float64(n)
+	return true
+}
+
+// Reserve returns a Reservation for an event, which tells how long the caller
+// has to wait before the event may happen.
+func (l *Limiter) Reserve() *Reservation {
+	return l.ReserveN(time.Now(), 1)
+}
+
+// ReserveN returns a Reservation for n events at time now. The events are
+// counted against the limiter at the time they may happen, unless the
+// reservation is canceled before that. The reservation is not OK if n is
+// negative, if n exceeds the burst or the limit, as those events would never
+// be allowed, or if now is before the current bucket of the window of the
+// limiter, as those events can't be counted anymore.
+func (l *Limiter) ReserveN(now time.Time, n int) *Reservation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if n < 0 || n > l.burst || float64(n) > l.limit || l.late(now) {
+		return &Reservation{l: l, n: n}
+	}
+
+	l.advance(now)
+	r := &Reservation{l: l, ok: true, n: n, at: now.Add(l.delay(now, n))}
+	if !r.at.After(now) {
+		if !l.sw.AddAt(now, float64(n)) {
+			return &Reservation{l: l, n: n}
+		}
+
+		l.tokens -= float64(n)
+		return r
+	}
+	l.tokens -= float64(n)
+
+	i := sort.Search(len(l.pending), func(i int) bool { return l.pending[i].at.After(r.at) })
+	l.pending = append(l.pending, nil)
+	copy(l.pending[i+1:], l.pending[i:])
+	l.pending[i] = r
+	return r
+}
+
+// Wait blocks until an event may happen, or until ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n events may happen, or until ctx is done. It returns an
+// error if n is negative or exceeds the burst or the limit, or if ctx has a deadline before
+// the events may happen.
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	r := l.ReserveN(now, n)
+	if !r.OK() {
+		return errors.New("events exceed the burst or the limit of the limiter")
+	}
+
+	delay := r.DelayFrom(now)
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(r.at) {
+		r.Cancel()
+		return errors.New("waiting for the limiter would exceed the context deadline")
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// Debug returns the DebugStats of the window of this limiter, which counts
+// every batch of events that was allowed, and drops the batches that were too
+// late to be counted.
+func (l *Limiter) Debug() DebugStats {
+	return l.sw.Debug()
+}
+
+// advance refills the token bucket, counts the reservations that are due and
+// moves the window forward to now. It must be called with the lock held.
+func (l *Limiter) advance(now time.Time) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = math.Min(float64(l.burst), l.tokens+elapsed.Seconds()*l.rate)
+		l.last = now
+	}
+
+	for len(l.pending) > 0 && !l.pending[0].at.After(now) {
+		r := l.pending[0]
+		l.pending[0] = nil
+		l.pending = l.pending[1:]
+
+		l.sw.AdvanceTo(r.at)
+		l.sw.AddAt(r.at, float64(r.n))
+	}
+
+	l.sw.AdvanceTo(now)
+}
+
+// late returns whether now is before the current bucket of the window, so
+// that events at that time can't be counted. It must be called with the lock
+// held.
+func (l *Limiter) late(now time.Time) bool {
+	l.sw.RLock()
+	defer l.sw.RUnlock()
+
+	return now.Before(l.sw.start)
+}
+
+// delay returns how long n events have to wait until both the token bucket
+// and the window allow them. It must be called with the lock held, after
+// advance.
+func (l *Limiter) delay(now time.Time, n int) time.Duration {
+	var delay time.Duration
+	if missing := float64(n) - l.tokens; missing > 0 {
+		delay = time.Duration(math.Ceil(missing / l.rate * float64(time.Second)))
+	}
+
+	type expiry struct {
+		at time.Time
+		n  float64
+	}
+
+	// The events in the window expire with their buckets, and the pending
+	// reservations one window after they are due.
+	s := l.sw.Snapshot()
+	expiries := make([]expiry, 0, len(s.Samples)+len(l.pending))
+	var used float64
+	for i, v := range s.Samples {
+		age := time.Duration(l.sw.len()-i) * s.Granularity
+		expiries = append(expiries, expiry{at: s.Start.Add(age), n: v})
+		used += v
+	}
+	for _, r := range l.pending {
+		expiries = append(expiries, expiry{at: r.at.Add(s.Window), n: float64(r.n)})
+		used += float64(r.n)
+	}
+
+	excess := used + float64(n) - l.limit
+	if excess <= 0 {
+		return delay
+	}
+
+	sort.Slice(expiries, func(i, j int) bool { return expiries[i].at.Before(expiries[j].at) })
+	for _, e := range expiries {
+		if excess -= e.n; excess <= 0 {
+			if d := e.at.Sub(now); d > delay {
+				delay = d
+			}
+			break
+		}
+	}
+
+	return delay
+}
+
+// OK returns whether the limiter can allow the reserved events at all.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay returns how long the caller has to wait before the reserved events
+// may happen.
+func (r *Reservation) Delay() time.Duration {
+	return r.DelayFrom(time.Now())
+}
+
+// DelayFrom returns how long the caller has to wait from now before the
+// reserved events may happen. It returns math.MaxInt64 if the reservation is
+// not OK.
+func (r *Reservation) DelayFrom(now time.Time) time.Duration {
+	if !r.ok {
+		return math.MaxInt64
+	}
+	if d := r.at.Sub(now); d > 0 {
+		return d
+	}
+
+	return 0
+}
+
+// Cancel returns the reserved events to the limiter, if they aren't due yet,
+// so that other events may use them.
+func (r *Reservation) Cancel() {
+	if !r.ok {
+		return
+	}
+
+	l := r.l
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, p := range l.pending {
+		if p == r {
+			l.pending = append(l.pending[:i], l.pending[i+1:]...)
+			l.tokens = math.Min(float64(l.burst), l.tokens+float64(r.n))
+			return
+		}
+	}
+}
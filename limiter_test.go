@@ -0,0 +1,131 @@
+package average
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLimiter(t *testing.T) {
+	_, err := NewLimiter(0, time.Minute, time.Second, 1)
+	assert.EqualError(t, err, "limit has to be at least 1")
+
+	_, err = NewLimiter(10, time.Minute, time.Second, 0)
+	assert.EqualError(t, err, "burst has to be at least 1")
+
+	_, err = NewLimiter(10, time.Second, time.Second, 1)
+	assert.EqualError(t, err, "window size has to be a multiplier of the granularity size")
+
+	assert.Panics(t, func() { MustNewLimiter(0, time.Minute, time.Second, 1) })
+}
+
+func TestLimiterAllowN(t *testing.T) {
+	// 10 events per 10 seconds, refilling 1 token per second, in bursts of 5.
+	l := MustNewLimiter(10, 10*time.Second, time.Second, 5)
+	now := l.last
+
+	assert.True(t, l.AllowN(now, 5))
+	assert.False(t, l.AllowN(now, 1))
+
+	// The token bucket refills, but the window still allows the sustained
+	// rate only.
+	now = now.Add(5 * time.Second)
+	assert.True(t, l.AllowN(now, 5))
+	now = now.Add(4 * time.Second)
+	assert.False(t, l.AllowN(now, 1))
+
+	// Once the first bucket expires, its events can be used again.
+	now = now.Add(time.Second)
+	assert.True(t, l.AllowN(now, 4))
+	assert.False(t, l.AllowN(now, 2))
+	assert.True(t, l.AllowN(now, 1))
+}
+
+func TestLimiterNegative(t *testing.T) {
+	l := MustNewLimiter(10, 10*time.Second, time.Second, 5)
+	now := l.last
+
+	// A negative number of events would hand back tokens beyond the burst.
+	assert.True(t, l.AllowN(now, 5))
+	assert.False(t, l.AllowN(now, -5))
+	assert.False(t, l.ReserveN(now, -5).OK())
+	assert.Equal(t, 0.0, l.tokens)
+
+	total, _ := l.sw.Total(10 * time.Second)
+	assert.Equal(t, 5.0, total)
+}
+
+func TestLimiterAllowNLate(t *testing.T) {
+	l := MustNewLimiter(10, 10*time.Second, time.Second, 5)
+	now := l.last
+
+	assert.True(t, l.AllowN(now.Add(2*time.Second), 1))
+	tokens := l.tokens
+
+	// Events before the current bucket can't be counted, so they are neither
+	// allowed nor reserved, and don't use tokens.
+	assert.False(t, l.AllowN(now, 1))
+	assert.False(t, l.ReserveN(now, 1).OK())
+	assert.Equal(t, tokens, l.tokens)
+
+	total, _ := l.sw.Total(10 * time.Second)
+	assert.Equal(t, 1.0, total)
+}
+
+func TestLimiterReserveN(t *testing.T) {
+	l := MustNewLimiter(4, 4*time.Second, time.Second, 2)
+	now := l.last
+
+	r := l.ReserveN(now, 2)
+	assert.True(t, r.OK())
+	assert.Equal(t, time.Duration(0), r.DelayFrom(now))
+
+	// The tokens are missing.
+	r = l.ReserveN(now, 2)
+	assert.Equal(t, 2*time.Second, r.DelayFrom(now))
+	assert.Equal(t, time.Second, r.DelayFrom(now.Add(time.Second)))
+
+	// The window is full until the first bucket expires.
+	r = l.ReserveN(now, 1)
+	assert.Equal(t, 4*time.Second, r.DelayFrom(now))
+
+	// Canceling returns the events.
+	r.Cancel()
+	r.Cancel()
+	assert.False(t, l.AllowN(now.Add(2*time.Second), 1))
+	r = l.ReserveN(now.Add(2*time.Second), 1)
+	assert.Equal(t, 2*time.Second, r.DelayFrom(now.Add(2*time.Second)))
+
+	// Due reservations are counted in their bucket, and the first bucket has
+	// expired by now.
+	assert.True(t, l.AllowN(now.Add(5*time.Second), 1))
+	assert.Equal(t, []int64{1, 1, 0, 1}, l.sw.Counts(4*time.Second))
+	total, _ := l.sw.Total(4 * time.Second)
+	assert.Equal(t, 4.0, total)
+
+	r = l.ReserveN(now, 3)
+	assert.False(t, r.OK())
+	assert.Equal(t, time.Duration(1<<63-1), r.Delay())
+	r.Cancel()
+}
+
+func TestLimiterWait(t *testing.T) {
+	l := MustNewLimiter(100, time.Second, 10*time.Millisecond, 1)
+
+	assert.NoError(t, l.Wait(context.Background()))
+	assert.NoError(t, l.Wait(context.Background()))
+	assert.True(t, time.Since(l.last) >= 0)
+
+	assert.Error(t, l.WaitN(context.Background(), 2))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	l.AllowN(time.Now(), 1)
+	assert.EqualError(t, l.WaitN(ctx, 1), "waiting for the limiter would exceed the context deadline")
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, l.Wait(ctx))
+}
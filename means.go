@@ -0,0 +1,87 @@
+package average
+
+import (
+	"math"
+	"time"
+)
+
+// meanSums are the sums of a bucket that the geometric and harmonic means are
+// derived from.
+type meanSums struct {
+	logs        float64 // The sum of the natural logarithms of the values.
+	reciprocals float64 // The sum of the reciprocals of the values.
+	count       int64
+}
+
+// WithMeans keeps track of the sums of the logarithms and of the reciprocals of
+// the values in every bucket, so that GeometricMean and HarmonicMean can
+// average ratios and rates correctly. Both means are only defined for positive
+// values. The sums are not part of snapshots, so after a restore the means
+// only cover the values that were added since.
+func WithMeans() Option {
+	return func(sw *SlidingWindow) error {
+		sw.means = make([]meanSums, sw.len())
+		return nil
+	}
+}
+
+// mean adds the value v, which was added n times, to the sums of the current
+// bucket. It must be called with the lock held.
+func (sw *SlidingWindow) mean(v float64, n int64) {
+	m := &sw.means[sw.pos]
+	m.logs += float64(n) * math.Log(v)
+	m.reciprocals += float64(n) / v
+	m.count += n
+}
+
+// GeometricMean returns the geometric mean of the values over the specified
+// window, which is the right way to average ratios like growth factors. It
+// returns 0 if there are no values or the window was not created with
+// WithMeans, and NaN if any of the values is negative.
+func (sw *SlidingWindow) GeometricMean(window time.Duration) float64 {
+	logs, _, count := sw.meanSums(window)
+	if count == 0 {
+		return 0
+	}
+
+	return math.Exp(logs / float64(count))
+}
+
+// HarmonicMean returns the harmonic mean of the values over the specified
+// window, which is the right way to average rates like requests per second
+// over equal amounts of work. It returns 0 if there are no values, if any of
+// the values is 0 or if the window was not created with WithMeans.
+func (sw *SlidingWindow) HarmonicMean(window time.Duration) float64 {
+	_, reciprocals, count := sw.meanSums(window)
+	if count == 0 || math.IsInf(reciprocals, 1) {
+		return 0
+	}
+
+	return float64(count) / reciprocals
+}
+
+// meanSums returns the sums of the logarithms and of the reciprocals over the
+// specified window, as well as the number of values.
+func (sw *SlidingWindow) meanSums(window time.Duration) (float64, float64, int64) {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	if sw.means == nil {
+		return 0, 0, 0
+	}
+
+	var logs, reciprocals float64
+	var count int64
+	for i, n := 0, sw.buckets(window); i < n; i++ {
+		if sw.open(i) {
+			continue
+		}
+
+		m := &sw.means[sw.index(i)]
+		logs += m.logs
+		reciprocals += m.reciprocals
+		count += m.count
+	}
+
+	return logs, reciprocals, count
+}
@@ -0,0 +1,56 @@
+package average
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMeans(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithMeans(), WithManualClock())
+	defer sw.Stop()
+
+	assert.Equal(t, 0.0, sw.GeometricMean(4*time.Second))
+	assert.Equal(t, 0.0, sw.HarmonicMean(4*time.Second))
+
+	sw.Add(1)
+	sw.Add(4)
+	sw.Advance(time.Second)
+	sw.AddN(32, 2)
+
+	assert.InDelta(t, math.Pow(1*4*16*16, 0.25), sw.GeometricMean(4*time.Second), 1e-9)
+	assert.InDelta(t, 4/(1+0.25+2.0/16), sw.HarmonicMean(4*time.Second), 1e-9)
+	assert.InDelta(t, 16, sw.GeometricMean(time.Second), 1e-9)
+	assert.InDelta(t, 16, sw.HarmonicMean(time.Second), 1e-9)
+
+	// The sums of a bucket expire with it.
+	sw.Advance(3 * time.Second)
+	assert.InDelta(t, 16, sw.GeometricMean(4*time.Second), 1e-9)
+}
+
+func TestWithMeansNonPositive(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithMeans())
+	defer sw.Stop()
+
+	sw.Add(2)
+	sw.Add(0)
+	assert.Equal(t, 0.0, sw.GeometricMean(4*time.Second))
+	assert.Equal(t, 0.0, sw.HarmonicMean(4*time.Second))
+
+	sw.Add(-1)
+	assert.True(t, math.IsNaN(sw.GeometricMean(4*time.Second)))
+}
+
+func TestWithMeansErrors(t *testing.T) {
+	_, err := New(4*time.Second, time.Second, WithoutCounts(), WithMeans())
+	assert.EqualError(t, err, "geometric and harmonic means require sample counts")
+
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Add(2)
+	assert.Equal(t, 0.0, sw.GeometricMean(4*time.Second))
+	assert.Equal(t, 0.0, sw.HarmonicMean(4*time.Second))
+}
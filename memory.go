@@ -0,0 +1,92 @@
+package average
+
+import (
+	"errors"
+	"time"
+	"unsafe"
+)
+
+// WithMaxBuckets caps the number of buckets of a window at n, so that the
+// memory of thousands of windows can be budgeted up front. What happens when
+// the window and granularity need more buckets than that depends on coarsen:
+// if it's false, the configuration is rejected with an error. If it's true,
+// the granularity is raised to the smallest multiple of itself that divides
+// the window into at most n buckets, and an error is only returned if there
+// is no such granularity. The window then answers queries at the raised
+// granularity, which Snapshot reports. n has to be at least 2.
+func WithMaxBuckets(n int, coarsen bool) Option {
+	return func(sw *SlidingWindow) error {
+		if n < 2 {
+			return errors.New("maximum number of buckets has to be at least 2")
+		}
+
+		sw.maxBuckets = n
+		sw.coarsen = coarsen
+		return nil
+	}
+}
+
+// capGranularity returns the granularity that keeps window within the maximum
+// number of buckets of this window.
+func (sw *SlidingWindow) capGranularity(window, granularity time.Duration) (time.Duration, error) {
+	if !sw.coarsen {
+		return 0, errors.New("window has more buckets than the maximum")
+	}
+
+	// Find the largest number of buckets, within the maximum, that the window
+	// splits into evenly.
+	buckets := int64(window / granularity)
+	for n := int64(sw.maxBuckets); n >= 2; n-- {
+		if buckets%n == 0 {
+			return granularity * time.Duration(buckets/n), nil
+		}
+	}
+
+	return 0, errors.New("no granularity divides the window into the maximum number of buckets")
+}
+
+// MemoryFootprint returns the approximate number of bytes that this window
+// uses, which is the SlidingWindow itself plus its buckets and their optional
+// state, like the extrema, reservoirs, gauge, horizons and the view of
+// lock-free reads. The state of subscriptions and of the types that wrap a
+// SlidingWindow, like a DistinctWindow, is not included.
+func (sw *SlidingWindow) MemoryFootprint() int {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	n := int(unsafe.Sizeof(*sw))
+	n += 8*cap(sw.samples) + 8*cap(sw.counts)
+	n += 4*cap(sw.samples32) + 4*cap(sw.counts32)
+	n += cap(sw.valid) + cap(sw.overflows) + cap(sw.written)
+	n += 8*cap(sw.mins) + 8*cap(sw.maxs) + 8*cap(sw.weights)
+	n += int(unsafe.Sizeof(p2{})) * cap(sw.estimates)
+	n += int(unsafe.Sizeof(meanSums{})) * cap(sw.means)
+	n += int(unsafe.Sizeof(moments{})) * cap(sw.moments)
+	n += int(unsafe.Sizeof(horizon{})) * cap(sw.horizons)
+
+	if sw.gauge != nil {
+		n += int(unsafe.Sizeof(*sw.gauge))
+		n += 8*cap(sw.gauge.areas) + 8*cap(sw.gauge.times)
+	}
+
+	if sw.lockFree {
+		v := sw.view.Load().(*readView)
+		n += int(unsafe.Sizeof(*v)) + int(unsafe.Sizeof(*v.current))
+		n += int(unsafe.Sizeof(viewBucket{})) * cap(v.completed)
+	}
+
+	if sw.reservoirs != nil {
+		n += int(unsafe.Sizeof([]float64(nil))) * cap(sw.reservoirs)
+		for _, res := range sw.reservoirs {
+			n += 8 * cap(res)
+		}
+	}
+
+	return n
+}
+
+// MemoryFootprint returns the approximate number of bytes that the buckets of
+// both parts of this tiered window use.
+func (tw *TieredWindow) MemoryFootprint() int {
+	return tw.fine.MemoryFootprint() + tw.coarse.MemoryFootprint()
+}
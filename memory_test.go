@@ -0,0 +1,87 @@
+package average
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMaxBuckets(t *testing.T) {
+	_, err := New(time.Minute, time.Second, WithMaxBuckets(1, true))
+	assert.EqualError(t, err, "maximum number of buckets has to be at least 2")
+
+	_, err = New(time.Minute, time.Second, WithMaxBuckets(10, false))
+	assert.EqualError(t, err, "window has more buckets than the maximum")
+
+	_, err = New(7*time.Second, time.Second, WithMaxBuckets(5, true))
+	assert.EqualError(t, err, "no granularity divides the window into the maximum number of buckets")
+
+	sw := MustNew(time.Minute, time.Second, WithMaxBuckets(60, false))
+	assert.Equal(t, 60, sw.len())
+	sw.Stop()
+
+	sw = MustNew(time.Minute, time.Second, WithMaxBuckets(10, true))
+	assert.Equal(t, 10, sw.len())
+	assert.Equal(t, 6*time.Second, sw.Snapshot().Granularity)
+	sw.Stop()
+
+	// Options that were applied before WithMaxBuckets use the raised
+	// granularity as well.
+	sw = MustNew(time.Minute, time.Second, WithExtrema(), WithMaxBuckets(7, true))
+	assert.Equal(t, 6, sw.len())
+	assert.Len(t, sw.mins, 6)
+	assert.Equal(t, 10*time.Second, sw.granularity)
+	sw.Stop()
+}
+
+func TestSlidingWindowMemoryFootprint(t *testing.T) {
+	base := int(unsafe.Sizeof(SlidingWindow{}))
+
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+	assert.Equal(t, base+160, sw.MemoryFootprint())
+
+	compact := MustNew(10*time.Second, time.Second, WithCompactStorage())
+	defer compact.Stop()
+	assert.Equal(t, base+80, compact.MemoryFootprint())
+
+	res := MustNew(10*time.Second, time.Second, WithReservoir(4))
+	defer res.Stop()
+
+	empty := res.MemoryFootprint()
+	res.Add(1)
+	assert.Greater(t, res.MemoryFootprint(), empty)
+
+	// A gauge keeps the area and the time of every bucket next to its
+	// validity, and a bound keeps whether every bucket overflowed.
+	gw := MustNew(10*time.Second, time.Second, WithGauge(false), WithBound(100, false))
+	defer gw.Stop()
+	assert.Equal(t, base+160+10+int(unsafe.Sizeof(gauge{}))+160+10, gw.MemoryFootprint())
+
+	hw := MustNew(10*time.Second, time.Second)
+	defer hw.Stop()
+	assert.NoError(t, hw.RegisterHorizon(5*time.Second))
+	assert.Equal(t, base+160+int(unsafe.Sizeof(horizon{})), hw.MemoryFootprint())
+
+	// Lock-free reads keep a copy of the completed buckets, which grows with
+	// the buckets in use.
+	lf := MustNewReplay(10*time.Second, time.Second, time.Now(), WithLockFreeReads())
+	defer lf.Stop()
+	view := int(unsafe.Sizeof(readView{}) + unsafe.Sizeof(seqBucket{}))
+	assert.Equal(t, base+160+view+int(unsafe.Sizeof(viewBucket{})), lf.MemoryFootprint())
+
+	lf.Advance(3 * time.Second)
+	assert.Equal(t, base+160+view+4*int(unsafe.Sizeof(viewBucket{})), lf.MemoryFootprint())
+}
+
+func TestTieredWindowMemoryFootprint(t *testing.T) {
+	tw := MustNewTieredWindow(24*time.Hour, time.Second, time.Hour, time.Minute)
+	defer tw.Stop()
+
+	base := int(unsafe.Sizeof(SlidingWindow{}))
+	// The fine part keeps track of the buckets that were written to for the
+	// buckets that it evicts into the coarse part.
+	assert.Equal(t, 2*base+16*(3600+1380)+3600, tw.MemoryFootprint())
+}
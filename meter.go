@@ -0,0 +1,79 @@
+package average
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// meterGranularity is the granularity of the window of a Meter, which matches
+// the tick interval of the EWMA meters of go-metrics.
+const meterGranularity = 5 * time.Second
+
+// Meter counts events and reports their rate over the last 1, 5 and 15
+// minutes, like the meters of go-metrics. Where those are exponentially
+// weighted moving averages, the rates of a Meter are exact over a sliding
+// window: the events in the window are divided by the time the window covers,
+// including the part of its current bucket that has elapsed.
+type Meter struct {
+	count int64 // Accessed atomically, so it has to stay 64-bit aligned.
+	sw    *SlidingWindow
+	start time.Time
+}
+
+// NewMeter returns a new Meter, which has to be stopped once it is no longer
+// used.
+func NewMeter() *Meter {
+	return &Meter{
+		sw:    MustNew(15*time.Minute, meterGranularity),
+		start: time.Now(),
+	}
+}
+
+// Mark records the occurrence of n events.
+func (m *Meter) Mark(n int64) {
+	atomic.AddInt64(&m.count, n)
+	m.sw.AddN(float64(n), 1)
+}
+
+// Count returns the number of events that were marked since the meter was
+// created.
+func (m *Meter) Count() int64 {
+	return atomic.LoadInt64(&m.count)
+}
+
+// Rate1 returns the number of events per second over the last minute.
+func (m *Meter) Rate1() float64 {
+	return m.sw.Rate(time.Minute)
+}
+
+// Rate5 returns the number of events per second over the last 5 minutes.
+func (m *Meter) Rate5() float64 {
+	return m.sw.Rate(5 * time.Minute)
+}
+
+// Rate15 returns the number of events per second over the last 15 minutes.
+func (m *Meter) Rate15() float64 {
+	return m.sw.Rate(15 * time.Minute)
+}
+
+// RateMean returns the number of events per second since the meter was
+// created.
+func (m *Meter) RateMean() float64 {
+	elapsed := time.Since(m.start).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return float64(m.Count()) / elapsed
+}
+
+// Debug returns the DebugStats of the window of this meter, which counts every
+// call to Mark as a single value.
+func (m *Meter) Debug() DebugStats {
+	return m.sw.Debug()
+}
+
+// Stop the shifter of this meter. A stopped Meter cannot be started again.
+func (m *Meter) Stop() {
+	m.sw.Stop()
+}
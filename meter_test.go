@@ -0,0 +1,55 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeter(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := &Meter{sw: MustNewReplay(15*time.Minute, meterGranularity, start), start: time.Now().Add(-time.Second)}
+	defer m.Stop()
+
+	assert.Equal(t, int64(0), m.Count())
+	assert.Equal(t, 0.0, m.Rate1())
+
+	m.Mark(10)
+	m.sw.AdvanceTo(start.Add(5 * time.Second))
+	m.Mark(20)
+	m.sw.AdvanceTo(start.Add(7500 * time.Millisecond))
+
+	// The buckets cover 7.5 seconds.
+	assert.Equal(t, int64(30), m.Count())
+	assert.Equal(t, 4.0, m.Rate1())
+	assert.Equal(t, 4.0, m.Rate5())
+	assert.Equal(t, 4.0, m.Rate15())
+	assert.True(t, m.RateMean() > 0 && m.RateMean() <= 30)
+}
+
+func TestMeterConstantRate(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := &Meter{sw: MustNewReplay(15*time.Minute, meterGranularity, start), start: start}
+	defer m.Stop()
+
+	// 10 events per second for 20 minutes, with the current bucket partly
+	// elapsed.
+	for d := time.Duration(0); d < 20*time.Minute+2300*time.Millisecond; d += 100 * time.Millisecond {
+		m.sw.AdvanceTo(start.Add(d))
+		m.Mark(1)
+	}
+
+	assert.InDelta(t, 10, m.Rate1(), 0.02)
+	assert.InDelta(t, 10, m.Rate5(), 0.02)
+	assert.InDelta(t, 10, m.Rate15(), 0.02)
+}
+
+func TestNewMeter(t *testing.T) {
+	m := NewMeter()
+	defer m.Stop()
+
+	m.Mark(10)
+	assert.Equal(t, int64(10), m.Count())
+	assert.True(t, m.RateMean() > 0)
+}
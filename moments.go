@@ -0,0 +1,96 @@
+package average
+
+import (
+	"math"
+	"time"
+)
+
+// moments are the central moments of the values of a bucket, which are kept
+// instead of power sums because they don't lose precision when the mean is
+// large compared to the spread.
+type moments struct {
+	count      float64
+	mean       float64
+	m2, m3, m4 float64 // The sums of the 2nd, 3rd and 4th powers of the deviations.
+}
+
+// merge combines the moments of o into m, with the pairwise update formulas of
+// Pébay.
+func (m *moments) merge(o moments) {
+	if o.count == 0 {
+		return
+	}
+	if m.count == 0 {
+		*m = o
+		return
+	}
+
+	na, nb := m.count, o.count
+	n := na + nb
+	d := o.mean - m.mean
+	d2 := d * d
+
+	m4 := m.m4 + o.m4 + d2*d2*na*nb*(na*na-na*nb+nb*nb)/(n*n*n) +
+		6*d2*(na*na*o.m2+nb*nb*m.m2)/(n*n) + 4*d*(na*o.m3-nb*m.m3)/n
+	m3 := m.m3 + o.m3 + d2*d*na*nb*(na-nb)/(n*n) + 3*d*(na*o.m2-nb*m.m2)/n
+	m2 := m.m2 + o.m2 + d2*na*nb/n
+
+	m.count, m.mean = n, m.mean+d*nb/n
+	m.m2, m.m3, m.m4 = m2, m3, m4
+}
+
+// WithMoments keeps track of the higher-order moments of the values in every
+// bucket, so that Skewness and Kurtosis can characterize the shape of their
+// distribution without the raw values. The moments are not part of snapshots,
+// so after a restore they only cover the values that were added since.
+func WithMoments() Option {
+	return func(sw *SlidingWindow) error {
+		sw.moments = make([]moments, sw.len())
+		return nil
+	}
+}
+
+// Skewness returns the skewness of the values over the specified window, which
+// is positive when the distribution has a longer tail to the right and
+// negative when it has a longer tail to the left. It returns 0 if the values
+// don't vary or the window was not created with WithMoments.
+func (sw *SlidingWindow) Skewness(window time.Duration) float64 {
+	m := sw.mergedMoments(window)
+	if m.m2 == 0 {
+		return 0
+	}
+
+	return math.Sqrt(m.count) * m.m3 / math.Pow(m.m2, 1.5)
+}
+
+// Kurtosis returns the excess kurtosis of the values over the specified
+// window, which is 0 for a normal distribution and positive for distributions
+// with heavier tails. It returns 0 if the values don't vary or the window was
+// not created with WithMoments.
+func (sw *SlidingWindow) Kurtosis(window time.Duration) float64 {
+	m := sw.mergedMoments(window)
+	if m.m2 == 0 {
+		return 0
+	}
+
+	return m.count*m.m4/(m.m2*m.m2) - 3
+}
+
+// mergedMoments returns the moments of the values over the specified window.
+func (sw *SlidingWindow) mergedMoments(window time.Duration) moments {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	var merged moments
+	if sw.moments == nil {
+		return merged
+	}
+
+	for i, n := 0, sw.buckets(window); i < n; i++ {
+		if !sw.open(i) {
+			merged.merge(sw.moments[sw.index(i)])
+		}
+	}
+
+	return merged
+}
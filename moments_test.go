@@ -0,0 +1,75 @@
+package average
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// naiveMoments returns the skewness and the excess kurtosis of values.
+func naiveMoments(values []float64) (float64, float64) {
+	var mean float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+
+	var m2, m3, m4 float64
+	for _, v := range values {
+		d := v - mean
+		m2 += d * d
+		m3 += d * d * d
+		m4 += d * d * d * d
+	}
+
+	n := float64(len(values))
+	return math.Sqrt(n) * m3 / math.Pow(m2, 1.5), n*m4/(m2*m2) - 3
+}
+
+func TestWithMoments(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithMoments(), WithManualClock())
+	defer sw.Stop()
+
+	assert.Equal(t, 0.0, sw.Skewness(4*time.Second))
+	assert.Equal(t, 0.0, sw.Kurtosis(4*time.Second))
+
+	r := rand.New(rand.NewSource(1))
+	var all, newest []float64
+	for i := 0; i < 3; i++ {
+		newest = newest[:0]
+		for j := 0; j < 100; j++ {
+			v := 1e6 + r.ExpFloat64()*float64(i+1)
+			sw.Add(v)
+			all = append(all, v)
+			newest = append(newest, v)
+		}
+		sw.Advance(time.Second)
+	}
+	sw.AddN(2e6+10, 2)
+	all = append(all, 1e6+5, 1e6+5)
+
+	skewness, kurtosis := naiveMoments(all)
+	assert.InDelta(t, skewness, sw.Skewness(4*time.Second), 1e-6)
+	assert.InDelta(t, kurtosis, sw.Kurtosis(4*time.Second), 1e-6)
+	assert.True(t, skewness > 1)
+
+	skewness, kurtosis = naiveMoments(append(newest, 1e6+5, 1e6+5))
+	assert.InDelta(t, skewness, sw.Skewness(2*time.Second), 1e-6)
+	assert.InDelta(t, kurtosis, sw.Kurtosis(2*time.Second), 1e-6)
+}
+
+func TestWithMomentsConstant(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithMoments())
+	defer sw.Stop()
+
+	sw.Add(3)
+	sw.AddN(6, 2)
+	assert.Equal(t, 0.0, sw.Skewness(4*time.Second))
+	assert.Equal(t, 0.0, sw.Kurtosis(4*time.Second))
+
+	_, err := New(4*time.Second, time.Second, WithoutCounts(), WithMoments())
+	assert.EqualError(t, err, "moments require sample counts")
+}
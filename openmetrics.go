@@ -0,0 +1,119 @@
+package average
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// WriteOpenMetrics writes the total, the average and the rate per second of
+// every horizon to w in the Prometheus text exposition format, as three gauge
+// families that are labeled by their horizon:
+//
+//	# TYPE requests gauge
+//	requests{window="1m0s"} 120
+//	# TYPE requests_average gauge
+//	requests_average{window="1m0s"} 2.5
+//	# TYPE requests_rate gauge
+//	requests_rate{window="1m0s"} 2
+//
+// Without horizons, the whole window is written. A horizon that is longer than
+// the window is labeled as the window, as that is all that it covers, and is
+// only written once. This is enough for a tiny
+// /metrics endpoint without the Prometheus client library. The output doesn't
+// end with the "# EOF" line of OpenMetrics, so that several windows can be
+// written to the same response.
+func (sw *SlidingWindow) WriteOpenMetrics(w io.Writer, name string, horizons ...time.Duration) error {
+	if !validMetricName(name) {
+		return errors.New("invalid metric name " + strconv.Quote(name))
+	}
+	horizons = sw.clampHorizons(horizons)
+
+	totals := make([]float64, len(horizons))
+	averages := make([]float64, len(horizons))
+	rates := make([]float64, len(horizons))
+
+	sw.RLock()
+	for i, horizon := range horizons {
+		total, _, n := sw.total(horizon)
+		totals[i] = total
+		averages[i] = sw.average(horizon)
+		rates[i] = sw.rate(total, n)
+	}
+	sw.RUnlock()
+
+	var buf bytes.Buffer
+	writeGauges(&buf, name, horizons, totals)
+	writeGauges(&buf, name+"_average", horizons, averages)
+	writeGauges(&buf, name+"_rate", horizons, rates)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// clampHorizons returns the horizons, with those that are longer than the
+// window replaced by the window and without duplicates, or just the window if
+// there are none.
+func (sw *SlidingWindow) clampHorizons(horizons []time.Duration) []time.Duration {
+	clamped := make([]time.Duration, 0, len(horizons))
+	seen := make(map[time.Duration]bool, len(horizons))
+	for _, horizon := range horizons {
+		if horizon > sw.window {
+			horizon = sw.window
+		}
+		if !seen[horizon] {
+			seen[horizon] = true
+			clamped = append(clamped, horizon)
+		}
+	}
+
+	if len(clamped) == 0 {
+		clamped = append(clamped, sw.window)
+	}
+
+	return clamped
+}
+
+// writeGauges writes a gauge family with a value for every horizon to buf.
+func writeGauges(buf *bytes.Buffer, name string, horizons []time.Duration, values []float64) {
+	fmt.Fprintf(buf, "# TYPE %s gauge\n", name)
+	for i, horizon := range horizons {
+		fmt.Fprintf(buf, "%s{window=%q} %s\n", name, horizon.String(), formatMetricValue(values[i]))
+	}
+}
+
+// formatMetricValue formats v the way the exposition format expects it.
+func formatMetricValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+// validMetricName returns true if name is a valid Prometheus metric name.
+func validMetricName(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == ':':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
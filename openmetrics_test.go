@@ -0,0 +1,77 @@
+package average
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithManualClock())
+	defer sw.Stop()
+
+	sw.Add(4)
+	sw.Advance(time.Second)
+	sw.Add(1)
+	sw.Add(3)
+	sw.Advance(500 * time.Millisecond)
+
+	var buf bytes.Buffer
+	assert.NoError(t, sw.WriteOpenMetrics(&buf, "requests", time.Second, 4*time.Second))
+	assert.Equal(t, `# TYPE requests gauge
+requests{window="1s"} 4
+requests{window="4s"} 8
+# TYPE requests_average gauge
+requests_average{window="1s"} 2
+requests_average{window="4s"} 2.6666666666666665
+# TYPE requests_rate gauge
+requests_rate{window="1s"} 8
+requests_rate{window="4s"} 5.333333333333333
+`, buf.String())
+
+	buf.Reset()
+	assert.NoError(t, sw.WriteOpenMetrics(&buf, "app:latency_seconds"))
+	assert.Contains(t, buf.String(), `app:latency_seconds{window="4s"} 8`+"\n")
+
+	// Horizons beyond the window are labeled as the window, once.
+	buf.Reset()
+	assert.NoError(t, sw.WriteOpenMetrics(&buf, "requests", time.Minute, 4*time.Second, time.Hour))
+	assert.Equal(t, `# TYPE requests gauge
+requests{window="4s"} 8
+# TYPE requests_average gauge
+requests_average{window="4s"} 2.6666666666666665
+# TYPE requests_rate gauge
+requests_rate{window="4s"} 5.333333333333333
+`, buf.String())
+}
+
+func TestWriteOpenMetricsErrors(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	for _, name := range []string{"", "1requests", "requests-total", "requests total"} {
+		assert.Error(t, sw.WriteOpenMetrics(&bytes.Buffer{}, name), name)
+	}
+
+	err := errors.New("closed")
+	assert.Equal(t, err, sw.WriteOpenMetrics(failingWriter{err}, "requests"))
+}
+
+func TestFormatMetricValue(t *testing.T) {
+	assert.Equal(t, "NaN", formatMetricValue(math.NaN()))
+	assert.Equal(t, "+Inf", formatMetricValue(math.Inf(1)))
+	assert.Equal(t, "-Inf", formatMetricValue(math.Inf(-1)))
+	assert.Equal(t, "1e+21", formatMetricValue(1e21))
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write([]byte) (int, error) {
+	return 0, w.err
+}
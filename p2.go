@@ -0,0 +1,110 @@
+package average
+
+import "sort"
+
+// p2 is a P² (piecewise-parabolic) estimator of a single quantile, as
+// described by Jain and Chlamtac. It keeps five markers instead of the values
+// themselves, so it estimates the quantile of any number of values in constant
+// memory.
+type p2 struct {
+	p       float64    // The quantile to estimate, between 0 and 1.
+	count   int        // The number of values.
+	heights [5]float64 // The heights of the markers.
+	pos     [5]float64 // The actual positions of the markers.
+	desired [5]float64 // The desired positions of the markers.
+}
+
+// newP2 returns an estimator for quantile p, between 0 and 1.
+func newP2(p float64) p2 {
+	return p2{p: p}
+}
+
+// add adds v to the estimator.
+func (e *p2) add(v float64) {
+	if e.count < len(e.heights) {
+		e.heights[e.count] = v
+		if e.count++; e.count == len(e.heights) {
+			sort.Float64s(e.heights[:])
+			e.pos = [5]float64{0, 1, 2, 3, 4}
+			e.desired = [5]float64{0, 2 * e.p, 4 * e.p, 2 + 2*e.p, 4}
+		}
+		return
+	}
+
+	// Find the cell that v falls into, extending the outer markers if needed.
+	var k int
+	switch {
+	case v < e.heights[0]:
+		e.heights[0] = v
+	case v >= e.heights[4]:
+		e.heights[4] = v
+		k = 3
+	default:
+		for k = 0; k < 3 && v >= e.heights[k+1]; k++ {
+		}
+	}
+
+	for i := k + 1; i < len(e.pos); i++ {
+		e.pos[i]++
+	}
+
+	increments := [5]float64{0, e.p / 2, e.p, (1 + e.p) / 2, 1}
+	for i := range e.desired {
+		e.desired[i] += increments[i]
+	}
+	e.count++
+
+	// Move the middle markers towards their desired positions.
+	for i := 1; i < 4; i++ {
+		d := e.desired[i] - e.pos[i]
+		if (d < 1 || e.pos[i+1]-e.pos[i] <= 1) && (d > -1 || e.pos[i-1]-e.pos[i] >= -1) {
+			continue
+		}
+
+		s := 1.0
+		if d < 0 {
+			s = -1
+		}
+
+		if h := e.parabolic(i, s); e.heights[i-1] < h && h < e.heights[i+1] {
+			e.heights[i] = h
+		} else {
+			e.heights[i] = e.linear(i, s)
+		}
+		e.pos[i] += s
+	}
+}
+
+// parabolic returns the height of marker i moved by d with the P² formula.
+func (e *p2) parabolic(i int, d float64) float64 {
+	q, n := &e.heights, &e.pos
+	return q[i] + d/(n[i+1]-n[i-1])*((n[i]-n[i-1]+d)*(q[i+1]-q[i])/(n[i+1]-n[i])+
+		(n[i+1]-n[i]-d)*(q[i]-q[i-1])/(n[i]-n[i-1]))
+}
+
+// linear returns the height of marker i moved by d with linear interpolation.
+func (e *p2) linear(i int, d float64) float64 {
+	j := i + int(d)
+	return e.heights[i] + d*(e.heights[j]-e.heights[i])/(e.pos[j]-e.pos[i])
+}
+
+// value returns the estimated quantile, or 0 if no values were added. Until
+// the estimator has seen five values, the quantile is exact.
+func (e *p2) value() float64 {
+	if e.count == 0 {
+		return 0
+	}
+	if e.count < len(e.heights) {
+		sorted := make([]float64, e.count)
+		copy(sorted, e.heights[:e.count])
+		sort.Float64s(sorted)
+		return nearestRank(sorted, e.p*100)
+	}
+
+	return e.heights[2]
+}
+
+// reset removes all values from the estimator.
+func (e *p2) reset() {
+	*e = newP2(e.p)
+}
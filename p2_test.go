@@ -0,0 +1,40 @@
+package average
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestP2(t *testing.T) {
+	e := newP2(0.5)
+	assert.Equal(t, 0.0, e.value())
+
+	e.add(3)
+	e.add(1)
+	e.add(2)
+	assert.Equal(t, 2.0, e.value())
+
+	r := rand.New(rand.NewSource(1))
+	for _, p := range []float64{0.5, 0.9, 0.99} {
+		e := newP2(p)
+		for i := 0; i < 100000; i++ {
+			e.add(r.Float64() * 1000)
+		}
+
+		assert.InDelta(t, p*1000, e.value(), 10, "p=%g", p)
+		assert.Equal(t, 100000, e.count)
+	}
+}
+
+func TestP2Reset(t *testing.T) {
+	e := newP2(0.9)
+	for i := 0; i < 10; i++ {
+		e.add(float64(i))
+	}
+
+	e.reset()
+	assert.Equal(t, 0.0, e.value())
+	assert.Equal(t, 0.9, e.p)
+}
@@ -0,0 +1,149 @@
+package average
+
+import (
+	"math"
+	"time"
+)
+
+// PairedWindow is a sliding time window for two series that share their
+// buckets and rotation, like the request rate and the error rate of a
+// service. Besides the totals of both series, it reports how they move
+// together over time: the covariance and the Pearson correlation of their
+// bucket totals.
+type PairedWindow struct {
+	sw *SlidingWindow
+	xs []float64
+	ys []float64
+}
+
+// MustNewPairedWindow returns a new PairedWindow, but panics if an error
+// occurs.
+func MustNewPairedWindow(window, granularity time.Duration) *PairedWindow {
+	pw, err := NewPairedWindow(window, granularity)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return pw
+}
+
+// NewPairedWindow returns a new PairedWindow.
+func NewPairedWindow(window, granularity time.Duration) (*PairedWindow, error) {
+	sw, err := newSlidingWindow(window, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	pw := &PairedWindow{
+		sw: sw,
+		xs: make([]float64, sw.len()),
+		ys: make([]float64, sw.len()),
+	}
+	sw.onClear = pw.clear
+
+	sw.startShifter()
+	return pw, nil
+}
+
+func (pw *PairedWindow) clear(pos int) {
+	pw.xs[pos], pw.ys[pos] = 0, 0
+}
+
+// Add increments the current values of both series.
+func (pw *PairedWindow) Add(x, y float64) {
+	pw.sw.Lock()
+	defer pw.sw.Unlock()
+
+	if pw.sw.countAdd() {
+		pw.xs[pw.sw.pos] += x
+		pw.ys[pw.sw.pos] += y
+	}
+}
+
+// AddX increments the current value of the first series.
+func (pw *PairedWindow) AddX(x float64) {
+	pw.Add(x, 0)
+}
+
+// AddY increments the current value of the second series.
+func (pw *PairedWindow) AddY(y float64) {
+	pw.Add(0, y)
+}
+
+// Total returns the sums of both series over the specified window.
+func (pw *PairedWindow) Total(window time.Duration) (float64, float64) {
+	pw.sw.RLock()
+	defer pw.sw.RUnlock()
+
+	var x, y float64
+	for i, n := 0, pw.sw.buckets(window); i < n; i++ {
+		pos := pw.sw.index(i)
+		x += pw.xs[pos]
+		y += pw.ys[pos]
+	}
+
+	return x, y
+}
+
+// Covariance returns the population covariance of the bucket totals of both
+// series over the specified window. It is positive when the series tend to go
+// up and down together.
+func (pw *PairedWindow) Covariance(window time.Duration) float64 {
+	cov, _, _ := pw.comoments(window)
+	return cov
+}
+
+// Correlation returns the Pearson correlation of the bucket totals of both
+// series over the specified window, between -1 and 1. It returns 0 if the
+// window covers fewer than two buckets, or if one of the series is constant.
+func (pw *PairedWindow) Correlation(window time.Duration) float64 {
+	cov, varX, varY := pw.comoments(window)
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+
+	return cov / math.Sqrt(varX*varY)
+}
+
+// comoments returns the population covariance of the bucket totals over the
+// specified window, and the population variances of both series.
+func (pw *PairedWindow) comoments(window time.Duration) (float64, float64, float64) {
+	pw.sw.RLock()
+	defer pw.sw.RUnlock()
+
+	n := pw.sw.buckets(window)
+	if n < 2 {
+		return 0, 0, 0
+	}
+
+	var meanX, meanY float64
+	for i := 0; i < n; i++ {
+		pos := pw.sw.index(i)
+		meanX += pw.xs[pos]
+		meanY += pw.ys[pos]
+	}
+	meanX /= float64(n)
+	meanY /= float64(n)
+
+	var cov, varX, varY float64
+	for i := 0; i < n; i++ {
+		pos := pw.sw.index(i)
+		dx, dy := pw.xs[pos]-meanX, pw.ys[pos]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+
+	return cov / float64(n), varX / float64(n), varY / float64(n)
+}
+
+// Debug returns the DebugStats of this paired window.
+func (pw *PairedWindow) Debug() DebugStats {
+	return pw.sw.Debug()
+}
+
+// Stop the shifter of this paired window. A stopped PairedWindow cannot be
+// started again.
+func (pw *PairedWindow) Stop() {
+	pw.sw.Stop()
+}
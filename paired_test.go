@@ -0,0 +1,55 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPairedWindow(t *testing.T) {
+	_, err := NewPairedWindow(time.Second, time.Second)
+	assert.EqualError(t, err, "window size has to be a multiplier of the granularity size")
+	assert.Panics(t, func() { MustNewPairedWindow(0, time.Second) })
+}
+
+func TestPairedWindow(t *testing.T) {
+	pw := MustNewPairedWindow(4*time.Second, time.Second)
+	defer pw.Stop()
+
+	assert.Equal(t, 0.0, pw.Correlation(4*time.Second))
+	assert.Equal(t, 0.0, pw.Covariance(4*time.Second))
+
+	for i, pair := range [][2]float64{{10, 1}, {20, 2}, {30, 4}, {40, 3}} {
+		if i > 0 {
+			pw.sw.Lock()
+			pw.sw.shift()
+			pw.sw.Unlock()
+		}
+		pw.AddX(pair[0])
+		pw.AddY(pair[1])
+	}
+
+	x, y := pw.Total(4 * time.Second)
+	assert.Equal(t, 100.0, x)
+	assert.Equal(t, 10.0, y)
+
+	assert.Equal(t, 10.0, pw.Covariance(4*time.Second))
+	assert.InDelta(t, 0.8, pw.Correlation(4*time.Second), 1e-9)
+	assert.InDelta(t, -1, pw.Correlation(2*time.Second), 1e-9)
+	assert.Equal(t, 0.0, pw.Correlation(time.Second))
+
+	pw.Add(0, 2)
+	assert.InDelta(t, 1, pw.Correlation(2*time.Second), 1e-9)
+}
+
+func TestPairedWindowStopped(t *testing.T) {
+	pw := MustNewPairedWindow(4*time.Second, time.Second)
+	pw.Add(1, 2)
+	pw.Stop()
+	pw.Add(1, 2)
+
+	x, y := pw.Total(4 * time.Second)
+	assert.Equal(t, 1.0, x)
+	assert.Equal(t, 2.0, y)
+}
@@ -0,0 +1,40 @@
+package average
+
+import "time"
+
+// MaxBucket returns the bucket with the largest value in the specified window,
+// along with the time range that it covers, so that a peak can be reported
+// with the moment it happened, like a peak rate of Sum/(End-Start) per second
+// between Start and End. If several buckets share the largest value, the most
+// recent one is returned. For other aggregations than AggregateSum, buckets
+// without a value are skipped, and false is returned if no bucket in the
+// window has a value.
+func (sw *SlidingWindow) MaxBucket(window time.Duration) (BucketResult, bool) {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	best, found := 0, false
+	for i, n := 0, sw.buckets(window); i < n; i++ {
+		pos := sw.index(i)
+		if sw.valid != nil && !sw.valid[pos] || sw.open(i) {
+			continue
+		}
+
+		if !found || sw.sum(pos) > sw.sum(sw.index(best)) {
+			best, found = i, true
+		}
+	}
+
+	if !found {
+		return BucketResult{}, false
+	}
+
+	pos := sw.index(best)
+	start := sw.start.Add(-time.Duration(best) * sw.granularity)
+	return BucketResult{
+		Sum:   sw.sum(pos),
+		Count: sw.count(pos),
+		Start: start,
+		End:   start.Add(sw.granularity),
+	}, true
+}
@@ -0,0 +1,56 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxBucket(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Add(3)
+	sw.Add(4)
+	sw.Lock()
+	sw.shift()
+	sw.shift()
+	start := sw.start
+	sw.Unlock()
+	sw.Add(5)
+
+	b, ok := sw.MaxBucket(4 * time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 7.0, b.Sum)
+	assert.Equal(t, int64(2), b.Count)
+	assert.Equal(t, start.Add(-2*time.Second), b.Start)
+	assert.Equal(t, start.Add(-time.Second), b.End)
+
+	b, ok = sw.MaxBucket(2 * time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, 5.0, b.Sum)
+	assert.Equal(t, start, b.Start)
+
+	// The most recent of equal buckets wins.
+	sw.Add(2)
+	b, _ = sw.MaxBucket(4 * time.Second)
+	assert.Equal(t, start, b.Start)
+}
+
+func TestMaxBucketAggregation(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithAggregation(AggregateMax))
+	defer sw.Stop()
+
+	_, ok := sw.MaxBucket(4 * time.Second)
+	assert.False(t, ok)
+
+	sw.Add(-3)
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+
+	b, ok := sw.MaxBucket(4 * time.Second)
+	assert.True(t, ok)
+	assert.Equal(t, -3.0, b.Sum)
+}
@@ -0,0 +1,53 @@
+package average
+
+import (
+	"math/rand"
+	"time"
+)
+
+// WithRandomPhase rotates the buckets of a window at a random offset within
+// the granularity, instead of exactly one granularity after the window was
+// created. When thousands of windows are created at once, like at the start
+// of a process, their shifters then spread their rotations across the
+// granularity instead of all firing in the same millisecond. The current
+// bucket of a new window is treated as if it started up to one granularity
+// before the window was created, so that its buckets still line up with the
+// moments they rotate. Windows created WithManualClock are not affected.
+func WithRandomPhase() Option {
+	return func(sw *SlidingWindow) error {
+		sw.randomPhase = true
+		return nil
+	}
+}
+
+// phase picks the random offset of the first rotation of this window, and
+// moves the start of the current bucket back so that it ends at that moment.
+// It returns 0 for windows without a random phase.
+func (sw *SlidingWindow) phase() time.Duration {
+	if !sw.randomPhase {
+		return 0
+	}
+
+	phase := time.Duration(rand.Int63n(int64(sw.granularity))) + 1
+	sw.start = sw.start.Add(phase - sw.granularity)
+	sw.refreshView()
+	return phase
+}
+
+// waitPhase waits for the first rotation of a window with a random phase. It
+// returns false if stopC fires first.
+func waitPhase(phase time.Duration, stopC chan struct{}) bool {
+	if phase <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(phase)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-stopC:
+		return false
+	}
+}
@@ -0,0 +1,47 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRandomPhase(t *testing.T) {
+	before := time.Now()
+	sw := MustNew(time.Second, 100*time.Millisecond, WithRandomPhase())
+	defer sw.Stop()
+
+	sw.RLock()
+	start := sw.start
+	sw.RUnlock()
+
+	// The current bucket started up to one granularity before the window was
+	// created, and ends within the first granularity.
+	assert.False(t, start.Before(before.Add(-100*time.Millisecond)))
+	assert.False(t, start.After(time.Now()))
+
+	time.Sleep(time.Until(start.Add(150 * time.Millisecond)))
+
+	sw.RLock()
+	assert.Equal(t, start.Add(100*time.Millisecond), sw.start)
+	assert.Equal(t, 2, sw.size)
+	sw.RUnlock()
+}
+
+func TestWithRandomPhaseStop(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second, WithRandomPhase(), WithAutoStop())
+	sw.Stop()
+	assert.True(t, sw.Stopped())
+
+	sw = MustNew(10*time.Second, time.Second, WithRandomPhase())
+	sw.Stop()
+	assert.True(t, sw.Stopped())
+}
+
+func TestWithRandomPhaseManualClock(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second, WithRandomPhase(), WithManualClock())
+	defer sw.Stop()
+
+	assert.Equal(t, sw.virtual, sw.start)
+}
@@ -0,0 +1,107 @@
+package average
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Pool recycles windows with the same configuration, including their running
+// shifter, for programs that create and discard many short-lived windows, like
+// one per connection. Creating a window allocates its buckets and starts a
+// goroutine, which dominates the cost of a window that only lives for a few
+// seconds.
+type Pool struct {
+	window      time.Duration
+	granularity time.Duration
+	opts        []Option
+	size        int
+	mu          sync.Mutex
+	idle        []*SlidingWindow
+	closed      bool
+}
+
+// NewPool returns a new Pool of windows that are created with the specified
+// window, granularity and options, which keeps up to size idle windows around
+// for reuse.
+func NewPool(size int, window, granularity time.Duration, opts ...Option) (*Pool, error) {
+	if size < 1 {
+		return nil, errors.New("pool size has to be at least 1")
+	}
+
+	// Create the first window right away, to validate the configuration.
+	sw, err := New(window, granularity, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pool{
+		window:      window,
+		granularity: granularity,
+		opts:        append([]Option(nil), opts...),
+		size:        size,
+		idle:        []*SlidingWindow{sw},
+	}, nil
+}
+
+// Get returns an idle window from the pool, or a new window if the pool has no
+// idle windows left. The window is empty, as if it was just created.
+func (p *Pool) Get() *SlidingWindow {
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 {
+		sw := p.idle[n-1]
+		p.idle[n-1] = nil
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return sw
+	}
+	p.mu.Unlock()
+
+	return MustNew(p.window, p.granularity, p.opts...)
+}
+
+// Put returns sw to the pool once its owner is done with it. It resets the
+// window, its debug counters and a manual clock, and closes its subscriptions,
+// so sw must not be used anymore after Put. If the pool already holds as many idle windows as its size, or is
+// closed, sw is stopped instead. Stopped windows are not reused.
+func (p *Pool) Put(sw *SlidingWindow) {
+	if sw.Stopped() {
+		return
+	}
+
+	sw.Reset()
+	sw.Lock()
+	sw.closeSubscribers()
+	sw.adds, sw.dropped, sw.rotations, sw.missedTicks = 0, 0, 0, 0
+	if sw.manual {
+		sw.start = time.Now()
+		sw.virtual = sw.start
+		sw.refreshView()
+	}
+	sw.Unlock()
+
+	p.mu.Lock()
+	if !p.closed && len(p.idle) < p.size {
+		p.idle = append(p.idle, sw)
+		sw = nil
+	}
+	p.mu.Unlock()
+
+	if sw != nil {
+		sw.Stop()
+	}
+}
+
+// Close stops all idle windows of the pool. Windows that are put back into the
+// pool afterwards are stopped right away, while Get keeps returning new
+// windows.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle, p.closed = nil, true
+	p.mu.Unlock()
+
+	for _, sw := range idle {
+		sw.Stop()
+	}
+}
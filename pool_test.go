@@ -0,0 +1,92 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPool(t *testing.T) {
+	p, err := NewPool(1, 4*time.Second, time.Second, WithExtrema())
+	assert.NoError(t, err)
+	defer p.Close()
+
+	sw := p.Get()
+	sw.Add(3)
+	c := sw.Subscribe(1, DropNewest)
+	p.Put(sw)
+
+	_, ok := <-c
+	assert.False(t, ok)
+
+	// The same window is handed out again, but empty.
+	reused := p.Get()
+	assert.True(t, sw == reused)
+	assert.Equal(t, 0.0, reused.Average(4*time.Second))
+	assert.Equal(t, 0.0, reused.Max(4*time.Second))
+	assert.Equal(t, []int64{0}, reused.Counts(4*time.Second))
+
+	other := p.Get()
+	assert.False(t, other == reused)
+
+	p.Put(reused)
+	p.Put(other)
+	assert.False(t, reused.Stopped())
+	assert.True(t, other.Stopped())
+}
+
+func TestPoolManualClock(t *testing.T) {
+	p, err := NewPool(1, 4*time.Second, time.Second, WithManualClock())
+	assert.NoError(t, err)
+	defer p.Close()
+
+	sw := p.Get()
+	sw.Add(3)
+	sw.Advance(3 * time.Second)
+	p.Put(sw)
+
+	// The reused window starts over at the current time with fresh counters.
+	reused := p.Get()
+	assert.True(t, sw == reused)
+	assert.Equal(t, DebugStats{}, reused.Debug())
+	assert.WithinDuration(t, time.Now(), reused.virtual, time.Second)
+	assert.Equal(t, reused.start, reused.virtual)
+
+	reused.Add(2)
+	reused.Advance(time.Second)
+	assert.Equal(t, DebugStats{Adds: 1, Rotations: 1}, reused.Debug())
+	assert.Equal(t, []int64{0, 1}, reused.Counts(4*time.Second))
+}
+
+func TestPoolClose(t *testing.T) {
+	p, err := NewPool(2, 4*time.Second, time.Second)
+	assert.NoError(t, err)
+
+	sw := p.Get()
+	idle := p.Get()
+	p.Put(idle)
+	p.Close()
+	assert.True(t, idle.Stopped())
+
+	p.Put(sw)
+	assert.True(t, sw.Stopped())
+
+	sw = p.Get()
+	assert.False(t, sw.Stopped())
+	sw.Stop()
+
+	// Stopped windows are not reused.
+	p, _ = NewPool(2, 4*time.Second, time.Second)
+	defer p.Close()
+	p.Put(sw)
+	assert.Len(t, p.idle, 1)
+}
+
+func TestNewPoolErrors(t *testing.T) {
+	_, err := NewPool(0, 4*time.Second, time.Second)
+	assert.EqualError(t, err, "pool size has to be at least 1")
+
+	_, err = NewPool(1, time.Second, time.Second)
+	assert.EqualError(t, err, "window size has to be a multiplier of the granularity size")
+}
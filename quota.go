@@ -0,0 +1,202 @@
+package average
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+// QuotaTracker enforces a rolling limit per tenant, like 1000 API requests
+// per tenant over the last hour. The window of a tenant is created the first
+// time it is used, and the number of windows is bounded by evicting the
+// tenant that was used least recently. The windows don't run a goroutine, as
+// they only advance when the tracker is used.
+type QuotaTracker struct {
+	mu          sync.Mutex
+	window      time.Duration
+	granularity time.Duration
+	limit       float64
+	capacity    int
+	onEvict     func(tenant string, s Snapshot)
+	lru         *list.List // The tenants, from the most to the least recently used.
+	tenants     map[string]*list.Element
+}
+
+// QuotaOption configures optional behaviour of a QuotaTracker.
+type QuotaOption func(*QuotaTracker) error
+
+// quota is the window of a single tenant.
+type quota struct {
+	tenant string
+	sw     *SlidingWindow
+}
+
+// WithQuotaEvict calls fn with the tenant and a final snapshot of its window
+// whenever a tenant is evicted to make room for another one. The function is
+// called after the lock of the tracker was released, so it may call methods
+// on the tracker.
+func WithQuotaEvict(fn func(tenant string, s Snapshot)) QuotaOption {
+	return func(qt *QuotaTracker) error {
+		if fn == nil {
+			return errors.New("evict function cannot be nil")
+		}
+
+		qt.onEvict = fn
+		return nil
+	}
+}
+
+// MustNewQuotaTracker returns a new QuotaTracker, but panics if an error
+// occurs.
+func MustNewQuotaTracker(window, granularity time.Duration, limit float64, capacity int, opts ...QuotaOption) *QuotaTracker {
+	qt, err := NewQuotaTracker(window, granularity, limit, capacity, opts...)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return qt
+}
+
+// NewQuotaTracker returns a new QuotaTracker that allows every tenant a total
+// of limit over every window, and keeps the windows of up to capacity
+// tenants.
+func NewQuotaTracker(window, granularity time.Duration, limit float64, capacity int, opts ...QuotaOption) (*QuotaTracker, error) {
+	if err := validate(window, granularity); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		return nil, errors.New("limit has to be positive")
+	}
+	if capacity < 1 {
+		return nil, errors.New("capacity has to be at least 1")
+	}
+
+	qt := &QuotaTracker{
+		window:      window,
+		granularity: granularity,
+		limit:       limit,
+		capacity:    capacity,
+		lru:         list.New(),
+		tenants:     make(map[string]*list.Element),
+	}
+
+	for _, opt := range opts {
+		if err := opt(qt); err != nil {
+			return nil, err
+		}
+	}
+
+	return qt, nil
+}
+
+// Allow reports whether tenant may use v of its quota now. If so, v is counted
+// against its quota.
+func (qt *QuotaTracker) Allow(tenant string, v float64) bool {
+	return qt.AllowAt(time.Now(), tenant, v)
+}
+
+// AllowAt reports whether tenant may use v of its quota at time now. If so, v
+// is counted against its quota. A time before the current bucket of the
+// window of tenant can't be counted anymore, so it isn't allowed. A negative v
+// would give quota back to tenant, so it isn't allowed either.
+func (qt *QuotaTracker) AllowAt(now time.Time, tenant string, v float64) bool {
+	if v < 0 {
+		return false
+	}
+
+	qt.mu.Lock()
+
+	sw := qt.get(now, tenant)
+	total, _ := sw.Total(qt.window)
+	ok := total+v <= qt.limit && sw.AddAt(now, v)
+
+	qt.unlockAndEvict(qt.trim())
+	return ok
+}
+
+// Used returns how much of its quota tenant has used over the last window.
+func (qt *QuotaTracker) Used(tenant string) float64 {
+	return qt.UsedAt(time.Now(), tenant)
+}
+
+// UsedAt returns how much of its quota tenant has used over the window up to
+// time now. Tenants that aren't tracked haven't used any of their quota.
+func (qt *QuotaTracker) UsedAt(now time.Time, tenant string) float64 {
+	qt.mu.Lock()
+	defer qt.mu.Unlock()
+
+	e, ok := qt.tenants[tenant]
+	if !ok {
+		return 0
+	}
+
+	sw := e.Value.(*quota).sw
+	sw.AdvanceTo(now)
+
+	total, _ := sw.Total(qt.window)
+	return total
+}
+
+// Len returns the number of tenants that are tracked.
+func (qt *QuotaTracker) Len() int {
+	qt.mu.Lock()
+	defer qt.mu.Unlock()
+
+	return qt.lru.Len()
+}
+
+// Remove stops tracking tenant, which resets its quota. The function passed to
+// WithQuotaEvict is not called for it.
+func (qt *QuotaTracker) Remove(tenant string) {
+	qt.mu.Lock()
+	defer qt.mu.Unlock()
+
+	if e, ok := qt.tenants[tenant]; ok {
+		qt.lru.Remove(e)
+		delete(qt.tenants, tenant)
+		e.Value.(*quota).sw.Stop()
+	}
+}
+
+// get returns the window of tenant, advanced to time now, and marks tenant as
+// the most recently used one. It must be called with the lock held.
+func (qt *QuotaTracker) get(now time.Time, tenant string) *SlidingWindow {
+	if e, ok := qt.tenants[tenant]; ok {
+		qt.lru.MoveToFront(e)
+
+		sw := e.Value.(*quota).sw
+		sw.AdvanceTo(now)
+		return sw
+	}
+
+	sw := MustNewReplay(qt.window, qt.granularity, now)
+	qt.tenants[tenant] = qt.lru.PushFront(&quota{tenant: tenant, sw: sw})
+	return sw
+}
+
+// trim removes the least recently used tenants until the tracker is within
+// its capacity, and returns them. It must be called with the lock held.
+func (qt *QuotaTracker) trim() []*quota {
+	var evicted []*quota
+	for qt.lru.Len() > qt.capacity {
+		q := qt.lru.Remove(qt.lru.Back()).(*quota)
+		delete(qt.tenants, q.tenant)
+		evicted = append(evicted, q)
+	}
+
+	return evicted
+}
+
+// unlockAndEvict releases the lock, stops the windows of the evicted tenants
+// and passes their final state to the evict function.
+func (qt *QuotaTracker) unlockAndEvict(evicted []*quota) {
+	qt.mu.Unlock()
+
+	for _, q := range evicted {
+		s := q.sw.StopAndSnapshot()
+		if qt.onEvict != nil {
+			qt.onEvict(q.tenant, s)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewQuotaTracker(t *testing.T) {
+	_, err := NewQuotaTracker(0, time.Second, 5, 2)
+	assert.EqualError(t, err, "window cannot be 0")
+
+	_, err = NewQuotaTracker(10*time.Second, time.Second, 0, 2)
+	assert.EqualError(t, err, "limit has to be positive")
+
+	_, err = NewQuotaTracker(10*time.Second, time.Second, 5, 0)
+	assert.EqualError(t, err, "capacity has to be at least 1")
+
+	_, err = NewQuotaTracker(10*time.Second, time.Second, 5, 2, WithQuotaEvict(nil))
+	assert.EqualError(t, err, "evict function cannot be nil")
+}
+
+func TestQuotaTrackerAllowAt(t *testing.T) {
+	qt := MustNewQuotaTracker(10*time.Second, time.Second, 5, 2)
+	now := time.Now()
+
+	assert.True(t, qt.AllowAt(now, "a", 3))
+	assert.True(t, qt.AllowAt(now.Add(time.Second), "a", 2))
+	assert.False(t, qt.AllowAt(now.Add(2*time.Second), "a", 1))
+	assert.True(t, qt.AllowAt(now.Add(2*time.Second), "b", 5))
+
+	assert.Equal(t, 5.0, qt.UsedAt(now.Add(2*time.Second), "a"))
+	assert.Equal(t, 0.0, qt.UsedAt(now, "c"))
+
+	// Once the first usage expires, the quota is available again.
+	assert.True(t, qt.AllowAt(now.Add(10*time.Second), "a", 3))
+	assert.False(t, qt.AllowAt(now.Add(10*time.Second), "a", 1))
+	assert.Equal(t, 5.0, qt.UsedAt(now.Add(10*time.Second), "a"))
+
+	qt.Remove("a")
+	assert.Equal(t, 1, qt.Len())
+	assert.Equal(t, 0.0, qt.UsedAt(now.Add(10*time.Second), "a"))
+}
+
+func TestQuotaTrackerAllowAtLate(t *testing.T) {
+	qt := MustNewQuotaTracker(10*time.Second, time.Second, 5, 2)
+	now := time.Now()
+
+	assert.True(t, qt.AllowAt(now.Add(2*time.Second), "a", 1))
+	assert.False(t, qt.AllowAt(now, "a", 1))
+	assert.Equal(t, 1.0, qt.UsedAt(now.Add(2*time.Second), "a"))
+}
+
+func TestQuotaTrackerAllowAtNegative(t *testing.T) {
+	qt := MustNewQuotaTracker(10*time.Second, time.Second, 5, 2)
+	now := time.Now()
+
+	assert.True(t, qt.AllowAt(now, "a", 5))
+	assert.False(t, qt.AllowAt(now, "a", -5))
+	assert.False(t, qt.AllowAt(now, "a", 1))
+	assert.Equal(t, 5.0, qt.UsedAt(now, "a"))
+
+	// A rejected value doesn't start tracking a tenant.
+	assert.False(t, qt.AllowAt(now, "b", -1))
+	assert.Equal(t, 1, qt.Len())
+}
+
+func TestQuotaTrackerEvict(t *testing.T) {
+	var evicted []string
+	var totals []float64
+	qt := MustNewQuotaTracker(10*time.Second, time.Second, 5, 2, WithQuotaEvict(func(tenant string, s Snapshot) {
+		total, _ := s.Total(s.Window)
+		evicted = append(evicted, tenant)
+		totals = append(totals, total)
+	}))
+	now := time.Now()
+
+	qt.AllowAt(now, "a", 1)
+	qt.AllowAt(now, "b", 2)
+	qt.AllowAt(now, "a", 1)
+	qt.AllowAt(now, "c", 3)
+
+	assert.Equal(t, 2, qt.Len())
+	assert.Equal(t, []string{"b"}, evicted)
+	assert.Equal(t, []float64{2}, totals)
+
+	// An evicted tenant starts over with its full quota.
+	assert.True(t, qt.AllowAt(now, "b", 5))
+	assert.Equal(t, []string{"b", "a"}, evicted)
+}
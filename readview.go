@@ -0,0 +1,195 @@
+package average
+
+import (
+	"math"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// WithLockFreeReads lets Total, Average and Snapshot read the window without
+// taking its lock, so dashboards that poll a busy window never block the
+// goroutines that add to it, and vice versa. Every rotation publishes an
+// immutable copy of the completed buckets, and the current bucket is guarded
+// by a sequence lock that readers retry on instead of waiting for writers.
+// Reads always see a consistent state of the window, but may miss a value that
+// is being added at the same time.
+//
+// The copy costs an allocation the size of the window on every rotation. The
+// Average of windows with another aggregation than AggregateSum or with
+// fractional counts still takes the lock. Windows with a manual clock take it
+// for every read, as the time of a Snapshot comes from their clock, which the
+// lock guards, and Total and Average read the same state as Snapshot.
+func WithLockFreeReads() Option {
+	return func(sw *SlidingWindow) error {
+		sw.lockFree = true
+		return nil
+	}
+}
+
+// readView is an immutable copy of the completed buckets of a window, along
+// with the current bucket that was in use when it was published.
+type readView struct {
+	start     time.Time
+	size      int
+	completed []viewBucket // The completed buckets by age, starting at age 1.
+	current   *seqBucket
+}
+
+// viewBucket is the data of a completed bucket in a readView.
+type viewBucket struct {
+	sum   float64
+	count int64
+}
+
+// seqBucket holds the value and sample count of the current bucket behind a
+// sequence lock. Writers hold the lock of the window, so there is only ever
+// one writer, and the sequence number is odd while it writes.
+type seqBucket struct {
+	seq   uint64
+	sum   uint64 // The bits of a float64.
+	count int64
+}
+
+// store replaces the value and sample count of the bucket.
+func (b *seqBucket) store(sum float64, count int64) {
+	atomic.AddUint64(&b.seq, 1)
+	atomic.StoreUint64(&b.sum, math.Float64bits(sum))
+	atomic.StoreInt64(&b.count, count)
+	atomic.AddUint64(&b.seq, 1)
+}
+
+// load returns the value and sample count of the bucket, retrying until it
+// reads them without a write in between.
+func (b *seqBucket) load() (float64, int64) {
+	for {
+		seq := atomic.LoadUint64(&b.seq)
+		if seq&1 != 0 {
+			runtime.Gosched()
+			continue
+		}
+
+		sum := atomic.LoadUint64(&b.sum)
+		count := atomic.LoadInt64(&b.count)
+		if atomic.LoadUint64(&b.seq) == seq {
+			return math.Float64frombits(sum), count
+		}
+	}
+}
+
+// publishView publishes a copy of the completed buckets, along with a new
+// current bucket for writers to update. It must be called with the lock held.
+func (sw *SlidingWindow) publishView() {
+	v := &readView{
+		start:     sw.start,
+		size:      sw.size,
+		completed: make([]viewBucket, sw.size),
+		current:   &seqBucket{},
+	}
+	for age := 1; age < sw.size; age++ {
+		if sw.open(age) {
+			continue
+		}
+
+		pos := sw.index(age)
+		v.completed[age] = viewBucket{sum: sw.sum(pos), count: sw.count(pos)}
+	}
+
+	v.current.store(sw.sum(sw.pos), sw.count(sw.pos))
+	sw.current = v.current
+	sw.view.Store(v)
+}
+
+// detachView stops writers from updating the current bucket of the published
+// view while the buckets are rewritten, so readers of that view keep seeing
+// its data until the next view is published. It must be called with the lock
+// held.
+func (sw *SlidingWindow) detachView() {
+	sw.current = nil
+}
+
+// storeCurrent updates the current bucket of the published view after the
+// bucket at the specified position changed. It must be called with the lock
+// held.
+func (sw *SlidingWindow) storeCurrent(pos int) {
+	if pos == sw.pos {
+		sw.current.store(sw.sum(pos), sw.count(pos))
+	}
+}
+
+// loadView returns the published view of a window created WithLockFreeReads,
+// or nil if the window has to be read with the lock held.
+func (sw *SlidingWindow) loadView() *readView {
+	if !sw.lockFree || sw.manual {
+		return nil
+	}
+
+	return sw.view.Load().(*readView)
+}
+
+// total returns the sum of all values over the specified window, the number of
+// samples and the number of buckets that were considered, like the total
+// method of the window.
+func (v *readView) total(sw *SlidingWindow, window time.Duration) (float64, int64, int) {
+	n := v.buckets(sw, window)
+	if n == 0 {
+		return 0, 0, 0
+	}
+
+	total, count := v.current.load()
+	for age := 1; age < n; age++ {
+		total += v.completed[age].sum
+		count += v.completed[age].count
+	}
+
+	return total, count, n
+}
+
+// buckets returns the number of buckets that make up the specified window,
+// like the buckets method of the window.
+func (v *readView) buckets(sw *SlidingWindow, window time.Duration) int {
+	if window > sw.window {
+		window = sw.window
+	}
+
+	n := sw.round(window)
+	if n > v.size {
+		n = v.size
+	} else if n < 0 {
+		n = 0
+	}
+
+	return n
+}
+
+// snapshot returns a copy of the buckets in this view.
+func (v *readView) snapshot(sw *SlidingWindow) Snapshot {
+	n := v.buckets(sw, sw.window)
+	s := Snapshot{
+		Window:      sw.window,
+		Granularity: sw.granularity,
+		Time:        time.Now(),
+		Start:       v.start,
+		Samples:     make([]float64, n),
+		Counts:      make([]int64, n),
+	}
+
+	if n > 0 {
+		s.Samples[0], s.Counts[0] = v.current.load()
+	}
+	for age := 1; age < n; age++ {
+		s.Samples[age] = v.completed[age].sum
+		s.Counts[age] = v.completed[age].count
+	}
+
+	return s
+}
+
+// refreshView publishes a new view of a window created WithLockFreeReads
+// after its buckets were rotated or rewritten. It must be called with the lock
+// held.
+func (sw *SlidingWindow) refreshView() {
+	if sw.lockFree {
+		sw.publishView()
+	}
+}
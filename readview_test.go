@@ -0,0 +1,153 @@
+package average
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithLockFreeReads(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	locked := MustNewReplay(10*time.Second, time.Second, start, WithGracePeriod(3*time.Second))
+	lockFree := MustNewReplay(10*time.Second, time.Second, start, WithGracePeriod(3*time.Second), WithLockFreeReads())
+	defer locked.Stop()
+	defer lockFree.Stop()
+
+	windows := []time.Duration{-time.Second, 0, time.Second, 3 * time.Second, 10 * time.Second, time.Minute}
+
+	rng := rand.New(rand.NewSource(1))
+	now := start
+	for i := 0; i < 500; i++ {
+		switch r := rng.Intn(20); {
+		case r == 0:
+			locked.Trim(4 * time.Second)
+			lockFree.Trim(4 * time.Second)
+		case r == 1:
+			locked.Reset()
+			lockFree.Reset()
+		case r == 2:
+			locked.ResetData()
+			lockFree.ResetData()
+		case r < 6:
+			late := now.Add(-time.Duration(rng.Intn(4000)) * time.Millisecond)
+			v := float64(rng.Intn(10))
+			assert.Equal(t, locked.AddAt(late, v), lockFree.AddAt(late, v))
+		default:
+			now = now.Add(time.Duration(rng.Intn(1500)) * time.Millisecond)
+			v := float64(rng.Intn(10))
+			locked.AddAt(now, v)
+			lockFree.AddAt(now, v)
+		}
+
+		// Windows with a manual clock read with the lock held, so the view
+		// that they publish is compared directly.
+		v := lockFree.view.Load().(*readView)
+		for _, d := range windows {
+			total, count := locked.Total(d)
+			viewTotal, viewCount, _ := v.total(lockFree, d)
+			assert.Equal(t, total, viewTotal, "step %d, window %s", i, d)
+			assert.Equal(t, count, viewCount, "step %d, window %s", i, d)
+		}
+
+		s := locked.Snapshot()
+		vs := v.snapshot(lockFree)
+		assert.Equal(t, s.Start, vs.Start, "step %d", i)
+		assert.Equal(t, s.Samples, vs.Samples, "step %d", i)
+		assert.Equal(t, s.Counts, vs.Counts, "step %d", i)
+	}
+
+	// Restoring a snapshot and cloning publish a new view as well.
+	lockFree.Lock()
+	assert.NoError(t, lockFree.restore(locked.Snapshot()))
+	lockFree.Unlock()
+	total, _ := locked.Total(10 * time.Second)
+	viewTotal, _, _ := lockFree.view.Load().(*readView).total(lockFree, 10*time.Second)
+	assert.Equal(t, total, viewTotal)
+
+	clone := lockFree.Clone()
+	defer clone.Stop()
+	clone.AddAt(now, 1)
+	cloneTotal, _, _ := clone.view.Load().(*readView).total(clone, 10*time.Second)
+	assert.Equal(t, viewTotal+1, cloneTotal)
+}
+
+func TestWithLockFreeReadsManualClock(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	sw := MustNewReplay(4*time.Second, time.Second, start, WithLockFreeReads())
+	defer sw.Stop()
+
+	// Total, Average and Snapshot of a window with a manual clock all read
+	// with the lock held, so they agree with each other.
+	assert.Nil(t, sw.loadView())
+
+	sw.AddAt(start, 2)
+	sw.AddAt(start.Add(1500*time.Millisecond), 4)
+
+	total, count := sw.Total(4 * time.Second)
+	assert.Equal(t, 6.0, total)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, 3.0, sw.Average(4*time.Second))
+
+	s := sw.Snapshot()
+	assert.Equal(t, start.Add(1500*time.Millisecond), s.Time)
+	assert.Equal(t, []float64{4, 2}, s.Samples)
+}
+
+func TestWithLockFreeReadsOptions(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithLockFreeReads(), WithCompactStorage(), WithoutCounts())
+	defer sw.Stop()
+
+	sw.Add(2)
+	sw.Add(4)
+	total, count := sw.Total(4 * time.Second)
+	assert.Equal(t, 6.0, total)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, 6.0, sw.Average(4*time.Second))
+
+	s := sw.Snapshot()
+	assert.Equal(t, []float64{6}, s.Samples)
+}
+
+// TestWithLockFreeReadsConsistency checks that readers never see a torn
+// current bucket: every value that is added is 1, so the total has to match
+// the sample count.
+func TestWithLockFreeReadsConsistency(t *testing.T) {
+	sw := MustNew(4*time.Millisecond, time.Millisecond, WithLockFreeReads())
+	defer sw.Stop()
+
+	stopC := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stopC:
+					return
+				default:
+					sw.Add(1)
+				}
+			}
+		}()
+	}
+
+	deadline := time.Now().Add(50 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		total, count := sw.Total(4 * time.Millisecond)
+		if !assert.Equal(t, float64(count), total) {
+			break
+		}
+
+		s := sw.Snapshot()
+		for i := range s.Samples {
+			assert.Equal(t, float64(s.Counts[i]), s.Samples[i])
+		}
+	}
+
+	close(stopC)
+	wg.Wait()
+}
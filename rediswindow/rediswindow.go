@@ -0,0 +1,284 @@
+// Package rediswindow implements a sliding time window that is stored in Redis,
+// so that multiple processes can share it, for instance to rate limit across
+// replicas.
+//
+// The buckets of a window are stored as fields of a single Redis hash. Adding
+// to and querying the window happens atomically through Lua scripts, which use
+// the clock of the Redis server to determine the current bucket so that the
+// clocks of the replicas don't need to be in sync. The scripts require Redis
+// 3.2 or later.
+package rediswindow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prep/average"
+)
+
+// Scripter is the part of a Redis client that a Window needs. Most clients
+// can be adapted to it with a ScripterFunc.
+type Scripter interface {
+	// Eval runs the Lua script with the specified keys and arguments, and
+	// returns its reply.
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// ScripterFunc adapts a function to the Scripter interface.
+type ScripterFunc func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+
+// Eval calls f.
+func (f ScripterFunc) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return f(ctx, script, keys, args...)
+}
+
+// addScript increments the sample and count of the current bucket, removes
+// the buckets that have fallen out of the window and refreshes the TTL of the
+// hash. The hash only holds the buckets up to the one that was written to
+// most recently, which is stored in its last field, so only the buckets that
+// have fallen out of the window since then have to be removed. That takes
+// constant time for every bucket that passes, rather than a scan of the hash
+// on every add. Scripts that call TIME before they write have to be
+// replicated by their effects, which redis.replicate_commands enables on
+// Redis versions before 5.
+//
+// ARGV: granularity (µs), buckets in window, value, count, TTL (ms)
+const addScript = `
+redis.replicate_commands()
+
+local now = redis.call('TIME')
+local bucket = math.floor((now[1] * 1000000 + now[2]) / tonumber(ARGV[1]))
+local n = tonumber(ARGV[2])
+
+local last = tonumber(redis.call('HGET', KEYS[1], 'last'))
+if last == nil or bucket > last then
+	if last ~= nil and bucket - last >= n then
+		redis.call('DEL', KEYS[1])
+	elseif last ~= nil then
+		for b = last - n + 1, bucket - n do
+			local field = string.format('%d', b)
+			redis.call('HDEL', KEYS[1], field .. ':s', field .. ':c')
+		end
+	end
+
+	redis.call('HSET', KEYS[1], 'last', string.format('%d', bucket))
+end
+
+local field = string.format('%d', bucket)
+
+redis.call('HINCRBYFLOAT', KEYS[1], field .. ':s', ARGV[3])
+redis.call('HINCRBY', KEYS[1], field .. ':c', ARGV[4])
+
+redis.call('PEXPIRE', KEYS[1], ARGV[5])
+return bucket
+`
+
+// totalScript returns the sum, as a string with all 17 significant digits to
+// retain its precision, and the number of samples over the most recent
+// buckets.
+//
+// ARGV: granularity (µs), buckets to include
+const totalScript = `
+local now = redis.call('TIME')
+local bucket = math.floor((now[1] * 1000000 + now[2]) / tonumber(ARGV[1]))
+local oldest = bucket - tonumber(ARGV[2]) + 1
+
+local sum, count = 0, 0
+local fields = redis.call('HGETALL', KEYS[1])
+for i = 1, #fields, 2 do
+	local b, kind = string.match(fields[i], '^(%d+):(%a)$')
+	b = tonumber(b)
+	if b ~= nil and b >= oldest and b <= bucket then
+		if kind == 's' then
+			sum = sum + tonumber(fields[i + 1])
+		else
+			count = count + tonumber(fields[i + 1])
+		end
+	end
+end
+
+return {string.format('%.17g', sum), count}
+`
+
+// Window is a sliding time window that is stored in Redis.
+type Window struct {
+	client      Scripter
+	key         string
+	window      time.Duration
+	granularity time.Duration
+	timeout     time.Duration
+
+	mu  sync.Mutex
+	err error
+}
+
+var _ average.Window = (*Window)(nil)
+
+// New returns a new Window that stores its buckets in the Redis hash key.
+// Every process that uses the same key and configuration shares the window.
+// The granularity has to be a multiple of a microsecond.
+func New(client Scripter, key string, window, granularity time.Duration) (*Window, error) {
+	if client == nil {
+		return nil, errors.New("client cannot be nil")
+	}
+	if key == "" {
+		return nil, errors.New("key cannot be empty")
+	}
+	if window == 0 {
+		return nil, errors.New("window cannot be 0")
+	}
+	if granularity == 0 {
+		return nil, errors.New("granularity cannot be 0")
+	}
+	if window <= granularity || window%granularity != 0 {
+		return nil, errors.New("window size has to be a multiplier of the granularity size")
+	}
+	if granularity%time.Microsecond != 0 {
+		return nil, errors.New("granularity has to be a multiple of a microsecond")
+	}
+
+	return &Window{
+		client:      client,
+		key:         key,
+		window:      window,
+		granularity: granularity,
+		timeout:     time.Second,
+	}, nil
+}
+
+// AddContext increments the value of the current sample by v.
+func (w *Window) AddContext(ctx context.Context, v float64) error {
+	return w.AddNContext(ctx, v, 1)
+}
+
+// AddNContext increments the value of the current sample by v and its sample
+// count by n.
+func (w *Window) AddNContext(ctx context.Context, v float64, n int64) error {
+	_, err := w.client.Eval(ctx, addScript, []string{w.key},
+		int64(w.granularity/time.Microsecond),
+		int64(w.window/w.granularity),
+		strconv.FormatFloat(v, 'f', -1, 64),
+		n,
+		w.ttl(),
+	)
+
+	return err
+}
+
+// ttl returns the TTL of the hash in milliseconds, which lasts for the window
+// and the current bucket. It's rounded up, as a TTL of 0 would delete the hash
+// right away.
+func (w *Window) ttl() int64 {
+	return int64((w.window + w.granularity + time.Millisecond - 1) / time.Millisecond)
+}
+
+// TotalContext returns the sum of all values over the specified window, as
+// well as the number of samples.
+func (w *Window) TotalContext(ctx context.Context, window time.Duration) (float64, int64, error) {
+	if window > w.window {
+		window = w.window
+	}
+
+	buckets := int64(window / w.granularity)
+	if buckets == 0 {
+		return 0, 0, nil
+	}
+
+	reply, err := w.client.Eval(ctx, totalScript, []string{w.key}, int64(w.granularity/time.Microsecond), buckets)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return parseTotal(reply)
+}
+
+// AverageContext returns the unweighted mean of the specified window.
+func (w *Window) AverageContext(ctx context.Context, window time.Duration) (float64, error) {
+	total, count, err := w.TotalContext(ctx, window)
+	if err != nil || count == 0 {
+		return 0, err
+	}
+
+	return total / float64(count), nil
+}
+
+// Add increments the value of the current sample. Errors are retained and
+// can be retrieved with Err.
+func (w *Window) Add(v float64) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	defer cancel()
+
+	w.setErr(w.AddContext(ctx, v))
+}
+
+// Average returns the unweighted mean of the specified window, or 0 if Redis
+// couldn't be queried. Errors are retained and can be retrieved with Err.
+func (w *Window) Average(window time.Duration) float64 {
+	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	defer cancel()
+
+	avg, err := w.AverageContext(ctx, window)
+	w.setErr(err)
+	return avg
+}
+
+// Total returns the sum of all values over the specified window, as well as
+// the number of samples, or zeroes if Redis couldn't be queried. Errors are
+// retained and can be retrieved with Err.
+func (w *Window) Total(window time.Duration) (float64, int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	defer cancel()
+
+	total, count, err := w.TotalContext(ctx, window)
+	w.setErr(err)
+	return total, count
+}
+
+// Err returns the error of the most recent call to Add, Average or Total, or
+// nil if it succeeded.
+func (w *Window) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.err
+}
+
+func (w *Window) setErr(err error) {
+	w.mu.Lock()
+	w.err = err
+	w.mu.Unlock()
+}
+
+// parseTotal parses the reply of totalScript.
+func parseTotal(reply interface{}) (float64, int64, error) {
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return 0, 0, fmt.Errorf("unexpected reply %v", reply)
+	}
+
+	var s string
+	switch v := values[0].(type) {
+	case string:
+		s = v
+	case []byte:
+		s = string(v)
+	default:
+		return 0, 0, fmt.Errorf("unexpected total %v", values[0])
+	}
+
+	total, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	count, ok := values[1].(int64)
+	if !ok {
+		return 0, 0, fmt.Errorf("unexpected count %v", values[1])
+	}
+
+	return total, count, nil
+}
@@ -0,0 +1,118 @@
+package rediswindow
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedis mimics the scripts of this package for a single bucket.
+type fakeRedis struct {
+	sum   float64
+	count int64
+	args  [][]interface{}
+	err   error
+}
+
+func (f *fakeRedis) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	f.args = append(f.args, append([]interface{}{keys[0]}, args...))
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	switch script {
+	case addScript:
+		v, _ := strconv.ParseFloat(args[2].(string), 64)
+		f.sum += v
+		f.count += args[3].(int64)
+		return int64(1), nil
+	case totalScript:
+		return []interface{}{strconv.FormatFloat(f.sum, 'f', -1, 64), f.count}, nil
+	}
+
+	return nil, errors.New("unknown script")
+}
+
+func TestNew(t *testing.T) {
+	client := &fakeRedis{}
+
+	_, err := New(nil, "key", time.Minute, time.Second)
+	assert.EqualError(t, err, "client cannot be nil")
+
+	_, err = New(client, "", time.Minute, time.Second)
+	assert.EqualError(t, err, "key cannot be empty")
+
+	_, err = New(client, "key", time.Second, time.Second)
+	assert.EqualError(t, err, "window size has to be a multiplier of the granularity size")
+
+	_, err = New(client, "key", 10*time.Nanosecond, time.Nanosecond)
+	assert.EqualError(t, err, "granularity has to be a multiple of a microsecond")
+}
+
+func TestWindow(t *testing.T) {
+	client := &fakeRedis{}
+	w, err := New(client, "requests", time.Minute, time.Second)
+	assert.NoError(t, err)
+
+	w.Add(1.5)
+	assert.NoError(t, w.AddNContext(context.Background(), 10, 4))
+	assert.Equal(t, []interface{}{"requests", int64(1000000), int64(60), "1.5", int64(1), int64(61000)}, client.args[0])
+
+	total, count := w.Total(10 * time.Second)
+	assert.Equal(t, 11.5, total)
+	assert.Equal(t, int64(5), count)
+	assert.Equal(t, []interface{}{"requests", int64(1000000), int64(10)}, client.args[2])
+
+	assert.Equal(t, 2.3, w.Average(time.Hour))
+	assert.Equal(t, int64(60), client.args[3][2])
+	assert.NoError(t, w.Err())
+
+	total, count = w.Total(0)
+	assert.Equal(t, 0.0, total)
+	assert.Equal(t, int64(0), count)
+	assert.Len(t, client.args, 4)
+}
+
+func TestWindowTTL(t *testing.T) {
+	client := &fakeRedis{}
+	w, err := New(client, "requests", 10*time.Microsecond, time.Microsecond)
+	assert.NoError(t, err)
+
+	w.Add(1)
+	assert.Equal(t, int64(1), client.args[0][5])
+
+	w, err = New(client, "requests", 1500*time.Microsecond, 500*time.Microsecond)
+	assert.NoError(t, err)
+
+	w.Add(1)
+	assert.Equal(t, int64(2), client.args[1][5])
+}
+
+func TestWindowErrors(t *testing.T) {
+	client := &fakeRedis{err: errors.New("connection refused")}
+	w, _ := New(client, "requests", time.Minute, time.Second)
+
+	w.Add(1)
+	assert.EqualError(t, w.Err(), "connection refused")
+
+	client.err = nil
+	w.Add(1)
+	assert.NoError(t, w.Err())
+}
+
+func TestParseTotal(t *testing.T) {
+	total, count, err := parseTotal([]interface{}{[]byte("0.25"), int64(3)})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.25, total)
+	assert.Equal(t, int64(3), count)
+
+	_, _, err = parseTotal("OK")
+	assert.EqualError(t, err, "unexpected reply OK")
+
+	_, _, err = parseTotal([]interface{}{int64(1), int64(3)})
+	assert.EqualError(t, err, "unexpected total 1")
+}
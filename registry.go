@@ -0,0 +1,107 @@
+package average
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// DefaultRegistry is the registry used by the package-level Register,
+// Unregister and Get functions.
+var DefaultRegistry = NewRegistry()
+
+// Registry keeps track of windows by name, so that every window in a process
+// can be enumerated, for instance by an admin endpoint.
+type Registry struct {
+	mu      sync.RWMutex
+	windows map[string]*SlidingWindow
+}
+
+// NewRegistry returns a new, empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{windows: make(map[string]*SlidingWindow)}
+}
+
+// Register adds sw under the specified name. It returns an error if another
+// window was already registered under that name.
+func (r *Registry) Register(name string, sw *SlidingWindow) error {
+	if sw == nil {
+		return errors.New("window cannot be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.windows[name]; ok {
+		return errors.New("a window named " + name + " is already registered")
+	}
+
+	r.windows[name] = sw
+	return nil
+}
+
+// Unregister removes the window with the specified name.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	delete(r.windows, name)
+	r.mu.Unlock()
+}
+
+// Get returns the window with the specified name.
+func (r *Registry) Get(name string) (*SlidingWindow, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sw, ok := r.windows[name]
+	return sw, ok
+}
+
+// Names returns the names of all registered windows in sorted order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.windows))
+	for name := range r.windows {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+
+	sort.Strings(names)
+	return names
+}
+
+// Each calls f for every registered window, in the order of their names. The
+// registry is not locked while f runs, so f may register or unregister
+// windows.
+func (r *Registry) Each(f func(name string, sw *SlidingWindow)) {
+	for _, name := range r.Names() {
+		if sw, ok := r.Get(name); ok {
+			f(name, sw)
+		}
+	}
+}
+
+// Snapshots returns a snapshot of every registered window by name.
+func (r *Registry) Snapshots() map[string]Snapshot {
+	snapshots := make(map[string]Snapshot)
+	r.Each(func(name string, sw *SlidingWindow) {
+		snapshots[name] = sw.Snapshot()
+	})
+
+	return snapshots
+}
+
+// Register adds sw to the DefaultRegistry under the specified name.
+func Register(name string, sw *SlidingWindow) error {
+	return DefaultRegistry.Register(name, sw)
+}
+
+// Unregister removes the window with the specified name from the
+// DefaultRegistry.
+func Unregister(name string) {
+	DefaultRegistry.Unregister(name)
+}
+
+// Get returns the window with the specified name from the DefaultRegistry.
+func Get(name string) (*SlidingWindow, bool) {
+	return DefaultRegistry.Get(name)
+}
@@ -0,0 +1,54 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+
+	requests := MustNew(10*time.Second, time.Second)
+	defer requests.Stop()
+	errs := MustNew(10*time.Second, time.Second)
+	defer errs.Stop()
+
+	assert.NoError(t, r.Register("requests", requests))
+	assert.NoError(t, r.Register("errors", errs))
+	assert.EqualError(t, r.Register("errors", requests), "a window named errors is already registered")
+	assert.EqualError(t, r.Register("nil", nil), "window cannot be nil")
+
+	sw, ok := r.Get("requests")
+	assert.True(t, ok)
+	assert.True(t, sw == requests)
+
+	var names []string
+	r.Each(func(name string, sw *SlidingWindow) {
+		names = append(names, name)
+	})
+	assert.Equal(t, []string{"errors", "requests"}, names)
+
+	requests.Add(5)
+	snapshots := r.Snapshots()
+	assert.Len(t, snapshots, 2)
+	assert.Equal(t, 5.0, snapshots["requests"].Average(10*time.Second))
+
+	r.Unregister("errors")
+	_, ok = r.Get("errors")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"requests"}, r.Names())
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+
+	assert.NoError(t, Register("test", sw))
+	defer Unregister("test")
+
+	registered, ok := Get("test")
+	assert.True(t, ok)
+	assert.True(t, registered == sw)
+}
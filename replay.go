@@ -0,0 +1,76 @@
+package average
+
+import "time"
+
+// MustNewReplay returns a new replay window, but panics if an error occurs.
+func MustNewReplay(window, granularity time.Duration, start time.Time, opts ...Option) *SlidingWindow {
+	sw, err := NewReplay(window, granularity, start, opts...)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return sw
+}
+
+// NewReplay returns a new SlidingWindow to replay historical data, like last
+// week's event log, whose first bucket starts at start. The window has a
+// manual clock that only advances with the timestamps that are passed to
+// AddAt and AdvanceTo, or with Advance.
+func NewReplay(window, granularity time.Duration, start time.Time, opts ...Option) (*SlidingWindow, error) {
+	opts = append(opts[:len(opts):len(opts)], WithManualClock())
+
+	sw, err := newSlidingWindow(window, granularity, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	sw.start, sw.virtual = start, start
+	sw.refreshView()
+	return sw, nil
+}
+
+// AddAt advances the clock of a window with a manual clock to t, if t is
+// later, and adds v to the bucket that contains t. Events that happened before
+// the current bucket started can't be added anymore, unless the window was
+// created WithGracePeriod, so AddAt returns false for those, as well as for
+// windows without a manual clock and for stopped windows.
+func (sw *SlidingWindow) AddAt(t time.Time, v float64) bool {
+	sw.Lock()
+	defer sw.unlockAndEvict()
+
+	switch {
+	case !sw.manual || sw.stopped:
+	case !t.Before(sw.start):
+		sw.advance(t)
+		sw.add(v, 1)
+		return true
+	case sw.grace > 0 && sw.addLate(t, v):
+		return true
+	}
+
+	sw.dropped++
+	return false
+}
+
+// AdvanceTo advances the clock of a window with a manual clock to t, and
+// rotates the buckets as if the time up to t had elapsed. It has no effect if
+// t isn't later than the current time of the window, or on windows without a
+// manual clock.
+func (sw *SlidingWindow) AdvanceTo(t time.Time) {
+	sw.Lock()
+	defer sw.unlockAndEvict()
+
+	if sw.manual && !sw.stopped {
+		sw.advance(t)
+	}
+}
+
+// advance moves the manual clock forward to t. It must be called with the lock
+// held.
+func (sw *SlidingWindow) advance(t time.Time) {
+	if t.After(sw.virtual) {
+		sw.virtual = t
+		sw.advanceTo(t)
+		sw.sealLate(false)
+	}
+}
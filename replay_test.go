@@ -0,0 +1,46 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplay(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	sw := MustNewReplay(time.Minute, 10*time.Second, start)
+	defer sw.Stop()
+
+	assert.True(t, sw.AddAt(start, 1))
+	assert.True(t, sw.AddAt(start.Add(5*time.Second), 2))
+	assert.True(t, sw.AddAt(start.Add(25*time.Second), 3))
+
+	// Late events within the current bucket are still added.
+	assert.True(t, sw.AddAt(start.Add(21*time.Second), 4))
+	assert.False(t, sw.AddAt(start.Add(15*time.Second), 5))
+
+	assert.Equal(t, []int64{2, 0, 2}, sw.Counts(time.Minute))
+	assert.Equal(t, 2.5, sw.Average(time.Minute))
+	assert.Equal(t, 25*time.Second, sw.Coverage(time.Minute))
+
+	sw.AdvanceTo(start.Add(10 * time.Second))
+	assert.Equal(t, []int64{2, 0, 2}, sw.Counts(time.Minute))
+
+	sw.AdvanceTo(start.Add(time.Hour))
+	assert.Equal(t, 0.0, sw.Average(time.Minute))
+	assert.Equal(t, start.Add(time.Hour), sw.Snapshot().Start)
+}
+
+func TestReplayErrors(t *testing.T) {
+	_, err := NewReplay(time.Second, time.Second, time.Now())
+	assert.EqualError(t, err, "window size has to be a multiplier of the granularity size")
+
+	assert.Panics(t, func() { MustNewReplay(0, time.Second, time.Now()) })
+
+	sw := MustNew(time.Minute, time.Second)
+	defer sw.Stop()
+
+	assert.False(t, sw.AddAt(time.Now(), 1))
+	assert.Equal(t, []int64{0}, sw.Counts(time.Minute))
+}
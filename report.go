@@ -0,0 +1,48 @@
+package average
+
+import (
+	"sync"
+	"time"
+)
+
+// Report calls fn with a consistent snapshot of this window every interval,
+// from a goroutine that is managed by the window, until the returned function
+// is called or the window is stopped. The returned function waits for a call
+// to fn that is in progress, so fn is never called after it returns. It must
+// therefore not be called from fn itself. The goroutine keeps the window
+// reachable, so a window created WithAutoStop has to be stopped explicitly
+// while it is being reported on. Report panics if interval isn't positive.
+func (sw *SlidingWindow) Report(interval time.Duration, fn func(Snapshot)) func() {
+	if interval <= 0 {
+		panic("non-positive interval for Report")
+	}
+
+	stopC := make(chan struct{})
+	doneC := make(chan struct{})
+
+	go func() {
+		defer close(doneC)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				fn(sw.Snapshot())
+
+			case <-stopC:
+				return
+
+			case <-sw.doneC:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(stopC) })
+		<-doneC
+	}
+}
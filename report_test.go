@@ -0,0 +1,64 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.AddN(6, 3)
+
+	c := make(chan Snapshot, 1)
+	stop := sw.Report(time.Millisecond, func(s Snapshot) {
+		select {
+		case c <- s:
+		default:
+		}
+	})
+
+	s := <-c
+	assert.Equal(t, 4*time.Second, s.Window)
+	total, count := s.Total(4 * time.Second)
+	assert.Equal(t, 6.0, total)
+	assert.Equal(t, int64(3), count)
+
+	stop()
+	stop()
+
+	select {
+	case <-c:
+	default:
+	}
+	time.Sleep(5 * time.Millisecond)
+	assert.Len(t, c, 0)
+}
+
+func TestReportStop(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+
+	calls := make(chan struct{}, 100)
+	stop := sw.Report(time.Millisecond, func(Snapshot) { calls <- struct{}{} })
+	<-calls
+
+	// Stopping the window ends the reporter as well.
+	sw.Stop()
+	stop()
+
+	for len(calls) > 0 {
+		<-calls
+	}
+	time.Sleep(5 * time.Millisecond)
+	assert.Len(t, calls, 0)
+}
+
+func TestReportInterval(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	assert.Panics(t, func() { sw.Report(0, func(Snapshot) {}) })
+}
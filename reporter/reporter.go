@@ -0,0 +1,366 @@
+// Package reporter periodically flushes statistics of sliding windows to a
+// StatsD daemon or a Graphite server.
+//
+// Every statistic is sent as a gauge, named after the prefix of the reporter,
+// the name of the window and the statistic, like "api.requests.rate". StatsD
+// lines look like "api.requests.rate:12.5|g", Graphite lines like
+// "api.requests.rate 12.5 1700000000".
+package reporter
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prep/average"
+)
+
+// ErrStopped is returned when a Reporter is flushed after it was stopped.
+var ErrStopped = errors.New("reporter is stopped")
+
+// maxPacketSize is the largest UDP packet that is sent, which fits in the MTU
+// of most networks.
+const maxPacketSize = 1432
+
+// defaultTimeout is the time that connecting and every flush may take, unless
+// configured otherwise with WithTimeout.
+const defaultTimeout = 5 * time.Second
+
+// Stat is a statistic of a window that can be reported.
+type Stat int
+
+const (
+	// Rate reports the sum of the values per second.
+	Rate Stat = iota
+	// Average reports the mean of the values.
+	Average
+	// Total reports the sum of the values.
+	Total
+	// P95 reports the 95th percentile of the values, if the window keeps a
+	// reservoir or estimates the 95th percentile. It is skipped otherwise.
+	P95
+)
+
+// String returns the name of the statistic as it is reported.
+func (s Stat) String() string {
+	switch s {
+	case Rate:
+		return "rate"
+	case Average:
+		return "average"
+	case Total:
+		return "total"
+	case P95:
+		return "p95"
+	default:
+		return "unknown"
+	}
+}
+
+// Option configures optional behaviour of a Reporter.
+type Option func(*Reporter) error
+
+// WithPrefix prepends prefix and a dot to the names of all statistics.
+func WithPrefix(prefix string) Option {
+	return func(r *Reporter) error {
+		if prefix = strings.Trim(prefix, "."); prefix != "" {
+			r.prefix = prefix + "."
+		}
+		return nil
+	}
+}
+
+// WithGraphite sends the statistics in the Graphite plaintext protocol
+// instead of as StatsD gauges.
+func WithGraphite() Option {
+	return func(r *Reporter) error {
+		r.graphite = true
+		return nil
+	}
+}
+
+// WithTimeout limits the time that connecting to the server, and writing the
+// statistics of a flush to it, may take. A server that stops reading would
+// otherwise block flushes, and with them Stop, indefinitely.
+func WithTimeout(timeout time.Duration) Option {
+	return func(r *Reporter) error {
+		if timeout <= 0 {
+			return errors.New("timeout has to be positive")
+		}
+
+		r.timeout = timeout
+		return nil
+	}
+}
+
+type metric struct {
+	name   string
+	sw     *average.SlidingWindow
+	window time.Duration
+	stats  []Stat
+}
+
+// Reporter flushes the statistics of the windows that were added to it on
+// a fixed interval.
+type Reporter struct {
+	network  string
+	address  string
+	prefix   string
+	graphite bool
+	timeout  time.Duration
+	conn     net.Conn
+	closed   bool // Whether Stop closed the connection for good.
+	metrics  []metric
+	err      error
+	flushMu  sync.Mutex // Serializes the writes of flushes.
+	stopOnce sync.Once
+	stopC    chan struct{}
+	doneC    chan struct{}
+	sync.Mutex
+}
+
+// New returns a new Reporter that flushes every interval to the address on the
+// network, like "udp" and "localhost:8125" for StatsD, or "tcp" and
+// "localhost:2003" for Graphite. The connection is made right away, and made
+// again with the next flush if it breaks.
+func New(network, address string, interval time.Duration, opts ...Option) (*Reporter, error) {
+	if interval <= 0 {
+		return nil, errors.New("interval has to be positive")
+	}
+
+	r := &Reporter{
+		network: network,
+		address: address,
+		timeout: defaultTimeout,
+		stopC:   make(chan struct{}),
+		doneC:   make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := net.DialTimeout(network, address, r.timeout)
+	if err != nil {
+		return nil, err
+	}
+	r.conn = conn
+
+	go r.flusher(interval)
+	return r, nil
+}
+
+// Add reports the stats of sw over the specified window under name. Without
+// stats, the rate and the average are reported.
+func (r *Reporter) Add(name string, sw *average.SlidingWindow, window time.Duration, stats ...Stat) {
+	if len(stats) == 0 {
+		stats = []Stat{Rate, Average}
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.metrics = append(r.metrics, metric{
+		name:   name,
+		sw:     sw,
+		window: window,
+		stats:  append([]Stat(nil), stats...),
+	})
+}
+
+func (r *Reporter) flusher(interval time.Duration) {
+	defer close(r.doneC)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.flush()
+
+		case <-r.stopC:
+			return
+		}
+	}
+}
+
+// Flush sends the current statistics right away. The statistics are
+// collected with the lock held, but sent after it was released, so a slow
+// server doesn't block Add. Sending gives up once the timeout of the reporter
+// has passed. Flush returns ErrStopped once the reporter was stopped.
+func (r *Reporter) Flush() error {
+	select {
+	case <-r.stopC:
+		return ErrStopped
+	default:
+	}
+
+	return r.flush()
+}
+
+// flush sends the current statistics, like Flush, but also while the reporter
+// is stopping.
+func (r *Reporter) flush() error {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	r.Lock()
+	lines := r.lines(time.Now())
+	conn := r.conn
+	r.Unlock()
+
+	if len(lines) == 0 {
+		return nil
+	}
+
+	if conn == nil {
+		var err error
+		if conn, err = net.DialTimeout(r.network, r.address, r.timeout); err != nil {
+			r.setErr(err)
+			return err
+		}
+
+		// A Flush that raced with Stop mustn't leave a connection behind
+		// that nothing closes anymore.
+		r.Lock()
+		if r.closed {
+			r.Unlock()
+			conn.Close()
+			return ErrStopped
+		}
+		r.conn = conn
+		r.Unlock()
+	}
+
+	if err := write(conn, lines, time.Now().Add(r.timeout)); err != nil {
+		conn.Close()
+
+		r.Lock()
+		if r.conn == conn {
+			r.conn = nil
+		}
+		r.err = err
+		r.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+func (r *Reporter) setErr(err error) {
+	r.Lock()
+	r.err = err
+	r.Unlock()
+}
+
+// lines returns a line for every statistic. It must be called with the lock
+// held.
+func (r *Reporter) lines(now time.Time) [][]byte {
+	var lines [][]byte
+	for _, m := range r.metrics {
+		for _, stat := range m.stats {
+			v, ok := value(m.sw, m.window, stat)
+			if !ok {
+				continue
+			}
+
+			name := r.prefix + m.name + "." + stat.String()
+			s := strconv.FormatFloat(v, 'f', -1, 64)
+			if r.graphite {
+				lines = append(lines, []byte(name+" "+s+" "+strconv.FormatInt(now.Unix(), 10)+"\n"))
+			} else {
+				lines = append(lines, []byte(name+":"+s+"|g\n"))
+			}
+		}
+	}
+
+	return lines
+}
+
+// write writes lines to conn, in packets that don't exceed maxPacketSize on
+// packet-oriented networks, and gives up at the deadline.
+func write(conn net.Conn, lines [][]byte, deadline time.Time) error {
+	if err := conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+
+	_, packets := conn.(net.PacketConn)
+
+	var buf bytes.Buffer
+	for _, line := range lines {
+		if packets && buf.Len() > 0 && buf.Len()+len(line) > maxPacketSize {
+			if _, err := conn.Write(buf.Bytes()); err != nil {
+				return err
+			}
+			buf.Reset()
+		}
+		buf.Write(line)
+	}
+
+	_, err := conn.Write(buf.Bytes())
+	return err
+}
+
+// value returns the stat of sw over window, and whether it is available.
+func value(sw *average.SlidingWindow, window time.Duration, stat Stat) (float64, bool) {
+	switch stat {
+	case Rate:
+		return sw.Rate(window), true
+	case Average:
+		return sw.Average(window), true
+	case Total:
+		total, _ := sw.Total(window)
+		return total, true
+	case P95:
+		// The sample count tells whether the reservoirs in range have any
+		// values without copying them.
+		if _, count := sw.Total(window); count > 0 && sw.ReservoirSize() > 0 {
+			v, _ := sw.Percentile(window, 95)
+			return v, true
+		}
+		if p, ok := sw.PercentileEstimate(); ok && p == 95 {
+			return sw.EstimatedPercentileOK(window)
+		}
+	}
+
+	return 0, false
+}
+
+// Err returns the error of the last flush that failed, if any.
+func (r *Reporter) Err() error {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.err
+}
+
+// Stop stops flushing, flushes the statistics one last time and closes the
+// connection. It returns the error of that last flush. As every flush gives up
+// after the timeout of the reporter, Stop returns within about twice that
+// time, even if the server stopped reading.
+func (r *Reporter) Stop() error {
+	var err error
+	r.stopOnce.Do(func() {
+		close(r.stopC)
+		<-r.doneC
+
+		err = r.flush()
+
+		r.Lock()
+		if r.conn != nil {
+			r.conn.Close()
+			r.conn = nil
+		}
+		r.closed = true
+		r.Unlock()
+	})
+
+	return err
+}
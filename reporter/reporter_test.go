@@ -0,0 +1,215 @@
+package reporter
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prep/average"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReporterStatsD(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r, err := New("udp", conn.LocalAddr().String(), time.Hour, WithPrefix("api."))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sw := average.MustNew(4*time.Second, time.Second, average.WithReservoir(100))
+	defer sw.Stop()
+	for i := 1; i <= 20; i++ {
+		sw.Add(float64(i))
+	}
+
+	plain := average.MustNew(4*time.Second, time.Second)
+	defer plain.Stop()
+
+	r.Add("requests", sw, 4*time.Second, Total, Average, P95)
+	r.Add("plain", plain, 4*time.Second, P95)
+	assert.NoError(t, r.Flush())
+
+	buf := make([]byte, maxPacketSize)
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "api.requests.total:210|g\napi.requests.average:10.5|g\napi.requests.p95:19|g\n", string(buf[:n]))
+
+	assert.NoError(t, r.Stop())
+	assert.NoError(t, r.Stop())
+	assert.NoError(t, r.Err())
+
+	// A flush after Stop doesn't connect again.
+	assert.Equal(t, ErrStopped, r.Flush())
+	assert.Nil(t, r.conn)
+}
+
+func TestReporterEstimate(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r, err := New("udp", conn.LocalAddr().String(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+
+	// An estimate of another percentile isn't reported as the 95th, but an
+	// estimate of 0 is.
+	p99 := average.MustNew(4*time.Second, time.Second, average.WithPercentileEstimate(99))
+	defer p99.Stop()
+	p95 := average.MustNew(4*time.Second, time.Second, average.WithPercentileEstimate(95))
+	defer p95.Stop()
+	for i := 0; i < 10; i++ {
+		p99.Add(float64(i))
+		p95.Add(0)
+	}
+
+	r.Add("p99", p99, 4*time.Second, P95)
+	r.Add("p95", p95, 4*time.Second, P95)
+	r.Add("total", p99, 4*time.Second, Total)
+	assert.NoError(t, r.Flush())
+
+	buf := make([]byte, maxPacketSize)
+	n, _, err := conn.ReadFrom(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "p95.p95:0|g\ntotal.total:45|g\n", string(buf[:n]))
+}
+
+func TestReporterPackets(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r, err := New("udp", conn.LocalAddr().String(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Stop()
+
+	sw := average.MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	name := strings.Repeat("x", 100)
+	for i := 0; i < 20; i++ {
+		r.Add(name, sw, time.Second, Rate)
+	}
+	assert.NoError(t, r.Flush())
+
+	var lines int
+	buf := make([]byte, 2*maxPacketSize)
+	for lines < 20 {
+		n, _, err := conn.ReadFrom(buf)
+		assert.NoError(t, err)
+		assert.True(t, n <= maxPacketSize)
+		lines += strings.Count(string(buf[:n]), "\n")
+	}
+	assert.Equal(t, 20, lines)
+}
+
+func TestReporterGraphite(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	r, err := New("tcp", ln.Addr().String(), 10*time.Millisecond, WithGraphite())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sw := average.MustNew(4*time.Second, time.Second, average.WithManualClock())
+	defer sw.Stop()
+	sw.Add(3)
+	sw.Advance(time.Second)
+	r.Add("requests", sw, 4*time.Second)
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	assert.NoError(t, err)
+
+	fields := strings.Fields(line)
+	assert.Len(t, fields, 3)
+	assert.Equal(t, "requests.rate", fields[0])
+	assert.Equal(t, "3", fields[1])
+
+	assert.NoError(t, r.Stop())
+}
+
+func TestReporterTimeout(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	r, err := New("udp", conn.LocalAddr().String(), time.Hour, WithTimeout(50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Replace the connection with a pipe that nobody reads from.
+	client, server := net.Pipe()
+	defer server.Close()
+	r.conn.Close()
+	r.conn = client
+
+	sw := average.MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+	sw.Add(1)
+	r.Add("requests", sw, 4*time.Second, Total)
+
+	flushed := make(chan error)
+	go func() { flushed <- r.Flush() }()
+
+	// Add doesn't wait for the stuck flush.
+	time.Sleep(10 * time.Millisecond)
+	r.Add("other", sw, 4*time.Second, Average)
+
+	select {
+	case err := <-flushed:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("flush didn't give up")
+	}
+	assert.Error(t, r.Err())
+
+	stopped := make(chan error)
+	go func() { stopped <- r.Stop() }()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop didn't give up")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New("udp", "127.0.0.1:8125", 0)
+	assert.EqualError(t, err, "interval has to be positive")
+
+	_, err = New("udp", "127.0.0.1:8125", time.Second, WithTimeout(0))
+	assert.EqualError(t, err, "timeout has to be positive")
+
+	_, err = New("nope", "127.0.0.1:8125", time.Second)
+	assert.Error(t, err)
+
+	assert.Equal(t, "unknown", Stat(42).String())
+}
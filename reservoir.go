@@ -0,0 +1,112 @@
+package average
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// WithReservoir keeps up to size raw values per bucket, in addition to the
+// running totals. Once more values than that are added to a bucket, the
+// values it keeps are a uniform random sample of all values of that bucket.
+func WithReservoir(size int) Option {
+	return func(sw *SlidingWindow) error {
+		if size < 1 {
+			return errors.New("reservoir size has to be at least 1")
+		}
+
+		sw.reservoirs = make([][]float64, sw.len())
+		sw.reservoirSize = size
+		return nil
+	}
+}
+
+// ReservoirSize returns the number of values that the window keeps per bucket,
+// as passed to WithReservoir, or 0 if the window was not created with it.
+func (sw *SlidingWindow) ReservoirSize() int {
+	return sw.reservoirSize
+}
+
+// sample adds v to the reservoir of the current bucket. It must be called with
+// the lock held, after the count of the current bucket has been incremented.
+func (sw *SlidingWindow) sample(v float64) {
+	res := sw.reservoirs[sw.pos]
+	if len(res) < sw.reservoirSize {
+		sw.reservoirs[sw.pos] = append(res, v)
+		return
+	}
+
+	if i := rand.Int63n(sw.count(sw.pos)); i < int64(sw.reservoirSize) {
+		res[i] = v
+	}
+}
+
+// Samples returns a copy of the raw values that are kept over the specified
+// window, ordered from the newest to the oldest bucket. It returns nil if the
+// window was not created with WithReservoir.
+func (sw *SlidingWindow) Samples(window time.Duration) []float64 {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	values, _ := sw.reservoirSamples(window)
+	return values
+}
+
+// reservoirSamples returns a copy of the raw values that are kept over the
+// specified window, and whether those are all values that were added. It must
+// be called with the lock held.
+func (sw *SlidingWindow) reservoirSamples(window time.Duration) ([]float64, bool) {
+	if sw.reservoirs == nil {
+		return nil, false
+	}
+
+	var values []float64
+	complete := true
+	for i, n := 0, sw.buckets(window); i < n; i++ {
+		if sw.open(i) {
+			continue
+		}
+
+		pos := sw.index(i)
+		values = append(values, sw.reservoirs[pos]...)
+		if int64(len(sw.reservoirs[pos])) < sw.count(pos) {
+			complete = false
+		}
+	}
+
+	return values, complete
+}
+
+// Percentile returns the p-th percentile, with p between 0 and 100, of the
+// values that are kept over the specified window, using the nearest-rank
+// method. The boolean reports whether the result is exact, which is the case
+// when none of the reservoirs in range have overflowed. If a reservoir did
+// overflow, the result is an estimate based on the sampled values. If there
+// are no values or the window was not created with WithReservoir, Percentile
+// returns 0 and false.
+func (sw *SlidingWindow) Percentile(window time.Duration, p float64) (float64, bool) {
+	sw.RLock()
+	values, exact := sw.reservoirSamples(window)
+	sw.RUnlock()
+
+	if len(values) == 0 {
+		return 0, false
+	}
+
+	sort.Float64s(values)
+	return nearestRank(values, p), exact
+}
+
+// nearestRank returns the p-th percentile of the sorted values.
+func nearestRank(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	} else if rank > len(sorted) {
+		rank = len(sorted)
+	}
+
+	return sorted[rank-1]
+}
@@ -0,0 +1,97 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithReservoir(t *testing.T) {
+	_, err := New(10*time.Second, time.Second, WithReservoir(0))
+	assert.EqualError(t, err, "reservoir size has to be at least 1")
+
+	sw := MustNew(10*time.Second, time.Second, WithReservoir(5))
+	defer sw.Stop()
+	assert.Equal(t, 5, sw.ReservoirSize())
+
+	plain := MustNew(10*time.Second, time.Second)
+	defer plain.Stop()
+	assert.Equal(t, 0, plain.ReservoirSize())
+}
+
+func TestSamples(t *testing.T) {
+	sw := MustNew(3*time.Second, time.Second, WithReservoir(10))
+	defer sw.Stop()
+
+	sw.Add(1)
+	sw.Add(2)
+
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+	sw.Add(3)
+
+	assert.Equal(t, []float64{3}, sw.Samples(time.Second))
+	assert.Equal(t, []float64{3, 1, 2}, sw.Samples(3*time.Second))
+
+	sw.Lock()
+	sw.shift()
+	sw.shift()
+	sw.Unlock()
+
+	assert.Equal(t, []float64{3}, sw.Samples(3*time.Second))
+}
+
+func TestSamplesWithoutReservoir(t *testing.T) {
+	sw := MustNew(3*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Add(1)
+	assert.Nil(t, sw.Samples(3*time.Second))
+
+	v, exact := sw.Percentile(3*time.Second, 50)
+	assert.Equal(t, 0.0, v)
+	assert.False(t, exact)
+}
+
+func TestPercentile(t *testing.T) {
+	sw := MustNew(3*time.Second, time.Second, WithReservoir(100))
+	defer sw.Stop()
+
+	for i := 100; i > 0; i-- {
+		sw.Add(float64(i))
+	}
+
+	tests := []struct {
+		p        float64
+		expected float64
+	}{
+		{0, 1},
+		{50, 50},
+		{95, 95},
+		{99.5, 100},
+		{100, 100},
+	}
+
+	for _, test := range tests {
+		v, exact := sw.Percentile(time.Second, test.p)
+		assert.Equal(t, test.expected, v, "p%v", test.p)
+		assert.True(t, exact)
+	}
+}
+
+func TestPercentileOverflow(t *testing.T) {
+	sw := MustNew(3*time.Second, time.Second, WithReservoir(10))
+	defer sw.Stop()
+
+	for i := 0; i < 1000; i++ {
+		sw.Add(5)
+	}
+
+	assert.Len(t, sw.Samples(time.Second), 10)
+
+	v, exact := sw.Percentile(time.Second, 50)
+	assert.Equal(t, 5.0, v)
+	assert.False(t, exact)
+}
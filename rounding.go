@@ -0,0 +1,50 @@
+package average
+
+import (
+	"errors"
+	"time"
+)
+
+// RoundingMode defines how a query window that isn't a multiplier of the
+// granularity is converted to a number of buckets.
+type RoundingMode int
+
+const (
+	// RoundFloor only includes the buckets that fit in the window entirely.
+	// This is the default.
+	RoundFloor RoundingMode = iota
+	// RoundCeil includes a bucket that fits in the window partially.
+	RoundCeil
+	// RoundNearest includes a bucket that fits in the window for at least
+	// half of its granularity.
+	RoundNearest
+)
+
+// WithRounding sets how queries convert the requested window to a number of
+// buckets. By default, a query over 2.5 seconds on a window with a granularity
+// of 1 second covers 2 buckets. With RoundCeil or RoundNearest, it covers 3.
+func WithRounding(mode RoundingMode) Option {
+	return func(sw *SlidingWindow) error {
+		switch mode {
+		case RoundFloor, RoundCeil, RoundNearest:
+		default:
+			return errors.New("unknown rounding mode")
+		}
+
+		sw.rounding = mode
+		return nil
+	}
+}
+
+// round returns the number of buckets in window, rounded with the rounding
+// mode of this window.
+func (sw *SlidingWindow) round(window time.Duration) int {
+	switch sw.rounding {
+	case RoundCeil:
+		window += sw.granularity - 1
+	case RoundNearest:
+		window += sw.granularity / 2
+	}
+
+	return int(window / sw.granularity)
+}
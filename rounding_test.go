@@ -0,0 +1,39 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRounding(t *testing.T) {
+	tests := []struct {
+		mode    RoundingMode
+		window  time.Duration
+		buckets int
+	}{
+		{RoundFloor, 2500 * time.Millisecond, 2},
+		{RoundFloor, 2999 * time.Millisecond, 2},
+		{RoundCeil, 2001 * time.Millisecond, 3},
+		{RoundCeil, 2 * time.Second, 2},
+		{RoundNearest, 2499 * time.Millisecond, 2},
+		{RoundNearest, 2500 * time.Millisecond, 3},
+		{RoundCeil, 6 * time.Second, 4},
+	}
+
+	for _, test := range tests {
+		sw := MustNew(4*time.Second, time.Second, WithRounding(test.mode), WithManualClock())
+		sw.Advance(4 * time.Second)
+
+		sw.RLock()
+		assert.Equal(t, test.buckets, sw.buckets(test.window), "mode=%d window=%s", test.mode, test.window)
+		sw.RUnlock()
+		sw.Stop()
+	}
+}
+
+func TestWithRoundingUnknown(t *testing.T) {
+	_, err := New(4*time.Second, time.Second, WithRounding(RoundingMode(42)))
+	assert.EqualError(t, err, "unknown rounding mode")
+}
@@ -3,28 +3,87 @@ package average
 
 import (
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// ErrStopped is returned when data is added to a window after it was stopped.
+var ErrStopped = errors.New("window is stopped")
+
 // SlidingWindow provides a sliding time window with a custom size and
 // granularity to store int64 counters. This can be used to determine the total
 // or unweighted mean average of a subset of the window size.
 type SlidingWindow struct {
-	window      time.Duration
-	granularity time.Duration
-	samples     []float64
-	counts      []int64
-	pos         int
-	size        int
-	stopOnce    sync.Once
-	stopC       chan struct{}
+	window        time.Duration
+	granularity   time.Duration
+	samples       []float64
+	counts        []int64
+	samples32     []float32
+	counts32      []uint32
+	pos           int
+	size          int // The number of buckets in use, including the current one.
+	start         time.Time
+	onClear       func(pos int)
+	subscribers   []subscriber
+	onEvict       func(BucketResult)
+	onEvictLocked func(BucketResult) // Like onEvict, but with the lock held.
+	evicted       []BucketResult     // Evicted buckets that wait for onEvict.
+	written       []bool             // Whether a bucket was written to, for evict.
+	wal           *WAL
+	sealed        []BucketResult // Completed buckets that wait for the WAL.
+	stopped       bool
+	autoStop      bool
+	manual        bool
+	virtual       time.Time // The current time of a window with a manual clock.
+	aggregation   Aggregation
+	rounding      RoundingMode
+	valid         []bool // Whether a bucket has a value, unless AggregateSum.
+	carryForward  bool
+	gauge         *gauge
+	mins          []float64
+	maxs          []float64
+	reservoirs    [][]float64
+	reservoirSize int
+	estimates     []p2
+	estimateP     float64 // The percentile that the estimates estimate.
+	weights       []float64
+	means         []meanSums
+	moments       []moments
+	maxBuckets    int
+	coarsen       bool
+	randomPhase   bool
+	driftCorrect  bool
+	bound         float64
+	saturate      bool
+	overflows     []bool // Whether the value of a bucket exceeded the bound.
+	grace         time.Duration
+	unsealed      int // The number of open buckets within the grace period.
+	lockFree      bool
+	view          atomic.Value // The *readView of a window with lock-free reads.
+	current       *seqBucket   // The current bucket of the view, if attached.
+	horizons      []horizon
+	limit         float64 // The limit of a bucket for TryAdd.
+	saturateLimit bool
+	adds          uint64
+	dropped       uint64
+	rotations     uint64
+	missedTicks   uint64
+	lag           int64 // The number of buckets that the shifter is behind.
+	stopOnce      sync.Once
+	stopC         chan struct{}
+	doneC         chan struct{} // Closed once the window is stopped.
+	stopCleanup   func()        // Cancels the cleanup of WithAutoStop.
 	sync.RWMutex
 }
 
+// Option configures optional behaviour of a SlidingWindow.
+type Option func(*SlidingWindow) error
+
 // MustNew returns a new SlidingWindow, but panics if an error occurs.
-func MustNew(window, granularity time.Duration) *SlidingWindow {
-	sw, err := New(window, granularity)
+func MustNew(window, granularity time.Duration, opts ...Option) *SlidingWindow {
+	sw, err := New(window, granularity, opts...)
 	if err != nil {
 		panic(err.Error())
 	}
@@ -33,114 +92,661 @@ func MustNew(window, granularity time.Duration) *SlidingWindow {
 }
 
 // New returns a new SlidingWindow.
-func New(window, granularity time.Duration) (*SlidingWindow, error) {
+func New(window, granularity time.Duration, opts ...Option) (*SlidingWindow, error) {
+	sw, err := newSlidingWindow(window, granularity, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	sw.startShifter()
+	return sw, nil
+}
+
+// newSlidingWindow returns a new SlidingWindow without starting its shifter.
+func newSlidingWindow(window, granularity time.Duration, opts ...Option) (*SlidingWindow, error) {
+	if err := validate(window, granularity); err != nil {
+		return nil, err
+	}
+
+	sw := &SlidingWindow{}
+	sw.init(window, granularity)
+
+	for _, opt := range opts {
+		if err := opt(sw); err != nil {
+			return nil, err
+		}
+	}
+
+	// Options allocate their state for the current number of buckets, so a
+	// raised granularity requires to apply them again.
+	if sw.maxBuckets > 0 && sw.len() > sw.maxBuckets {
+		granularity, err := sw.capGranularity(window, granularity)
+		if err != nil {
+			return nil, err
+		}
+
+		return newSlidingWindow(window, granularity, opts...)
+	}
+
+	if !sw.hasCounts() && (sw.mins != nil || sw.reservoirs != nil) {
+		return nil, errors.New("extrema and reservoirs require sample counts")
+	}
+	if !sw.hasCounts() && sw.estimates != nil {
+		return nil, errors.New("percentile estimates require sample counts")
+	}
+	if !sw.hasCounts() && sw.weights != nil {
+		return nil, errors.New("fractional counts require sample counts")
+	}
+	if !sw.hasCounts() && sw.means != nil {
+		return nil, errors.New("geometric and harmonic means require sample counts")
+	}
+	if !sw.hasCounts() && sw.moments != nil {
+		return nil, errors.New("moments require sample counts")
+	}
+
+	sw.refreshView()
+	return sw, nil
+}
+
+// validate returns an error if window and granularity don't make up a valid
+// sliding window.
+func validate(window, granularity time.Duration) error {
 	if window == 0 {
-		return nil, errors.New("window cannot be 0")
+		return errors.New("window cannot be 0")
 	}
 	if granularity == 0 {
-		return nil, errors.New("granularity cannot be 0")
+		return errors.New("granularity cannot be 0")
 	}
 	if window <= granularity || window%granularity != 0 {
-		return nil, errors.New("window size has to be a multiplier of the granularity size")
+		return errors.New("window size has to be a multiplier of the granularity size")
 	}
 
-	sw := &SlidingWindow{
-		window:      window,
-		granularity: granularity,
-		samples:     make([]float64, int(window/granularity)),
-		counts:      make([]int64, int(window/granularity)),
-		stopC:       make(chan struct{}),
-		size:        int(window / granularity),
+	return nil
+}
+
+// init configures a zero SlidingWindow and allocates its buckets.
+func (sw *SlidingWindow) init(window, granularity time.Duration) {
+	sw.window = window
+	sw.granularity = granularity
+	sw.samples = make([]float64, int(window/granularity))
+	sw.counts = make([]int64, int(window/granularity))
+	sw.stopC = make(chan struct{})
+	sw.doneC = make(chan struct{})
+	sw.size = 1
+	sw.start = time.Now()
+}
+
+// startShifter starts the goroutine that rotates the buckets of this window.
+func (sw *SlidingWindow) startShifter() {
+	if sw.manual {
+		return
 	}
 
-	go sw.shifter()
-	return sw, nil
+	sw.startShifterAfter(sw.phase())
 }
 
-func (sw *SlidingWindow) shifter() {
+// startShifterAfter starts the goroutine that rotates the buckets of this
+// window, with the first rotation after the specified delay, or after one
+// granularity if the delay is 0.
+func (sw *SlidingWindow) startShifterAfter(first time.Duration) {
+	if sw.manual {
+		return
+	}
+	if sw.autoStop {
+		startWeakShifter(sw, first)
+		return
+	}
+
+	go sw.shifter(first)
+}
+
+func (sw *SlidingWindow) shifter(phase time.Duration) {
+	if phase > 0 {
+		if !waitPhase(phase, sw.stopC) {
+			sw.halt()
+			return
+		}
+
+		sw.Lock()
+		sw.rotate()
+		sw.unlockAndEvict()
+	}
+
 	ticker := time.NewTicker(sw.granularity)
+	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
 			sw.Lock()
-			if sw.pos = sw.pos + 1; sw.pos >= len(sw.samples) {
-				sw.pos = 0
-			}
-			sw.samples[sw.pos] = 0
-			sw.counts[sw.pos] = 0
-			sw.Unlock()
+			sw.rotate()
+			sw.unlockAndEvict()
 
 		case <-sw.stopC:
+			sw.halt()
 			return
 		}
 	}
 }
 
-// Add increments the value of the current sample.
+// halt marks this window as stopped once its shifter has exited, and writes
+// the current bucket to the WAL, as it won't be completed anymore.
+func (sw *SlidingWindow) halt() {
+	sw.Lock()
+	sw.stopped = true
+	sw.sealLate(true)
+	if sw.wal != nil {
+		end := sw.now()
+		if limit := sw.start.Add(sw.granularity); end.After(limit) {
+			end = limit
+		}
+
+		sw.seal(BucketResult{
+			Sum:   sw.sum(sw.pos),
+			Count: sw.count(sw.pos),
+			Start: sw.start,
+			End:   end,
+		})
+	}
+	sw.closeSubscribers()
+	sw.unlockAndEvict()
+
+	close(sw.doneC)
+}
+
+// shift moves the current position to the next bucket and clears it. It must
+// be called with the lock held.
+func (sw *SlidingWindow) shift() {
+	sw.detachView()
+
+	if sw.keepsOpen() {
+		// The oldest open bucket is about to be cleared, so it can't wait
+		// for its grace period any longer.
+		if sw.unsealed == sw.len()-1 {
+			sw.complete(sw.unsealed)
+			sw.unsealed--
+		}
+		sw.unsealed++
+	} else {
+		sw.complete(0)
+	}
+
+	if sw.gauge != nil {
+		sw.accrue(sw.start.Add(sw.granularity))
+	}
+	sw.start = sw.start.Add(sw.granularity)
+	sw.rotations++
+	if sw.horizons != nil {
+		sw.rotateHorizons()
+	}
+	if sw.pos = sw.pos + 1; sw.pos >= sw.len() {
+		sw.pos = 0
+	}
+	if (sw.onEvict != nil || sw.onEvictLocked != nil) && sw.size == sw.len() {
+		sw.evict(sw.pos)
+	}
+	if sw.size < sw.len() {
+		sw.size++
+	}
+	sw.clear(sw.pos)
+	if sw.carryForward {
+		sw.carry()
+	}
+	if sw.horizons != nil {
+		sw.rescanHorizons(false)
+	}
+}
+
+// complete passes the bucket that is age buckets older than the current one to
+// the subscribers and the WAL. It must be called with the lock held.
+func (sw *SlidingWindow) complete(age int) {
+	if len(sw.subscribers) == 0 && sw.wal == nil {
+		return
+	}
+
+	pos := sw.index(age)
+	start := sw.start.Add(-time.Duration(age) * sw.granularity)
+	result := BucketResult{
+		Sum:   sw.sum(pos),
+		Count: sw.count(pos),
+		Start: start,
+		End:   start.Add(sw.granularity),
+	}
+	if len(sw.subscribers) > 0 {
+		sw.publish(result)
+	}
+	if sw.wal != nil {
+		sw.seal(result)
+	}
+}
+
+// clear zeroes the bucket at the specified position. It must be called with
+// the lock held.
+func (sw *SlidingWindow) clear(pos int) {
+	sw.set(pos, 0, 0)
+	if sw.valid != nil {
+		sw.valid[pos] = false
+	}
+	if sw.mins != nil {
+		sw.mins[pos], sw.maxs[pos] = 0, 0
+	}
+	if sw.reservoirs != nil {
+		sw.reservoirs[pos] = sw.reservoirs[pos][:0]
+	}
+	if sw.estimates != nil {
+		sw.estimates[pos].reset()
+	}
+	if sw.weights != nil {
+		sw.weights[pos] = 0
+	}
+	if sw.means != nil {
+		sw.means[pos] = meanSums{}
+	}
+	if sw.moments != nil {
+		sw.moments[pos] = moments{}
+	}
+	if sw.overflows != nil {
+		sw.overflows[pos] = false
+	}
+	if sw.written != nil {
+		sw.written[pos] = false
+	}
+	if sw.gauge != nil {
+		sw.gauge.areas[pos], sw.gauge.times[pos] = 0, 0
+	}
+	if sw.onClear != nil {
+		sw.onClear(pos)
+	}
+}
+
+// average returns the mean over the specified window: the mean of the samples,
+// the mean of the buckets for windows without sample counts, or the mean of
+// the buckets with a value for other aggregations than AggregateSum. It must
+// be called with the lock held.
+func (sw *SlidingWindow) average(window time.Duration) float64 {
+	avg, _ := sw.averageOK(window)
+	return avg
+}
+
+// averageOK returns the mean over the specified window like average, and
+// whether there was any data to average. It must be called with the lock held.
+func (sw *SlidingWindow) averageOK(window time.Duration) (float64, bool) {
+	if sw.gauge != nil {
+		if avg, ok := sw.gaugeAverage(window); ok {
+			return avg, true
+		}
+	}
+	if sw.valid != nil {
+		return sw.valueAverage(window)
+	}
+	if sw.weights != nil {
+		total, weight := sw.totalWeight(window)
+		if weight == 0 {
+			return 0, false
+		}
+
+		return total / weight, true
+	}
+
+	total, count, n := sw.total(window)
+	if !sw.hasCounts() {
+		count = int64(n)
+	}
+	if count == 0 {
+		return 0, false
+	}
+
+	return total / float64(count), true
+}
+
+// buckets returns the number of buckets that make up the specified window,
+// which is 0 for a negative window. It must be called with the lock held.
+func (sw *SlidingWindow) buckets(window time.Duration) int {
+	if window > sw.window {
+		window = sw.window
+	}
+
+	n := sw.round(window)
+	if n > sw.size {
+		n = sw.size
+	} else if n < 0 {
+		n = 0
+	}
+
+	return n
+}
+
+// index returns the position of the bucket that is age buckets older than the
+// current one.
+func (sw *SlidingWindow) index(age int) int {
+	pos := sw.pos - age
+	if pos < 0 {
+		pos += sw.len()
+	}
+
+	return pos
+}
+
+// Add increments the value of the current sample. It has no effect once the
+// window is stopped.
 func (sw *SlidingWindow) Add(v float64) {
 	sw.Lock()
-	sw.samples[sw.pos] += v
-	sw.counts[sw.pos]++
+	sw.add(v, 1)
 	sw.Unlock()
 }
 
-// Average returns the unweighted mean of the specified window.
+// AddN increments the value of the current sample by v and its sample count by
+// n in one operation. This is useful to add a batch of values that was
+// aggregated upstream, like 500 events with a total value of 12.5. Optional
+// per-bucket state that tracks individual values, like the extrema or the
+// reservoir, records the mean v/n of the batch as a single value. A batch with
+// a negative number of samples is ignored.
+func (sw *SlidingWindow) AddN(v float64, n int64) {
+	if n < 0 {
+		return
+	}
+
+	sw.Lock()
+	sw.add(v, n)
+	sw.Unlock()
+}
+
+// add increments the value of the current sample by v and its sample count by
+// n. It must be called with the lock held.
+func (sw *SlidingWindow) add(v float64, n int64) {
+	if sw.stopped {
+		sw.dropped++
+		return
+	}
+	sw.adds++
+
+	if sw.gauge != nil {
+		sw.accrue(sw.now())
+	}
+	if sw.valid != nil {
+		sw.fold(v, n)
+	} else {
+		sw.increment(sw.pos, v, n)
+	}
+	if sw.weights != nil {
+		sw.weights[sw.pos] += float64(n)
+	}
+	if sw.overflows != nil {
+		sw.checkBound(sw.pos)
+	}
+	if sw.gauge != nil {
+		sw.gauge.value, sw.gauge.known = sw.sum(sw.pos), true
+	}
+
+	if n <= 0 || !sw.hasCounts() {
+		return
+	}
+
+	if n > 1 {
+		v /= float64(n)
+	}
+	sw.observe(v, n)
+}
+
+// observe records the value v, which was added n times, in the optional
+// per-bucket state of the current bucket. It must be called with the lock
+// held, after the count of the current bucket has been incremented by n.
+func (sw *SlidingWindow) observe(v float64, n int64) {
+	if sw.mins != nil {
+		sw.extrema(v, n)
+	}
+	if sw.reservoirs != nil {
+		sw.sample(v)
+	}
+	if sw.estimates != nil {
+		sw.estimates[sw.pos].add(v)
+	}
+	if sw.means != nil {
+		sw.mean(v, n)
+	}
+	if sw.moments != nil {
+		sw.moments[sw.pos].merge(moments{count: float64(n), mean: v})
+	}
+}
+
+// Average returns the unweighted mean of the samples in the specified window.
+// Use AveragePerBucket for the mean of the bucket totals instead. For windows
+// created WithoutCounts, this is the mean of the buckets in the window. For
+// gauges, this is the time-weighted mean of the gauge.
 func (sw *SlidingWindow) Average(window time.Duration) float64 {
-	total, sampleCount := sw.Total(window)
-	if sampleCount == 0 {
+	if v := sw.loadView(); v != nil && sw.valid == nil && sw.weights == nil {
+		total, count, n := v.total(sw, window)
+		if !sw.hasCounts() {
+			count = int64(n)
+		}
+		if count == 0 {
+			return 0
+		}
+
+		return total / float64(count)
+	}
+
+	sw.RLock()
+	defer sw.RUnlock()
+
+	return sw.average(window)
+}
+
+// AverageOK returns the same mean as Average, and whether the specified window
+// contains any samples. This tells a window without data, for which Average
+// returns 0, apart from data that averages to 0. For windows created
+// WithoutCounts, which don't know about samples, it reports whether the window
+// covers any bucket. For other aggregations than AggregateSum, it reports
+// whether any bucket in the window has a value.
+func (sw *SlidingWindow) AverageOK(window time.Duration) (float64, bool) {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	return sw.averageOK(window)
+}
+
+// AveragePerBucket returns the mean of the bucket totals of the specified
+// window, like the average number of bytes per bucket over the last minute.
+// Where Average divides the sum of the window by the number of samples, this
+// divides it by the number of buckets in use, so empty buckets count as 0 and
+// the number of samples in a bucket doesn't matter.
+func (sw *SlidingWindow) AveragePerBucket(window time.Duration) float64 {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	total, _, n := sw.total(window)
+	if n == 0 {
 		return 0
 	}
 
-	return float64(total) / float64(sampleCount)
+	return total / float64(n)
 }
 
-// Reset the samples in this sliding time window.
+// Reset the samples in this sliding time window. The window starts over as if
+// it was just created: only the current bucket is in use, and every rotation
+// adds another bucket until the window is full again. Use ResetData to clear
+// the samples while keeping all buckets in use.
 func (sw *SlidingWindow) Reset() {
 	sw.Lock()
 	defer sw.Unlock()
 
-	sw.pos, sw.size = 0, 0
-	for i := range sw.samples {
-		sw.samples[i] = 0
-		sw.counts[i] = 0
+	sw.detachView()
+	sw.pos, sw.size, sw.unsealed = 0, 1, 0
+	if sw.gauge != nil {
+		sw.gauge.known = false
+	}
+	for i := 0; i < sw.len(); i++ {
+		sw.clear(i)
+	}
+	sw.rescanHorizons(true)
+	sw.refreshView()
+}
+
+// ResetData clears the samples in this sliding time window, but keeps the
+// buckets that are in use and the current position. A full window therefore
+// stays full, so queries keep covering the same time span and averages per
+// bucket keep dividing by the same number of buckets.
+func (sw *SlidingWindow) ResetData() {
+	sw.Lock()
+	defer sw.Unlock()
+
+	sw.detachView()
+	sw.unsealed = 0
+	if sw.gauge != nil {
+		sw.gauge.known = false
+	}
+	for i := 0; i < sw.len(); i++ {
+		sw.clear(i)
+	}
+	sw.rescanHorizons(true)
+	sw.refreshView()
+}
+
+// Trim drops the data that is older than the specified age, like everything
+// that was added before a failover, while keeping the recent data that Reset
+// and ResetData would clear as well. The buckets that make up the specified
+// window, rounded like any other window, are kept, and the older buckets are
+// cleared and no longer in use. The current bucket is always kept.
+func (sw *SlidingWindow) Trim(olderThan time.Duration) {
+	sw.Lock()
+	defer sw.Unlock()
+
+	n := sw.buckets(olderThan)
+	if n < 1 {
+		n = 1
+	}
+
+	sw.detachView()
+	for age := n; age < sw.size; age++ {
+		sw.clear(sw.index(age))
 	}
+	sw.size = n
+	if sw.unsealed >= n {
+		sw.unsealed = n - 1
+	}
+	sw.rescanHorizons(true)
+	sw.refreshView()
 }
 
 // Stop the shifter of this sliding time window. A stopped SlidingWindow cannot
-// be started again.
+// be started again. Stop returns once the shifter has exited, so no more
+// buckets are rotated after it returns. Its data stays as it was at that
+// moment: values that are added afterwards are discarded, and queries only
+// reflect the data that was added before Stop. Windows created
+// WithManualClock don't have a shifter, but have to be stopped all the same to
+// close their subscriptions. The current bucket is written to the WAL of the
+// window before Stop returns, so the WAL can be closed afterwards.
 func (sw *SlidingWindow) Stop() {
 	sw.stopOnce.Do(func() {
+		if sw.manual {
+			sw.halt()
+			return
+		}
+		if sw.stopC == nil {
+			// A zero window, like one that failed to decode, has no shifter.
+			sw.Lock()
+			sw.stopped = true
+			sw.Unlock()
+			return
+		}
+
+		if sw.stopCleanup != nil {
+			sw.stopCleanup()
+		}
+
 		sw.stopC <- struct{}{}
+		<-sw.doneC
 	})
 }
 
+// Stopped returns true if this window was stopped.
+func (sw *SlidingWindow) Stopped() bool {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	return sw.stopped
+}
+
+// StopAndSnapshot stops the shifter of this sliding time window and returns a
+// snapshot of its final state. Because no bucket rotates after Stop returns,
+// the snapshot can't race against a last tick. Like Stop, it writes the
+// current bucket to the WAL of the window before it returns.
+func (sw *SlidingWindow) StopAndSnapshot() Snapshot {
+	sw.Stop()
+	return sw.Snapshot()
+}
+
+// String returns a summary of the configuration and the state of the whole
+// window, like "window=1m0s gran=1s avg=3.2 total=192 n=60".
+func (sw *SlidingWindow) String() string {
+	sw.RLock()
+	total, count, _ := sw.total(sw.window)
+	avg := sw.average(sw.window)
+	sw.RUnlock()
+
+	return fmt.Sprintf("window=%s gran=%s avg=%g total=%g n=%d", sw.window, sw.granularity, avg, total, count)
+}
+
+// Rate returns the sum of all values over the specified window per second.
+// The sum is divided by the time that the buckets that make up the window
+// actually cover, like Coverage, rather than by the requested window. This
+// includes the part of the current bucket that has elapsed, so a steady rate
+// doesn't dip while the current bucket fills up. Rate returns 0 if the window
+// doesn't cover any time yet.
+func (sw *SlidingWindow) Rate(window time.Duration) float64 {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	total, _, n := sw.total(window)
+	return sw.rate(total, n)
+}
+
+// rate returns the total of the n most recent buckets per second of the time
+// they cover. It must be called with the lock held.
+func (sw *SlidingWindow) rate(total float64, n int) float64 {
+	covered := sw.coverage(n)
+	if covered <= 0 {
+		return 0
+	}
+
+	return total / covered.Seconds()
+}
+
 // Total returns the sum of all values over the specified window, as well as
 // the number of samples.
 func (sw *SlidingWindow) Total(window time.Duration) (float64, int64) {
-	if window > sw.window {
-		window = sw.window
-	}
-
-	totalCount := int64(0)
-	sampleCount := int(window / sw.granularity)
-	if sampleCount > sw.size {
-		sampleCount = sw.size
+	if v := sw.loadView(); v != nil {
+		total, count, _ := v.total(sw, window)
+		return total, count
 	}
 
 	sw.RLock()
 	defer sw.RUnlock()
 
+	total, count, _ := sw.total(window)
+	return total, count
+}
+
+// total returns the sum of all values over the specified window, the number of
+// samples and the number of buckets that were considered. It must be called
+// with the lock held.
+func (sw *SlidingWindow) total(window time.Duration) (float64, int64, int) {
 	var total float64
-	for i := 0; i < sampleCount; i++ {
-		pos := sw.pos - i
-		if pos < 0 {
-			pos += len(sw.samples)
+	var totalCount int64
+
+	n := sw.buckets(window)
+	if h := sw.horizon(window); h != nil && sw.unsealed == 0 {
+		return h.sum + sw.sum(sw.pos), h.count + sw.count(sw.pos), n
+	}
+	for i := 0; i < n; i++ {
+		if sw.open(i) {
+			continue
 		}
 
-		total += sw.samples[pos]
-		totalCount += sw.counts[pos]
+		pos := sw.index(i)
+		total += sw.sum(pos)
+		totalCount += sw.count(pos)
 	}
 
-	return total, totalCount
+	return total, totalCount, n
 }
@@ -1,6 +1,8 @@
 package average
 
 import (
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -48,6 +50,35 @@ func TestAdd(t *testing.T) {
 	}
 }
 
+func TestAddN(t *testing.T) {
+	sw := MustNew(2*time.Second, time.Second, WithExtrema())
+	defer sw.Stop()
+
+	sw.AddN(12.5, 500)
+	sw.Add(1)
+
+	total, count := sw.Total(time.Second)
+	assert.Equal(t, 13.5, total)
+	assert.Equal(t, int64(501), count)
+	assert.Equal(t, 0.025, sw.Min(time.Second))
+	assert.Equal(t, 1.0, sw.Max(time.Second))
+}
+
+func TestAddNNegative(t *testing.T) {
+	sw := MustNew(2*time.Second, time.Second, WithReservoir(10))
+	defer sw.Stop()
+
+	sw.Add(1)
+	sw.AddN(-5, -2)
+	sw.Add(2)
+
+	total, count := sw.Total(time.Second)
+	assert.Equal(t, 3.0, total)
+	assert.Equal(t, int64(2), count)
+	_, ok := sw.Percentile(time.Second, 50)
+	assert.True(t, ok)
+}
+
 func TestAverage(t *testing.T) {
 	sw := &SlidingWindow{
 		window:      10 * time.Second,
@@ -66,6 +97,66 @@ func TestAverage(t *testing.T) {
 	assert.Equal(t, 1.8695652173913044, sw.Average(20*time.Second))
 }
 
+func TestAverageOK(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	avg, ok := sw.AverageOK(4 * time.Second)
+	assert.Equal(t, 0.0, avg)
+	assert.False(t, ok)
+
+	sw.Add(-2)
+	sw.Add(2)
+	avg, ok = sw.AverageOK(4 * time.Second)
+	assert.Equal(t, 0.0, avg)
+	assert.True(t, ok)
+
+	_, ok = sw.AverageOK(0)
+	assert.False(t, ok)
+
+	sums := MustNew(4*time.Second, time.Second, WithoutCounts())
+	defer sums.Stop()
+
+	_, ok = sums.AverageOK(4 * time.Second)
+	assert.True(t, ok)
+
+	gauge := MustNew(4*time.Second, time.Second, WithGauge(false))
+	defer gauge.Stop()
+
+	_, ok = gauge.AverageOK(4 * time.Second)
+	assert.False(t, ok)
+	gauge.Add(0)
+	_, ok = gauge.AverageOK(4 * time.Second)
+	assert.True(t, ok)
+
+	weighted := MustNew(4*time.Second, time.Second, WithFractionalCounts())
+	defer weighted.Stop()
+
+	_, ok = weighted.AverageOK(4 * time.Second)
+	assert.False(t, ok)
+	weighted.AddWeighted(3, 0.5)
+	avg, ok = weighted.AverageOK(4 * time.Second)
+	assert.Equal(t, 3.0, avg)
+	assert.True(t, ok)
+}
+
+func TestAveragePerBucket(t *testing.T) {
+	sw := &SlidingWindow{
+		window:      10 * time.Second,
+		granularity: time.Second,
+		samples:     []float64{20, 4, 5, 0, 0, 0, 0, 0, 4, 10},
+		counts:      []int64{10, 2, 5, 0, 0, 0, 0, 0, 4, 2},
+		pos:         1,
+		size:        10,
+	}
+
+	assert.Equal(t, 0.0, sw.AveragePerBucket(0))
+	assert.Equal(t, 4.0, sw.AveragePerBucket(time.Second))
+	assert.Equal(t, 12.0, sw.AveragePerBucket(2*time.Second))
+	assert.Equal(t, 9.5, sw.AveragePerBucket(4*time.Second))
+	assert.Equal(t, 4.3, sw.AveragePerBucket(10*time.Second))
+}
+
 func TestReset(t *testing.T) {
 	sw := MustNew(2*time.Second, time.Second)
 	defer sw.Stop()
@@ -82,6 +173,83 @@ func TestReset(t *testing.T) {
 	}
 }
 
+func TestResetSize(t *testing.T) {
+	sw := MustNew(3*time.Second, time.Second, WithoutCounts())
+	defer sw.Stop()
+
+	sw.Lock()
+	sw.shift()
+	sw.shift()
+	sw.shift()
+	sw.Unlock()
+	assert.Equal(t, 3, sw.size)
+
+	sw.Reset()
+	assert.Equal(t, 0, sw.pos)
+	assert.Equal(t, 1, sw.size)
+
+	// Data added after a reset is visible right away.
+	sw.Add(6)
+	assert.Equal(t, 6.0, sw.Average(3*time.Second))
+
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+	assert.Equal(t, 2, sw.size)
+	assert.Equal(t, 3.0, sw.Average(3*time.Second))
+}
+
+func TestResetData(t *testing.T) {
+	sw := MustNew(3*time.Second, time.Second, WithoutCounts())
+	defer sw.Stop()
+
+	sw.Lock()
+	sw.shift()
+	sw.shift()
+	sw.shift()
+	sw.Unlock()
+	sw.Add(6)
+
+	sw.ResetData()
+	assert.Equal(t, 0.0, sw.Average(3*time.Second))
+	assert.Equal(t, 0, sw.pos)
+	assert.Equal(t, 3, sw.size)
+
+	// The window stays full, so the average is still over all buckets.
+	sw.Add(6)
+	assert.Equal(t, 2.0, sw.Average(3*time.Second))
+}
+
+func TestTrim(t *testing.T) {
+	sw := MustNew(5*time.Second, time.Second)
+	defer sw.Stop()
+
+	for i := 1; i <= 4; i++ {
+		sw.Add(float64(i))
+		sw.Lock()
+		sw.shift()
+		sw.Unlock()
+	}
+	sw.Add(5)
+
+	sw.Trim(2 * time.Second)
+	assert.Equal(t, []int64{1, 1}, sw.Counts(5*time.Second))
+	assert.Equal(t, 4.5, sw.AveragePerBucket(5*time.Second))
+
+	// Trimmed buckets don't come back once the window grows again.
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+
+	total, count := sw.Total(5 * time.Second)
+	assert.Equal(t, 9.0, total)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, 3, sw.size)
+
+	sw.Trim(0)
+	assert.Equal(t, []int64{0}, sw.Counts(5*time.Second))
+}
+
 func TestResetFlow(t *testing.T) {
 	sw := MustNew(time.Second, 10*time.Millisecond)
 	defer sw.Stop()
@@ -93,6 +261,23 @@ func TestResetFlow(t *testing.T) {
 	sw.Reset()
 }
 
+func TestString(t *testing.T) {
+	sw := &SlidingWindow{
+		window:      10 * time.Second,
+		granularity: time.Second,
+		samples:     []float64{1, 2, 5, 0, 0, 0, 0, 0, 4, 0},
+		counts:      []int64{1, 2, 2, 0, 0, 0, 0, 0, 3, 0},
+		pos:         1,
+		size:        10,
+	}
+
+	assert.Equal(t, "window=10s gran=1s avg=1.5 total=12 n=8", sw.String())
+	assert.Equal(t, "window=10s gran=1s avg=1.5 total=12 n=8", fmt.Sprintf("%v", sw))
+
+	sw.Reset()
+	assert.Equal(t, "window=10s gran=1s avg=0 total=0 n=0", sw.String())
+}
+
 func TestTotal(t *testing.T) {
 	sw := &SlidingWindow{
 		window:      10 * time.Second,
@@ -126,11 +311,193 @@ func TestTotal(t *testing.T) {
 
 func TestTotalFromNew(t *testing.T) {
 	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
 
 	sw.Add(10)
 	sw.Add(20)
 	total, samples := sw.Total(time.Second)
 
+	// Both values are added to the current bucket, so they are two samples
+	// of the same bucket rather than one sample per bucket.
 	assert.Equal(t, 30.0, total)
-	assert.Equal(t, 1, samples)
+	assert.Equal(t, int64(2), samples)
+}
+
+func TestRate(t *testing.T) {
+	sw := &SlidingWindow{
+		window:      10 * time.Second,
+		granularity: time.Second,
+		samples:     []float64{1, 2, 5, 0, 0, 0, 0, 0, 4, 0},
+		counts:      []int64{1, 2, 2, 0, 0, 0, 0, 0, 3, 0},
+		pos:         1,
+		size:        10,
+	}
+
+	assert.Equal(t, 0.0, sw.Rate(0))
+	assert.Equal(t, 1.5, sw.Rate(2*time.Second))
+	assert.Equal(t, 1.2, sw.Rate(10*time.Second))
+	assert.Equal(t, 1.2, sw.Rate(time.Minute))
+
+	// Only the elapsed part of the current bucket counts.
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	sw = MustNewReplay(10*time.Second, 500*time.Millisecond, start)
+	defer sw.Stop()
+
+	assert.Equal(t, 0.0, sw.Rate(10*time.Second))
+	sw.AddAt(start.Add(250*time.Millisecond), 3)
+	assert.Equal(t, 12.0, sw.Rate(500*time.Millisecond))
+	sw.AdvanceTo(start.Add(time.Second))
+	assert.Equal(t, 3.0, sw.Rate(10*time.Second))
+}
+
+func TestAddAfterStop(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithFractionalCounts())
+	sw.Add(2)
+	assert.False(t, sw.Stopped())
+
+	sw.Stop()
+	assert.True(t, sw.Stopped())
+
+	sw.Add(10)
+	sw.AddN(10, 5)
+	sw.AddWeighted(10, 0.5)
+
+	total, count := sw.Total(4 * time.Second)
+	assert.Equal(t, 2.0, total)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, 2.0, sw.Average(4*time.Second))
+}
+
+func TestAddAfterStopManualClock(t *testing.T) {
+	sw := MustNewReplay(4*time.Second, time.Second, time.Now())
+	sw.Add(1)
+	sw.Stop()
+
+	assert.False(t, sw.AddAt(sw.start, 1))
+	sw.Advance(time.Second)
+	assert.Equal(t, []int64{1}, sw.Counts(4*time.Second))
+}
+
+func BenchmarkAdd(b *testing.B) {
+	benchmarkAdd(b)
+}
+
+func BenchmarkAddLockFree(b *testing.B) {
+	benchmarkAdd(b, WithLockFreeReads())
+}
+
+func benchmarkAdd(b *testing.B, opts ...Option) {
+	sw := MustNew(time.Minute, time.Second, opts...)
+	defer sw.Stop()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			sw.Add(1)
+		}
+	})
+}
+
+func BenchmarkTotal(b *testing.B) {
+	benchmarkTotal(b)
+}
+
+func BenchmarkTotalLockFree(b *testing.B) {
+	benchmarkTotal(b, WithLockFreeReads())
+}
+
+func benchmarkTotal(b *testing.B, opts ...Option) {
+	sw := MustNew(time.Minute, time.Second, opts...)
+	defer sw.Stop()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			sw.Total(time.Minute)
+		}
+	})
+}
+
+// BenchmarkAddWithReaders measures Add while other goroutines keep calling
+// Total, like a dashboard that polls a busy window.
+func BenchmarkAddWithReaders(b *testing.B) {
+	benchmarkAddWithReaders(b)
+}
+
+func BenchmarkAddWithReadersLockFree(b *testing.B) {
+	benchmarkAddWithReaders(b, WithLockFreeReads())
+}
+
+func benchmarkAddWithReaders(b *testing.B, opts ...Option) {
+	sw := MustNew(time.Minute, time.Second, opts...)
+	defer sw.Stop()
+
+	stopC := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stopC:
+					return
+				default:
+					sw.Total(time.Minute)
+				}
+			}
+		}()
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			sw.Add(1)
+		}
+	})
+	b.StopTimer()
+
+	close(stopC)
+	wg.Wait()
+}
+
+// BenchmarkTotalWithWriters measures Total while other goroutines keep
+// calling Add.
+func BenchmarkTotalWithWriters(b *testing.B) {
+	benchmarkTotalWithWriters(b)
+}
+
+func BenchmarkTotalWithWritersLockFree(b *testing.B) {
+	benchmarkTotalWithWriters(b, WithLockFreeReads())
+}
+
+func benchmarkTotalWithWriters(b *testing.B, opts ...Option) {
+	sw := MustNew(time.Minute, time.Second, opts...)
+	defer sw.Stop()
+
+	stopC := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stopC:
+					return
+				default:
+					sw.Add(1)
+				}
+			}
+		}()
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			sw.Total(time.Minute)
+		}
+	})
+	b.StopTimer()
+
+	close(stopC)
+	wg.Wait()
 }
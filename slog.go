@@ -0,0 +1,24 @@
+//go:build go1.21
+
+package average
+
+import "log/slog"
+
+// LogValue implements slog.LogValuer, so that a window can be logged as a
+// single attribute. The resulting group describes the whole window.
+func (sw *SlidingWindow) LogValue() slog.Value {
+	sw.RLock()
+	total, count, n := sw.total(sw.window)
+	avg := sw.average(sw.window)
+	rate := sw.rate(total, n)
+	sw.RUnlock()
+
+	return slog.GroupValue(
+		slog.Duration("window", sw.window),
+		slog.Duration("granularity", sw.granularity),
+		slog.Float64("average", avg),
+		slog.Float64("total", total),
+		slog.Int64("count", count),
+		slog.Float64("rate", rate),
+	)
+}
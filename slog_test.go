@@ -0,0 +1,36 @@
+//go:build go1.21
+
+package average
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogValue(t *testing.T) {
+	sw := &SlidingWindow{
+		window:      10 * time.Second,
+		granularity: time.Second,
+		samples:     []float64{1, 2, 5, 0, 0, 0, 0, 0, 4, 0},
+		counts:      []int64{1, 2, 2, 0, 0, 0, 0, 0, 3, 0},
+		pos:         1,
+		size:        10,
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	logger.Info("stats", "requests", sw)
+	assert.Equal(t, "level=INFO msg=stats requests.window=10s requests.granularity=1s requests.average=1.5 requests.total=12 requests.count=8 requests.rate=1.2\n", buf.String())
+}
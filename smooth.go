@@ -0,0 +1,105 @@
+package average
+
+import (
+	"sort"
+	"time"
+)
+
+// SmoothingMethod defines how Smoothed removes noise from the values of the
+// buckets.
+type SmoothingMethod int
+
+const (
+	// SmoothMedian replaces every value with the median of the 5 values
+	// around it, which removes spikes while keeping steps intact. Near the
+	// ends of the series, the median is taken over the values that are
+	// available.
+	SmoothMedian SmoothingMethod = iota
+	// SmoothSavitzkyGolay fits a quadratic polynomial to the 5 values around
+	// every value, which removes noise while keeping the height and width of
+	// peaks better than a moving average. The 2 values at either end of the
+	// series are kept as they are.
+	SmoothSavitzkyGolay
+)
+
+// savitzkyGolay holds the coefficients of the 5-point quadratic
+// Savitzky-Golay filter, which are divided by 35.
+var savitzkyGolay = [5]float64{-3, 12, 17, 12, -3}
+
+// String returns the name of the smoothing method.
+func (m SmoothingMethod) String() string {
+	switch m {
+	case SmoothMedian:
+		return "median"
+	case SmoothSavitzkyGolay:
+		return "savitzky-golay"
+	default:
+		return "unknown"
+	}
+}
+
+// Smoothed returns the values of the buckets in the specified window, newest
+// first, with the noise removed by the specified method. This is useful to
+// plot or to put thresholds on noisy data, like per-second counts. It returns
+// nil for an unknown method.
+func (sw *SlidingWindow) Smoothed(window time.Duration, method SmoothingMethod) []float64 {
+	sw.RLock()
+	values := make([]float64, sw.buckets(window))
+	for i := range values {
+		if !sw.open(i) {
+			values[i] = sw.sum(sw.index(i))
+		}
+	}
+	sw.RUnlock()
+
+	switch method {
+	case SmoothMedian:
+		return movingMedian(values)
+	case SmoothSavitzkyGolay:
+		return savitzkyGolayFilter(values)
+	default:
+		return nil
+	}
+}
+
+// movingMedian returns the moving median of the 5 values around every value.
+func movingMedian(values []float64) []float64 {
+	smoothed := make([]float64, len(values))
+	var span [5]float64
+
+	for i := range values {
+		from, to := i-2, i+3
+		if from < 0 {
+			from = 0
+		}
+		if to > len(values) {
+			to = len(values)
+		}
+
+		s := span[:copy(span[:], values[from:to])]
+		sort.Float64s(s)
+		if n := len(s); n%2 == 1 {
+			smoothed[i] = s[n/2]
+		} else {
+			smoothed[i] = (s[n/2-1] + s[n/2]) / 2
+		}
+	}
+
+	return smoothed
+}
+
+// savitzkyGolayFilter returns the values smoothed by the 5-point quadratic
+// Savitzky-Golay filter.
+func savitzkyGolayFilter(values []float64) []float64 {
+	smoothed := append([]float64(nil), values...)
+
+	for i := 2; i < len(values)-2; i++ {
+		var v float64
+		for j, c := range savitzkyGolay {
+			v += c * values[i-2+j]
+		}
+		smoothed[i] = v / 35
+	}
+
+	return smoothed
+}
@@ -0,0 +1,48 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSmoothed(t *testing.T) {
+	sw := MustNew(6*time.Second, time.Second)
+	defer sw.Stop()
+
+	for i, v := range []float64{1, 2, 30, 4, 5, 6} {
+		if i > 0 {
+			sw.Lock()
+			sw.shift()
+			sw.Unlock()
+		}
+		sw.Add(v)
+	}
+
+	// The values are newest first: 6, 5, 4, 30, 2, 1.
+	assert.Equal(t, []float64{5, 5.5, 5, 4, 3, 2}, sw.Smoothed(6*time.Second, SmoothMedian))
+
+	smoothed := sw.Smoothed(6*time.Second, SmoothSavitzkyGolay)
+	assert.Len(t, smoothed, 6)
+	assert.Equal(t, []float64{6, 5}, smoothed[:2])
+	assert.InDelta(t, (-3*6+12*5+17*4+12*30-3*2)/35.0, smoothed[2], 1e-9)
+	assert.InDelta(t, (-3*5+12*4+17*30+12*2-3*1)/35.0, smoothed[3], 1e-9)
+	assert.Equal(t, []float64{2, 1}, smoothed[4:])
+
+	// Too few values for the filter are kept as they are.
+	assert.Equal(t, []float64{6, 5}, sw.Smoothed(2*time.Second, SmoothSavitzkyGolay))
+	assert.Equal(t, []float64{5.5, 5.5}, sw.Smoothed(2*time.Second, SmoothMedian))
+
+	assert.Nil(t, sw.Smoothed(6*time.Second, SmoothingMethod(-1)))
+	assert.Equal(t, "savitzky-golay", SmoothSavitzkyGolay.String())
+}
+
+func TestSmoothedNegativeWindow(t *testing.T) {
+	sw := MustNew(6*time.Second, time.Second)
+	defer sw.Stop()
+
+	sw.Add(1)
+	assert.Empty(t, sw.Smoothed(-time.Second, SmoothMedian))
+	assert.Empty(t, sw.Smoothed(-time.Second, SmoothSavitzkyGolay))
+}
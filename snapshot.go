@@ -0,0 +1,257 @@
+package average
+
+import (
+	"errors"
+	"time"
+)
+
+// Snapshot is a point-in-time copy of the totals and counts of a
+// SlidingWindow. Optional per-bucket state, like the extrema or reservoirs, is
+// not part of a snapshot. The counts of a window created WithoutCounts are
+// all 0.
+type Snapshot struct {
+	Window      time.Duration
+	Granularity time.Duration
+	// Time is the moment the snapshot was taken.
+	Time time.Time
+	// Start is the moment the current bucket started.
+	Start time.Time
+	// Samples and Counts hold the buckets that are in use, ordered from the
+	// current bucket to the oldest one.
+	Samples []float64
+	Counts  []int64
+}
+
+// Snapshot returns a consistent copy of the buckets of this window.
+func (sw *SlidingWindow) Snapshot() Snapshot {
+	if v := sw.loadView(); v != nil {
+		return v.snapshot(sw)
+	}
+
+	sw.RLock()
+	defer sw.RUnlock()
+
+	return sw.snapshot()
+}
+
+// snapshot returns a copy of the buckets of this window. It must be called with
+// the lock held.
+func (sw *SlidingWindow) snapshot() Snapshot {
+	n := sw.buckets(sw.window)
+	s := Snapshot{
+		Window:      sw.window,
+		Granularity: sw.granularity,
+		Time:        sw.now(),
+		Start:       sw.start,
+		Samples:     make([]float64, n),
+		Counts:      make([]int64, n),
+	}
+
+	for i := 0; i < n; i++ {
+		if sw.open(i) {
+			continue
+		}
+
+		pos := sw.index(i)
+		s.Samples[i] = sw.sum(pos)
+		s.Counts[i] = sw.count(pos)
+	}
+
+	return s
+}
+
+// restore replaces the buckets of this window with those of s. Buckets are aged
+// by the time that passed since the snapshot's current bucket started, so data
+// that has expired in the meantime is discarded. It must be called with the
+// lock held.
+func (sw *SlidingWindow) restore(s Snapshot) error {
+	if s.Window != sw.window || s.Granularity != sw.granularity {
+		return errors.New("snapshot configuration does not match the window")
+	}
+	if err := s.checkBuckets(sw.len()); err != nil {
+		return err
+	}
+
+	sw.detachView()
+	sw.unsealed = 0
+	if sw.gauge != nil {
+		sw.gauge.known = false
+	}
+	for i := 0; i < sw.len(); i++ {
+		sw.clear(i)
+	}
+
+	age := 0
+	if elapsed := sw.now().Sub(s.Start); elapsed > 0 {
+		age = int(elapsed / sw.granularity)
+	}
+
+	// The current bucket is always in use, even if s has no buckets at all.
+	if sw.size = len(s.Samples) + age; sw.size < 1 {
+		sw.size = 1
+	} else if sw.size > sw.len() {
+		sw.size = sw.len()
+	}
+
+	for i := range s.Samples {
+		if age+i >= sw.len() {
+			break
+		}
+
+		pos := sw.index(age + i)
+		sw.set(pos, s.Samples[i], s.Counts[i])
+		if sw.valid != nil {
+			sw.valid[pos] = s.Counts[i] > 0 || s.Samples[i] != 0
+		}
+		if sw.weights != nil {
+			sw.weights[pos] = float64(s.Counts[i])
+		}
+	}
+	sw.rescanHorizons(true)
+	sw.refreshView()
+
+	return nil
+}
+
+// checkBuckets returns an error if s doesn't have a sample count for every
+// bucket, or if it has more than n buckets.
+func (s Snapshot) checkBuckets(n int) error {
+	if len(s.Samples) != len(s.Counts) || len(s.Samples) > n {
+		return errors.New("snapshot has an invalid number of buckets")
+	}
+
+	return nil
+}
+
+// load restores the decoded snapshot s. A zero SlidingWindow is configured
+// after s and its shifter is started. Otherwise, the window needs to have the
+// same configuration as s. A zero SlidingWindow is left as it was if s is
+// invalid.
+func (sw *SlidingWindow) load(s Snapshot) error {
+	sw.Lock()
+	defer sw.Unlock()
+
+	if sw.len() > 0 {
+		return sw.restore(s)
+	}
+
+	if err := validate(s.Window, s.Granularity); err != nil {
+		return err
+	}
+	if err := s.checkBuckets(int(s.Window / s.Granularity)); err != nil {
+		return err
+	}
+
+	sw.init(s.Window, s.Granularity)
+	if err := sw.restore(s); err != nil {
+		return err
+	}
+
+	sw.startShifter()
+	return nil
+}
+
+// buckets returns the number of buckets that make up the specified window. A
+// snapshot that was decoded with fewer counts than samples only has as many
+// buckets as counts.
+func (s Snapshot) buckets(window time.Duration) int {
+	if s.Granularity <= 0 {
+		return 0
+	}
+
+	n := len(s.Samples)
+	if len(s.Counts) < n {
+		n = len(s.Counts)
+	}
+	if window < s.Window {
+		if w := int(window / s.Granularity); w < n {
+			n = w
+		}
+	}
+
+	return n
+}
+
+// Total returns the sum of all values over the specified window, as well as
+// the number of samples.
+func (s Snapshot) Total(window time.Duration) (float64, int64) {
+	var total float64
+	var count int64
+	for i, n := 0, s.buckets(window); i < n; i++ {
+		total += s.Samples[i]
+		count += s.Counts[i]
+	}
+
+	return total, count
+}
+
+// Average returns the unweighted mean of the specified window.
+func (s Snapshot) Average(window time.Duration) float64 {
+	total, count := s.Total(window)
+	if count == 0 {
+		return 0
+	}
+
+	return total / float64(count)
+}
+
+// Merge returns a snapshot that combines the buckets of s and o, for instance
+// to aggregate the windows of multiple processes. Both snapshots need to have
+// the same configuration. Their buckets are aligned by the start of their
+// current buckets, and the merged snapshot's current bucket is the newest one.
+// An error is returned if either snapshot has an invalid number of buckets.
+func (s Snapshot) Merge(o Snapshot) (Snapshot, error) {
+	if !s.sameConfiguration(o) {
+		return Snapshot{}, errors.New("snapshots have a different configuration")
+	}
+	if err := s.checkBuckets(int(s.Window / s.Granularity)); err != nil {
+		return Snapshot{}, err
+	}
+	if err := o.checkBuckets(int(o.Window / o.Granularity)); err != nil {
+		return Snapshot{}, err
+	}
+
+	newest, other := s, o
+	if o.Start.After(s.Start) {
+		newest, other = o, s
+	}
+
+	shift := int((newest.Start.Sub(other.Start) + s.Granularity/2) / s.Granularity)
+	n := len(newest.Samples)
+	if l := shift + len(other.Samples); l > n {
+		n = l
+	}
+	if max := int(s.Window / s.Granularity); n > max {
+		n = max
+	}
+
+	merged := Snapshot{
+		Window:      s.Window,
+		Granularity: s.Granularity,
+		Time:        newest.Time,
+		Start:       newest.Start,
+		Samples:     make([]float64, n),
+		Counts:      make([]int64, n),
+	}
+	if other.Time.After(merged.Time) {
+		merged.Time = other.Time
+	}
+
+	copy(merged.Samples, newest.Samples)
+	copy(merged.Counts, newest.Counts)
+	for i := range other.Samples {
+		if i+shift >= n {
+			break
+		}
+
+		merged.Samples[i+shift] += other.Samples[i]
+		merged.Counts[i+shift] += other.Counts[i]
+	}
+
+	return merged, nil
+}
+
+// sameConfiguration returns true if s and o have the same valid configuration.
+func (s Snapshot) sameConfiguration(o Snapshot) bool {
+	return s.Window == o.Window && s.Granularity == o.Granularity && s.Granularity > 0
+}
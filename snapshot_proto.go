@@ -0,0 +1,221 @@
+package average
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+// Protobuf wire types and the field numbers of snapshot.proto.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+
+	fieldWindow      = 1
+	fieldGranularity = 2
+	fieldTime        = 3
+	fieldStart       = 4
+	fieldSamples     = 5
+	fieldCounts      = 6
+)
+
+var errMalformedProto = errors.New("malformed snapshot message")
+
+// MarshalProto returns the encoding of this snapshot as the Snapshot message
+// of snapshot.proto.
+func (s Snapshot) MarshalProto() []byte {
+	var b []byte
+	b = appendVarintField(b, fieldWindow, uint64(s.Window))
+	b = appendVarintField(b, fieldGranularity, uint64(s.Granularity))
+	b = appendVarintField(b, fieldTime, uint64(unixNano(s.Time)))
+	b = appendVarintField(b, fieldStart, uint64(unixNano(s.Start)))
+
+	if len(s.Samples) > 0 {
+		b = appendVarint(b, fieldSamples<<3|wireBytes)
+		b = appendVarint(b, uint64(8*len(s.Samples)))
+		for _, v := range s.Samples {
+			b = appendFixed64(b, math.Float64bits(v))
+		}
+	}
+
+	if len(s.Counts) > 0 {
+		var packed []byte
+		for _, c := range s.Counts {
+			packed = appendVarint(packed, uint64(c))
+		}
+
+		b = appendVarint(b, fieldCounts<<3|wireBytes)
+		b = appendVarint(b, uint64(len(packed)))
+		b = append(b, packed...)
+	}
+
+	return b
+}
+
+// UnmarshalProto decodes a Snapshot message of snapshot.proto into this
+// snapshot. Both packed and unpacked repeated fields are accepted, and unknown
+// fields are skipped.
+func (s *Snapshot) UnmarshalProto(b []byte) error {
+	*s = Snapshot{}
+	var t, start int64
+
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errMalformedProto
+		}
+		b = b[n:]
+
+		field, wire := key>>3, key&7
+		switch {
+		case wire == wireVarint && field <= fieldStart:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return errMalformedProto
+			}
+			b = b[n:]
+
+			switch field {
+			case fieldWindow:
+				s.Window = time.Duration(v)
+			case fieldGranularity:
+				s.Granularity = time.Duration(v)
+			case fieldTime:
+				t = int64(v)
+			case fieldStart:
+				start = int64(v)
+			}
+
+		case field == fieldSamples && wire == wireFixed64:
+			if len(b) < 8 {
+				return errMalformedProto
+			}
+			s.Samples = append(s.Samples, math.Float64frombits(binary.LittleEndian.Uint64(b)))
+			b = b[8:]
+
+		case field == fieldCounts && wire == wireVarint:
+			v, n := binary.Uvarint(b)
+			if n <= 0 {
+				return errMalformedProto
+			}
+			s.Counts = append(s.Counts, int64(v))
+			b = b[n:]
+
+		case (field == fieldSamples || field == fieldCounts) && wire == wireBytes:
+			data, rest, err := readBytes(b)
+			if err != nil {
+				return err
+			}
+			b = rest
+
+			if field == fieldSamples {
+				if len(data)%8 != 0 {
+					return errMalformedProto
+				}
+				for ; len(data) > 0; data = data[8:] {
+					s.Samples = append(s.Samples, math.Float64frombits(binary.LittleEndian.Uint64(data)))
+				}
+				continue
+			}
+
+			for len(data) > 0 {
+				v, n := binary.Uvarint(data)
+				if n <= 0 {
+					return errMalformedProto
+				}
+				s.Counts = append(s.Counts, int64(v))
+				data = data[n:]
+			}
+
+		default:
+			rest, err := skipField(b, wire)
+			if err != nil {
+				return err
+			}
+			b = rest
+		}
+	}
+
+	if len(s.Samples) != len(s.Counts) {
+		return errors.New("snapshot has an invalid number of buckets")
+	}
+
+	s.Time = fromUnixNano(t)
+	s.Start = fromUnixNano(start)
+	return nil
+}
+
+// unixNano returns t in nanoseconds since the Unix epoch. The zero time is
+// outside of the range of UnixNano, so it is encoded as 0.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano()
+}
+
+// fromUnixNano returns the time of ns nanoseconds since the Unix epoch, and
+// the zero time for 0, like unixNano encodes it.
+func fromUnixNano(ns int64) time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, ns)
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+
+	b = appendVarint(b, uint64(field<<3|wireVarint))
+	return appendVarint(b, v)
+}
+
+func appendFixed64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func readBytes(b []byte) (data, rest []byte, err error) {
+	l, n := binary.Uvarint(b)
+	if n <= 0 || uint64(len(b)-n) < l {
+		return nil, nil, errMalformedProto
+	}
+
+	return b[n : n+int(l)], b[n+int(l):], nil
+}
+
+func skipField(b []byte, wire uint64) ([]byte, error) {
+	switch wire {
+	case wireVarint:
+		if _, n := binary.Uvarint(b); n > 0 {
+			return b[n:], nil
+		}
+	case wireFixed64:
+		if len(b) >= 8 {
+			return b[8:], nil
+		}
+	case wireBytes:
+		_, rest, err := readBytes(b)
+		return rest, err
+	case wireFixed32:
+		if len(b) >= 4 {
+			return b[4:], nil
+		}
+	}
+
+	return nil, errMalformedProto
+}
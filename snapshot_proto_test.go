@@ -0,0 +1,80 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotProto(t *testing.T) {
+	s := Snapshot{
+		Window:      time.Minute,
+		Granularity: time.Second,
+		Time:        time.Unix(1500000000, 500),
+		Start:       time.Unix(1500000000, 0),
+		Samples:     []float64{1.5, 0, -3},
+		Counts:      []int64{2, 0, 1},
+	}
+
+	var decoded Snapshot
+	assert.NoError(t, decoded.UnmarshalProto(s.MarshalProto()))
+	assert.Equal(t, s.Window, decoded.Window)
+	assert.Equal(t, s.Granularity, decoded.Granularity)
+	assert.True(t, s.Time.Equal(decoded.Time))
+	assert.True(t, s.Start.Equal(decoded.Start))
+	assert.Equal(t, s.Samples, decoded.Samples)
+	assert.Equal(t, s.Counts, decoded.Counts)
+}
+
+func TestSnapshotProtoZeroTime(t *testing.T) {
+	s := Snapshot{
+		Window:      time.Minute,
+		Granularity: time.Second,
+		Samples:     []float64{1},
+		Counts:      []int64{1},
+	}
+
+	var decoded Snapshot
+	assert.NoError(t, decoded.UnmarshalProto(s.MarshalProto()))
+	assert.True(t, decoded.Time.IsZero())
+	assert.True(t, decoded.Start.IsZero())
+}
+
+func TestSnapshotProtoWireFormat(t *testing.T) {
+	s := Snapshot{
+		Window:      150,
+		Granularity: 1,
+		Time:        time.Unix(0, 0),
+		Start:       time.Unix(0, 0),
+		Samples:     []float64{1},
+		Counts:      []int64{3},
+	}
+
+	assert.Equal(t, []byte{
+		0x08, 0x96, 0x01, // window = 150
+		0x10, 0x01, // granularity = 1
+		0x2a, 0x08, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f, // samples = [1.0]
+		0x32, 0x01, 0x03, // counts = [3]
+	}, s.MarshalProto())
+}
+
+func TestSnapshotProtoUnpacked(t *testing.T) {
+	b := []byte{
+		0x29, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f, // samples = 1.0, unpacked
+		0x30, 0x03, // counts = 3, unpacked
+		0x3a, 0x02, 'h', 'i', // unknown field 7
+	}
+
+	var s Snapshot
+	assert.NoError(t, s.UnmarshalProto(b))
+	assert.Equal(t, []float64{1}, s.Samples)
+	assert.Equal(t, []int64{3}, s.Counts)
+}
+
+func TestSnapshotProtoMalformed(t *testing.T) {
+	var s Snapshot
+	assert.Equal(t, errMalformedProto, s.UnmarshalProto([]byte{0x2a, 0x09, 1}))
+	assert.Equal(t, errMalformedProto, s.UnmarshalProto([]byte{0x08}))
+	assert.EqualError(t, s.UnmarshalProto([]byte{0x30, 0x03}), "snapshot has an invalid number of buckets")
+}
@@ -0,0 +1,153 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	sw := &SlidingWindow{
+		window:      5 * time.Second,
+		granularity: time.Second,
+		samples:     []float64{1, 2, 3, 4, 5},
+		counts:      []int64{1, 1, 2, 2, 3},
+		pos:         1,
+		size:        5,
+	}
+
+	s := sw.Snapshot()
+	assert.Equal(t, 5*time.Second, s.Window)
+	assert.Equal(t, time.Second, s.Granularity)
+	assert.Equal(t, []float64{2, 1, 5, 4, 3}, s.Samples)
+	assert.Equal(t, []int64{1, 1, 3, 2, 2}, s.Counts)
+
+	total, count := s.Total(2 * time.Second)
+	assert.Equal(t, 3.0, total)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, 15.0/9.0, s.Average(time.Minute))
+	assert.Equal(t, 0.0, Snapshot{}.Average(time.Minute))
+}
+
+func TestRestore(t *testing.T) {
+	sw := MustNew(5*time.Second, time.Second)
+	defer sw.Stop()
+
+	s := Snapshot{
+		Window:      5 * time.Second,
+		Granularity: time.Second,
+		Start:       time.Now().Add(-2500 * time.Millisecond),
+		Samples:     []float64{1, 2, 3, 4, 5},
+		Counts:      []int64{1, 1, 1, 1, 1},
+	}
+
+	sw.Lock()
+	assert.NoError(t, sw.restore(s))
+	sw.Unlock()
+
+	// The snapshot's buckets are 2 buckets old by now.
+	total, count := sw.Total(2 * time.Second)
+	assert.Equal(t, 0.0, total)
+	assert.Equal(t, int64(0), count)
+
+	total, count = sw.Total(5 * time.Second)
+	assert.Equal(t, 6.0, total)
+	assert.Equal(t, int64(3), count)
+
+	s.Window = time.Minute
+	sw.Lock()
+	assert.EqualError(t, sw.restore(s), "snapshot configuration does not match the window")
+	sw.Unlock()
+}
+
+func TestRestoreEmpty(t *testing.T) {
+	sw := MustNew(5*time.Second, time.Second, WithManualClock())
+	defer sw.Stop()
+
+	sw.Lock()
+	assert.NoError(t, sw.restore(Snapshot{Window: 5 * time.Second, Granularity: time.Second, Start: sw.start}))
+	assert.Equal(t, 1, sw.size)
+	sw.Unlock()
+
+	sw.Add(2)
+	total, count := sw.Total(5 * time.Second)
+	assert.Equal(t, 2.0, total)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestSnapshotTotalMissingCounts(t *testing.T) {
+	s := Snapshot{
+		Window:      3 * time.Second,
+		Granularity: time.Second,
+		Samples:     []float64{1, 2, 3},
+		Counts:      []int64{1},
+	}
+
+	// Buckets without a count are left out rather than read past the end.
+	total, count := s.Total(3 * time.Second)
+	assert.Equal(t, 1.0, total)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, 1.0, s.Average(3*time.Second))
+}
+
+func TestSnapshotMerge(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	a := Snapshot{
+		Window:      3 * time.Second,
+		Granularity: time.Second,
+		Start:       start,
+		Samples:     []float64{1, 2, 3},
+		Counts:      []int64{1, 1, 1},
+	}
+	b := Snapshot{
+		Window:      3 * time.Second,
+		Granularity: time.Second,
+		Start:       start.Add(1100 * time.Millisecond),
+		Samples:     []float64{10, 20},
+		Counts:      []int64{2, 2},
+	}
+
+	merged, err := a.Merge(b)
+	assert.NoError(t, err)
+	assert.Equal(t, b.Start, merged.Start)
+	assert.Equal(t, []float64{10, 21, 2}, merged.Samples)
+	assert.Equal(t, []int64{2, 3, 1}, merged.Counts)
+
+	b.Granularity = 500 * time.Millisecond
+	_, err = a.Merge(b)
+	assert.EqualError(t, err, "snapshots have a different configuration")
+}
+
+func TestSnapshotMergeInvalidBuckets(t *testing.T) {
+	a := Snapshot{Window: 3 * time.Second, Granularity: time.Second, Samples: []float64{1, 2}, Counts: []int64{1, 1}}
+	b := Snapshot{Window: 3 * time.Second, Granularity: time.Second, Samples: []float64{1, 2}, Counts: []int64{1}}
+
+	_, err := a.Merge(b)
+	assert.EqualError(t, err, "snapshot has an invalid number of buckets")
+
+	_, err = b.Merge(a)
+	assert.EqualError(t, err, "snapshot has an invalid number of buckets")
+
+	b.Samples = make([]float64, 4)
+	b.Counts = make([]int64, 4)
+	_, err = a.Merge(b)
+	assert.EqualError(t, err, "snapshot has an invalid number of buckets")
+}
+
+func TestStopAndSnapshot(t *testing.T) {
+	sw := MustNew(time.Second, 10*time.Millisecond)
+	sw.Add(3)
+
+	s := sw.StopAndSnapshot()
+	total, count := s.Total(time.Second)
+	assert.Equal(t, 3.0, total)
+	assert.Equal(t, int64(1), count)
+
+	// The window no longer rotates, so its data stays put.
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, s.Samples, sw.Snapshot().Samples)
+
+	// Stopping again is harmless.
+	assert.Equal(t, s.Counts, sw.StopAndSnapshot().Counts)
+}
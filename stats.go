@@ -0,0 +1,32 @@
+package average
+
+import "time"
+
+// Stats holds the statistics of a window that Stats computes.
+type Stats struct {
+	Total   float64
+	Count   int64
+	Average float64 // The mean of the samples, like Average returns.
+	Rate    float64 // The total per second, like Rate returns.
+	Min     float64
+	Max     float64
+}
+
+// Stats returns the total, count, average, rate and extrema of the specified
+// window at once. Calling the individual methods acquires the lock for every
+// one of them, and the buckets may rotate in between, so their results may not
+// agree with each other. Stats computes all of them from the same state. Min
+// and Max are 0 unless the window was created WithExtrema.
+func (sw *SlidingWindow) Stats(window time.Duration) Stats {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	var s Stats
+	var n int
+	s.Total, s.Count, n = sw.total(window)
+	s.Average = sw.average(window)
+	s.Rate = sw.rate(s.Total, n)
+	s.Min, s.Max = sw.extremes(window)
+
+	return s
+}
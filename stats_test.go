@@ -0,0 +1,44 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	sw := MustNewReplay(4*time.Second, time.Second, start, WithExtrema())
+	defer sw.Stop()
+
+	assert.Equal(t, Stats{}, sw.Stats(4*time.Second))
+
+	sw.AddAt(start, 2)
+	sw.AddAt(start, 6)
+	sw.AddAt(start.Add(1500*time.Millisecond), -2)
+
+	// The buckets cover 1.5 seconds.
+	assert.Equal(t, Stats{
+		Total:   6,
+		Count:   3,
+		Average: 2,
+		Rate:    4,
+		Min:     -2,
+		Max:     6,
+	}, sw.Stats(4*time.Second))
+
+	s := sw.Stats(time.Second)
+	assert.Equal(t, sw.Rate(time.Second), s.Rate)
+	assert.Equal(t, sw.Average(time.Second), s.Average)
+	assert.Equal(t, -2.0, s.Max)
+}
+
+func TestStatsWithoutExtrema(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	sw := MustNewReplay(4*time.Second, time.Second, start)
+	defer sw.Stop()
+
+	sw.AddAt(start.Add(500*time.Millisecond), 3)
+	assert.Equal(t, Stats{Total: 3, Count: 1, Average: 3, Rate: 6}, sw.Stats(4*time.Second))
+}
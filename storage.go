@@ -0,0 +1,122 @@
+package average
+
+import "math"
+
+// WithCompactStorage stores the value of every bucket as a float32 and its
+// sample count as a uint32, which halves the memory use of a window.
+//
+// A float32 has a precision of about 7 significant digits: integer totals are
+// exact up to 16777216, and adding a small value to a large total may not
+// change it at all. Totals beyond the range of a float32 become infinite.
+// Sample counts saturate at 4294967295 per bucket instead of wrapping around.
+// Queries still return float64 and int64 values. Optional per-bucket state,
+// like the extrema and reservoirs, is not affected.
+func WithCompactStorage() Option {
+	return func(sw *SlidingWindow) error {
+		n := sw.len()
+		if sw.counts != nil {
+			sw.counts32 = make([]uint32, n)
+		}
+
+		sw.samples32 = make([]float32, n)
+		sw.samples, sw.counts = nil, nil
+		return nil
+	}
+}
+
+// len returns the number of buckets of this window.
+func (sw *SlidingWindow) len() int {
+	if sw.samples32 != nil {
+		return len(sw.samples32)
+	}
+
+	return len(sw.samples)
+}
+
+// hasCounts returns true if this window keeps track of the number of samples
+// per bucket.
+func (sw *SlidingWindow) hasCounts() bool {
+	return sw.counts != nil || sw.counts32 != nil
+}
+
+// sum returns the value of the bucket at the specified position. It must be
+// called with the lock held.
+func (sw *SlidingWindow) sum(pos int) float64 {
+	if sw.samples32 != nil {
+		return float64(sw.samples32[pos])
+	}
+
+	return sw.samples[pos]
+}
+
+// count returns the sample count of the bucket at the specified position, which
+// is always 0 for windows without sample counts. It must be called with the
+// lock held.
+func (sw *SlidingWindow) count(pos int) int64 {
+	switch {
+	case sw.counts != nil:
+		return sw.counts[pos]
+	case sw.counts32 != nil:
+		return int64(sw.counts32[pos])
+	}
+
+	return 0
+}
+
+// increment adds v to the value and n to the sample count of the bucket at
+// the specified position. It must be called with the lock held.
+func (sw *SlidingWindow) increment(pos int, v float64, n int64) {
+	if sw.samples32 != nil {
+		sw.samples32[pos] += float32(v)
+	} else {
+		sw.samples[pos] += v
+	}
+
+	switch {
+	case sw.counts != nil:
+		sw.counts[pos] += n
+	case sw.counts32 != nil:
+		sw.counts32[pos] = saturateUint32(int64(sw.counts32[pos]) + n)
+	}
+	if sw.written != nil {
+		sw.written[pos] = true
+	}
+	if sw.current != nil {
+		sw.storeCurrent(pos)
+	}
+}
+
+// set replaces the value and sample count of the bucket at the specified
+// position. It must be called with the lock held.
+func (sw *SlidingWindow) set(pos int, v float64, n int64) {
+	if sw.samples32 != nil {
+		sw.samples32[pos] = float32(v)
+	} else {
+		sw.samples[pos] = v
+	}
+
+	switch {
+	case sw.counts != nil:
+		sw.counts[pos] = n
+	case sw.counts32 != nil:
+		sw.counts32[pos] = saturateUint32(n)
+	}
+	if sw.written != nil && (v != 0 || n != 0) {
+		sw.written[pos] = true
+	}
+	if sw.current != nil {
+		sw.storeCurrent(pos)
+	}
+}
+
+// saturateUint32 returns n clamped to the range of a uint32.
+func saturateUint32(n int64) uint32 {
+	switch {
+	case n < 0:
+		return 0
+	case n > math.MaxUint32:
+		return math.MaxUint32
+	}
+
+	return uint32(n)
+}
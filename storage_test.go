@@ -0,0 +1,69 @@
+package average
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCompactStorage(t *testing.T) {
+	sw := MustNew(3*time.Second, time.Second, WithCompactStorage(), WithExtrema())
+	defer sw.Stop()
+
+	assert.Nil(t, sw.samples)
+	assert.Nil(t, sw.counts)
+	assert.Len(t, sw.samples32, 3)
+	assert.Len(t, sw.counts32, 3)
+
+	sw.Add(1.5)
+	sw.AddN(10, 4)
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+	sw.Add(2)
+
+	total, count := sw.Total(3 * time.Second)
+	assert.Equal(t, 13.5, total)
+	assert.Equal(t, int64(6), count)
+	assert.Equal(t, 2.25, sw.Average(3*time.Second))
+	assert.Equal(t, 2.5, sw.Max(3*time.Second))
+
+	s := sw.Snapshot()
+	assert.Equal(t, []float64{2, 11.5}, s.Samples)
+	assert.Equal(t, []int64{1, 5}, s.Counts)
+
+	sw.Reset()
+	total, count = sw.Total(3 * time.Second)
+	assert.Equal(t, 0.0, total)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestWithCompactStoragePrecision(t *testing.T) {
+	sw := MustNew(3*time.Second, time.Second, WithCompactStorage())
+	defer sw.Stop()
+
+	sw.Add(16777216)
+	sw.Add(1)
+
+	total, _ := sw.Total(time.Second)
+	assert.Equal(t, 16777216.0, total)
+}
+
+func TestWithCompactStorageWithoutCounts(t *testing.T) {
+	sw := MustNew(3*time.Second, time.Second, WithoutCounts(), WithCompactStorage())
+	defer sw.Stop()
+
+	assert.Nil(t, sw.counts32)
+	assert.False(t, sw.hasCounts())
+
+	sw.Add(3)
+	assert.Equal(t, 3.0, sw.Average(time.Second))
+}
+
+func TestSaturateUint32(t *testing.T) {
+	assert.Equal(t, uint32(0), saturateUint32(-1))
+	assert.Equal(t, uint32(7), saturateUint32(7))
+	assert.Equal(t, uint32(math.MaxUint32), saturateUint32(math.MaxUint32+1))
+}
@@ -0,0 +1,104 @@
+package average
+
+import "time"
+
+// BucketResult is the data of a completed bucket.
+type BucketResult struct {
+	Sum   float64
+	Count int64
+	Start time.Time
+	End   time.Time
+}
+
+// DropPolicy determines what happens to a completed bucket when the buffer of a
+// subscription is full.
+type DropPolicy int
+
+const (
+	// DropNewest discards the bucket that was just completed.
+	DropNewest DropPolicy = iota
+	// DropOldest discards the oldest buffered bucket to make room for the one
+	// that was just completed.
+	DropOldest
+)
+
+type subscriber struct {
+	c      chan BucketResult
+	policy DropPolicy
+}
+
+// Subscribe returns a channel that receives every bucket when it stops being
+// the current bucket. The channel buffers up to size buckets, with a minimum of
+// 1, and applies policy when a bucket is completed while the buffer is full.
+// The shifter never blocks on a subscriber. The channel is closed when the
+// window is stopped or when it is passed to Unsubscribe.
+func (sw *SlidingWindow) Subscribe(size int, policy DropPolicy) <-chan BucketResult {
+	if size < 1 {
+		size = 1
+	}
+
+	c := make(chan BucketResult, size)
+
+	sw.Lock()
+	defer sw.Unlock()
+
+	if sw.stopped {
+		close(c)
+		return c
+	}
+
+	sw.subscribers = append(sw.subscribers, subscriber{c: c, policy: policy})
+	return c
+}
+
+// Unsubscribe stops the delivery of completed buckets to a channel returned by
+// Subscribe, and closes it.
+func (sw *SlidingWindow) Unsubscribe(c <-chan BucketResult) {
+	sw.Lock()
+	defer sw.Unlock()
+
+	for i, sub := range sw.subscribers {
+		if sub.c == c {
+			close(sub.c)
+			sw.subscribers = append(sw.subscribers[:i], sw.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// publish delivers a completed bucket to all subscribers. It must be called
+// with the lock held.
+func (sw *SlidingWindow) publish(result BucketResult) {
+	for _, sub := range sw.subscribers {
+		select {
+		case sub.c <- result:
+			continue
+		default:
+		}
+
+		if sub.policy != DropOldest {
+			continue
+		}
+
+		// Make room by discarding the oldest bucket, unless the subscriber
+		// has caught up in the meantime.
+		select {
+		case <-sub.c:
+		default:
+		}
+
+		select {
+		case sub.c <- result:
+		default:
+		}
+	}
+}
+
+// closeSubscribers closes and removes all subscriptions. It must be called with
+// the lock held.
+func (sw *SlidingWindow) closeSubscribers() {
+	for _, sub := range sw.subscribers {
+		close(sub.c)
+	}
+	sw.subscribers = nil
+}
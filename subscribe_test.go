@@ -0,0 +1,67 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribe(t *testing.T) {
+	sw := MustNew(3*time.Second, time.Second)
+	defer sw.Stop()
+
+	c := sw.Subscribe(2, DropNewest)
+	start := sw.start
+
+	sw.Add(3)
+	sw.Add(4)
+
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+
+	assert.Equal(t, BucketResult{
+		Sum:   7,
+		Count: 2,
+		Start: start,
+		End:   start.Add(time.Second),
+	}, <-c)
+
+	sw.Unsubscribe(c)
+	_, ok := <-c
+	assert.False(t, ok)
+}
+
+func TestSubscribeDropPolicy(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+	defer sw.Stop()
+
+	newest := sw.Subscribe(2, DropNewest)
+	oldest := sw.Subscribe(2, DropOldest)
+
+	sw.Lock()
+	for i := 1; i <= 4; i++ {
+		sw.add(float64(i), 1)
+		sw.shift()
+	}
+	sw.Unlock()
+
+	assert.Equal(t, 1.0, (<-newest).Sum)
+	assert.Equal(t, 2.0, (<-newest).Sum)
+	assert.Equal(t, 3.0, (<-oldest).Sum)
+	assert.Equal(t, 4.0, (<-oldest).Sum)
+}
+
+func TestSubscribeStop(t *testing.T) {
+	sw := MustNew(10*time.Second, time.Second)
+
+	c := sw.Subscribe(1, DropNewest)
+	sw.Stop()
+
+	_, ok := <-c
+	assert.False(t, ok)
+
+	_, ok = <-sw.Subscribe(1, DropNewest)
+	assert.False(t, ok)
+}
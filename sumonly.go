@@ -0,0 +1,13 @@
+package average
+
+// WithoutCounts doesn't keep track of the number of samples per bucket, which
+// halves the memory use of windows that only need totals. Total then always
+// reports 0 samples, and Average returns the mean of the buckets in the window
+// rather than the mean of the samples. It cannot be combined with WithExtrema
+// or WithReservoir.
+func WithoutCounts() Option {
+	return func(sw *SlidingWindow) error {
+		sw.counts, sw.counts32 = nil, nil
+		return nil
+	}
+}
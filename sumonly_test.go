@@ -0,0 +1,49 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithoutCounts(t *testing.T) {
+	_, err := New(10*time.Second, time.Second, WithoutCounts(), WithExtrema())
+	assert.EqualError(t, err, "extrema and reservoirs require sample counts")
+
+	_, err = New(10*time.Second, time.Second, WithReservoir(10), WithoutCounts())
+	assert.EqualError(t, err, "extrema and reservoirs require sample counts")
+
+	sw := MustNew(4*time.Second, time.Second, WithoutCounts())
+	defer sw.Stop()
+
+	assert.Nil(t, sw.counts)
+
+	sw.Add(4)
+	sw.AddN(2, 10)
+	sw.Lock()
+	sw.shift()
+	sw.Unlock()
+	sw.Add(3)
+
+	total, count := sw.Total(4 * time.Second)
+	assert.Equal(t, 9.0, total)
+	assert.Equal(t, int64(0), count)
+
+	// The average is over the buckets in use instead of samples.
+	assert.Equal(t, 3.0, sw.Average(time.Second))
+	assert.Equal(t, 4.5, sw.Average(2*time.Second))
+	assert.Equal(t, 4.5, sw.Average(4*time.Second))
+
+	sw.Lock()
+	sw.shift()
+	sw.shift()
+	sw.Unlock()
+	assert.Equal(t, 2.25, sw.Average(4*time.Second))
+
+	s := sw.Snapshot()
+	assert.Equal(t, []int64{0, 0, 0, 0}, s.Counts)
+
+	sw.Reset()
+	assert.Equal(t, 0.0, sw.Average(4*time.Second))
+}
@@ -0,0 +1,153 @@
+package average
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MarshalText encodes a snapshot of this window as a single line of text, so
+// it can be stored in places that only hold strings, like environment-style
+// configuration, flat files or etcd values. The format looks like
+// "window=1m0s;gran=1s;start=2006-01-02T15:04:05Z;time=2006-01-02T15:04:05.5Z;buckets=3:2,0:0,1.5:1",
+// where every bucket, newest first, is encoded as its value and sample count.
+// Like a Snapshot, it does not include optional per-bucket state, such as the
+// extrema or reservoirs.
+func (sw *SlidingWindow) MarshalText() ([]byte, error) {
+	s := sw.Snapshot()
+
+	b := make([]byte, 0, 96+8*len(s.Samples))
+	b = append(b, "window="...)
+	b = append(b, s.Window.String()...)
+	b = append(b, ";gran="...)
+	b = append(b, s.Granularity.String()...)
+	b = append(b, ";start="...)
+	b = s.Start.AppendFormat(b, time.RFC3339Nano)
+	b = append(b, ";time="...)
+	b = s.Time.AppendFormat(b, time.RFC3339Nano)
+	b = append(b, ";buckets="...)
+	for i := range s.Samples {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = strconv.AppendFloat(b, s.Samples[i], 'g', -1, 64)
+		b = append(b, ':')
+		b = strconv.AppendInt(b, s.Counts[i], 10)
+	}
+
+	return b, nil
+}
+
+// UnmarshalText decodes a window that was encoded with MarshalText. Like
+// GobDecode, it configures and starts a zero SlidingWindow, which then has to
+// be stopped like a window returned by New, and it replaces the buckets of
+// other windows, which need to have the same configuration as the encoded one.
+func (sw *SlidingWindow) UnmarshalText(text []byte) error {
+	s, err := parseSnapshot(string(text))
+	if err != nil {
+		return err
+	}
+
+	return sw.load(s)
+}
+
+// Fields of the text format, as bits of the set of fields that parseSnapshot
+// has seen.
+const (
+	textWindow = 1 << iota
+	textGran
+	textStart
+	textTime
+	textBuckets
+
+	textFields = textWindow | textGran | textStart | textTime | textBuckets
+)
+
+// parseSnapshot parses a snapshot in the format of MarshalText. Every field has
+// to be present exactly once.
+func parseSnapshot(text string) (Snapshot, error) {
+	var s Snapshot
+	var err error
+
+	seen := 0
+	for _, field := range strings.Split(text, ";") {
+		i := strings.IndexByte(field, '=')
+		if i < 0 {
+			return Snapshot{}, errors.New("invalid text field " + field)
+		}
+
+		key, value := field[:i], field[i+1:]
+
+		var bit int
+		switch key {
+		case "window":
+			bit = textWindow
+		case "gran":
+			bit = textGran
+		case "start":
+			bit = textStart
+		case "time":
+			bit = textTime
+		case "buckets":
+			bit = textBuckets
+		default:
+			return Snapshot{}, errors.New("unknown text field " + key)
+		}
+		if seen&bit != 0 {
+			return Snapshot{}, errors.New("duplicate text field " + key)
+		}
+		seen |= bit
+
+		switch bit {
+		case textWindow:
+			s.Window, err = time.ParseDuration(value)
+		case textGran:
+			s.Granularity, err = time.ParseDuration(value)
+		case textStart:
+			s.Start, err = time.Parse(time.RFC3339Nano, value)
+		case textTime:
+			s.Time, err = time.Parse(time.RFC3339Nano, value)
+		case textBuckets:
+			s.Samples, s.Counts, err = parseBuckets(value)
+		}
+		if err != nil {
+			return Snapshot{}, err
+		}
+	}
+
+	if seen != textFields {
+		return Snapshot{}, errors.New("text is missing fields")
+	}
+
+	return s, nil
+}
+
+// parseBuckets parses the buckets of a snapshot in the format of MarshalText.
+// An empty value is a snapshot without buckets.
+func parseBuckets(value string) ([]float64, []int64, error) {
+	if value == "" {
+		return nil, nil, nil
+	}
+
+	fields := strings.Split(value, ",")
+	samples := make([]float64, len(fields))
+	counts := make([]int64, len(fields))
+
+	for i, field := range fields {
+		j := strings.IndexByte(field, ':')
+		if j < 0 {
+			return nil, nil, errors.New("invalid text bucket " + field)
+		}
+
+		var err error
+		if samples[i], err = strconv.ParseFloat(field[:j], 64); err != nil {
+			return nil, nil, err
+		}
+		if counts[i], err = strconv.ParseInt(field[j+1:], 10, 64); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return samples, counts, nil
+}
@@ -0,0 +1,99 @@
+package average
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalText(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second, WithManualClock())
+	defer sw.Stop()
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	sw.Lock()
+	sw.start, sw.virtual = start, start.Add(1500*time.Millisecond)
+	sw.add(3, 2)
+	sw.shift()
+	sw.add(1.5, 1)
+	sw.Unlock()
+
+	text, err := sw.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "window=4s;gran=1s;start=2024-01-02T03:04:06Z;time=2024-01-02T03:04:06.5Z;buckets=1.5:1,3:2", string(text))
+
+	decoded := &SlidingWindow{}
+	assert.NoError(t, decoded.UnmarshalText(text))
+	defer decoded.Stop()
+
+	assert.Equal(t, 4*time.Second, decoded.window)
+	assert.Equal(t, time.Second, decoded.granularity)
+
+	// Time has passed since the snapshot, so its buckets have aged.
+	total, count := decoded.Total(4 * time.Second)
+	assert.Equal(t, 0.0, total)
+	assert.Equal(t, int64(0), count)
+
+	other := MustNew(4*time.Second, time.Second)
+	defer other.Stop()
+	other.Add(1)
+
+	text, err = other.MarshalText()
+	assert.NoError(t, err)
+
+	into := MustNew(4*time.Second, time.Second)
+	defer into.Stop()
+	assert.NoError(t, into.UnmarshalText(text))
+
+	total, count = into.Total(4 * time.Second)
+	assert.Equal(t, 1.0, total)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestMarshalTextEmpty(t *testing.T) {
+	var decoded SlidingWindow
+	assert.NoError(t, decoded.UnmarshalText([]byte("window=4s;gran=1s;start=2024-01-02T03:04:06Z;time=2024-01-02T03:04:06Z;buckets=")))
+	defer decoded.Stop()
+
+	total, count := decoded.Total(4 * time.Second)
+	assert.Equal(t, 0.0, total)
+	assert.Equal(t, int64(0), count)
+
+	// An empty window still encodes its current bucket.
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	text, err := sw.MarshalText()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(string(text), ";buckets=0:0"))
+
+	into := MustNew(4*time.Second, time.Second)
+	defer into.Stop()
+	assert.NoError(t, into.UnmarshalText(text))
+
+	total, count = into.Total(4 * time.Second)
+	assert.Equal(t, 0.0, total)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestUnmarshalTextErrors(t *testing.T) {
+	sw := MustNew(4*time.Second, time.Second)
+	defer sw.Stop()
+
+	assert.EqualError(t, sw.UnmarshalText([]byte("window")), "invalid text field window")
+	assert.EqualError(t, sw.UnmarshalText([]byte("size=4s")), "unknown text field size")
+	assert.EqualError(t, sw.UnmarshalText([]byte("window=4s;gran=1s")), "text is missing fields")
+	assert.EqualError(t, sw.UnmarshalText([]byte("window=4s;window=4s;gran=1s;start=2024-01-02T03:04:06Z;time=2024-01-02T03:04:06Z")), "duplicate text field window")
+	assert.EqualError(t, sw.UnmarshalText([]byte("window=4s;gran=1s;start=2024-01-02T03:04:06Z;buckets=;buckets=")), "duplicate text field buckets")
+	assert.EqualError(t, sw.UnmarshalText([]byte("window=4s;gran=1s;start=2024-01-02T03:04:06Z;buckets=1:1")), "text is missing fields")
+	assert.EqualError(t, sw.UnmarshalText([]byte("buckets=1")), "invalid text bucket 1")
+	assert.EqualError(t, sw.UnmarshalText([]byte("window=8s;gran=1s;start=2024-01-02T03:04:06Z;time=2024-01-02T03:04:06Z;buckets=1:1")), "snapshot configuration does not match the window")
+
+	var zero SlidingWindow
+	assert.EqualError(t, zero.UnmarshalText([]byte("window=0s;gran=1s;start=2024-01-02T03:04:06Z;time=2024-01-02T03:04:06Z;buckets=1:1")), "window cannot be 0")
+	assert.EqualError(t, zero.UnmarshalText([]byte("window=2s;gran=1s;start=2024-01-02T03:04:06Z;time=2024-01-02T03:04:06Z;buckets=1:1,2:1,3:1")), "snapshot has an invalid number of buckets")
+	zero.Stop()
+	assert.True(t, zero.Stopped())
+}
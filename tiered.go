@@ -0,0 +1,140 @@
+package average
+
+import (
+	"errors"
+	"time"
+)
+
+// TieredWindow is a sliding time window whose granularity coarsens with age.
+// The most recent part of the window keeps fine-grained buckets, and every
+// bucket that ages out of it is merged into a coarser bucket. This bounds the
+// memory of very long windows, like 24 hours, while keeping the precision of
+// recent data: a 24h window with 1s buckets needs 86400 of them, but keeping
+// only the last hour at 1s and the rest at 1m needs 3600+1380.
+//
+// Both granularities and the length of the recent part are fixed when the
+// window is created. The window does not coarsen any further on its own, like
+// under memory pressure, so its memory is bounded by that configuration.
+type TieredWindow struct {
+	fine   *SlidingWindow
+	coarse *SlidingWindow // Lags behind fine by the length of fine.
+}
+
+// MustNewTieredWindow returns a new TieredWindow, but panics if an error
+// occurs.
+func MustNewTieredWindow(window, granularity, recent, coarse time.Duration) *TieredWindow {
+	tw, err := NewTieredWindow(window, granularity, recent, coarse)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return tw
+}
+
+// NewTieredWindow returns a new TieredWindow that keeps buckets of the
+// specified granularity for the most recent part of the window, and buckets of
+// the coarse granularity for the data that is older than recent.
+func NewTieredWindow(window, granularity, recent, coarse time.Duration) (*TieredWindow, error) {
+	if window <= recent {
+		return nil, errors.New("window has to be larger than the recent part of it")
+	}
+	if err := validate(recent, granularity); err != nil {
+		return nil, err
+	}
+	if coarse <= granularity || coarse%granularity != 0 {
+		return nil, errors.New("coarse granularity has to be a multiplier of the granularity")
+	}
+	if err := validate(window-recent, coarse); err != nil {
+		return nil, err
+	}
+
+	tw := &TieredWindow{}
+
+	fine, err := newSlidingWindow(recent, granularity)
+	if err != nil {
+		return nil, err
+	}
+	fine.onEvictLocked = tw.merge
+	fine.written = make([]bool, fine.len())
+
+	tw.coarse, err = NewReplay(window-recent, coarse, fine.start)
+	if err != nil {
+		return nil, err
+	}
+
+	tw.fine = fine
+	fine.startShifter()
+	return tw, nil
+}
+
+// merge adds a bucket that was evicted from the fine-grained part of the
+// window to the coarse bucket that contains it. It is called with the lock of
+// the fine part held, so the bucket is in one of both parts at any time, and
+// the coarse part can't be advanced past it by Total before it is merged.
+func (tw *TieredWindow) merge(b BucketResult) {
+	tw.coarse.Lock()
+	defer tw.coarse.Unlock()
+
+	tw.coarse.advance(b.Start)
+	tw.coarse.add(b.Sum, b.Count)
+}
+
+// Add increments the value of the current sample.
+func (tw *TieredWindow) Add(v float64) {
+	tw.fine.Add(v)
+}
+
+// AddN increments the value of the current sample by v and its sample count by
+// n in one operation.
+func (tw *TieredWindow) AddN(v float64, n int64) {
+	tw.fine.AddN(v, n)
+}
+
+// Average returns the unweighted mean of the samples in the specified window.
+func (tw *TieredWindow) Average(window time.Duration) float64 {
+	total, count := tw.Total(window)
+	if count == 0 {
+		return 0
+	}
+
+	return total / float64(count)
+}
+
+// Total returns the sum of all values over the specified window, as well as
+// the number of samples. The part of the window that is older than the recent
+// part is rounded to the coarse granularity.
+func (tw *TieredWindow) Total(window time.Duration) (float64, int64) {
+	// The lock of the fine part is held while the coarse part is read, so no
+	// bucket can be evicted from the one and merged into the other in between.
+	// Locks are always taken in this order, as merge is called with the lock
+	// of the fine part held as well.
+	tw.fine.RLock()
+	defer tw.fine.RUnlock()
+
+	total, count, _ := tw.fine.total(window)
+	if window <= tw.fine.window {
+		return total, count
+	}
+
+	// The coarse part only moves along with the buckets that are merged into
+	// it, so it has to catch up with the fine part if those were empty.
+	tw.coarse.Lock()
+	defer tw.coarse.Unlock()
+
+	tw.coarse.advance(tw.fine.start.Add(tw.fine.granularity - tw.fine.window))
+	older, n, _ := tw.coarse.total(window - tw.fine.window)
+	return total + older, count + n
+}
+
+// Debug returns the DebugStats of the fine-grained part of this tiered window,
+// which every value is added to.
+func (tw *TieredWindow) Debug() DebugStats {
+	return tw.fine.Debug()
+}
+
+// Stop the shifter of this tiered window. A stopped TieredWindow cannot be
+// started again.
+func (tw *TieredWindow) Stop() {
+	tw.fine.Stop()
+	tw.coarse.Stop()
+}
@@ -0,0 +1,147 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTieredWindow(t *testing.T) {
+	_, err := NewTieredWindow(4*time.Second, time.Second, 4*time.Second, 2*time.Second)
+	assert.EqualError(t, err, "window has to be larger than the recent part of it")
+
+	_, err = NewTieredWindow(10*time.Second, 0, 4*time.Second, 2*time.Second)
+	assert.EqualError(t, err, "granularity cannot be 0")
+
+	_, err = NewTieredWindow(10*time.Second, 2*time.Second, 4*time.Second, 3*time.Second)
+	assert.EqualError(t, err, "coarse granularity has to be a multiplier of the granularity")
+
+	_, err = NewTieredWindow(10*time.Second, time.Second, 5*time.Second, 2*time.Second)
+	assert.EqualError(t, err, "window size has to be a multiplier of the granularity size")
+}
+
+func TestTieredWindowTotal(t *testing.T) {
+	tw := MustNewTieredWindow(10*time.Second, time.Second, 4*time.Second, 2*time.Second)
+	defer tw.Stop()
+
+	shift := func(n int) {
+		for i := 0; i < n; i++ {
+			tw.fine.Lock()
+			tw.fine.shift()
+			tw.fine.unlockAndEvict()
+		}
+	}
+
+	tw.Add(1)
+	shift(3)
+	tw.AddN(2, 1)
+
+	// The first bucket ages out of the recent part into the coarse part.
+	shift(1)
+
+	total, count := tw.Total(4 * time.Second)
+	assert.Equal(t, 2.0, total)
+	assert.Equal(t, int64(1), count)
+
+	total, count = tw.Total(10 * time.Second)
+	assert.Equal(t, 3.0, total)
+	assert.Equal(t, int64(2), count)
+	assert.Equal(t, 1.5, tw.Average(10*time.Second))
+
+	// The coarse part catches up even though no data was merged into it.
+	shift(6)
+
+	total, count = tw.Total(10 * time.Second)
+	assert.Equal(t, 2.0, total)
+	assert.Equal(t, int64(1), count)
+
+	shift(4)
+
+	total, count = tw.Total(10 * time.Second)
+	assert.Equal(t, 0.0, total)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, 0.0, tw.Average(10*time.Second))
+}
+
+func TestTieredWindowMergeWithLock(t *testing.T) {
+	tw := MustNewTieredWindow(10*time.Second, time.Second, 4*time.Second, 2*time.Second)
+	defer tw.Stop()
+
+	tw.Add(1)
+	for i := 0; i < 4; i++ {
+		tw.fine.Lock()
+		tw.fine.shift()
+		if i < 3 {
+			tw.fine.unlockAndEvict()
+		}
+	}
+
+	// The evicted bucket is in the coarse part before the lock of the fine
+	// part is released, so it is never missing from both parts.
+	tw.coarse.RLock()
+	total, count, _ := tw.coarse.total(6 * time.Second)
+	tw.coarse.RUnlock()
+	tw.fine.unlockAndEvict()
+
+	assert.Equal(t, 1.0, total)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestTieredWindowTotalWhileMerging(t *testing.T) {
+	tw := MustNewTieredWindow(time.Hour, time.Second, 4*time.Second, 2*time.Second)
+	defer tw.Stop()
+
+	const shifts = 1000
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < shifts; i++ {
+			tw.fine.Lock()
+			tw.fine.add(1, 1)
+			tw.fine.shift()
+			tw.fine.unlockAndEvict()
+		}
+	}()
+
+	// A bucket that moves from the fine part to the coarse part while the
+	// window is read is counted exactly once, so the total never drops.
+	var last float64
+	for {
+		total, count := tw.Total(time.Hour)
+		assert.Equal(t, total, float64(count))
+		if !assert.True(t, total >= last) {
+			return
+		}
+		last = total
+
+		select {
+		case <-done:
+			total, count = tw.Total(time.Hour)
+			assert.Equal(t, float64(shifts), total)
+			assert.Equal(t, int64(shifts), count)
+			return
+		default:
+		}
+	}
+}
+
+func TestTieredWindowTotalWhileRotating(t *testing.T) {
+	tw := MustNewTieredWindow(time.Hour, time.Millisecond, 10*time.Millisecond, 10*time.Millisecond)
+	defer tw.Stop()
+
+	// The shifter moves the buckets into the coarse part while they are
+	// read, and nothing falls off the end of the window in the meantime.
+	var added int64
+	deadline := time.Now().Add(100 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		tw.Add(1)
+		added++
+
+		total, count := tw.Total(time.Hour)
+		if !assert.Equal(t, float64(added), total) || !assert.Equal(t, added, count) {
+			return
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package average
+
+import (
+	"container/heap"
+	"errors"
+	"sort"
+	"time"
+)
+
+const (
+	// sketchWidth and sketchDepth determine the dimensions of the count-min
+	// sketch of a TopKWindow bucket. With these dimensions, an estimate exceeds
+	// the real count by at most 0.27% of the bucket total with a probability of
+	// over 98%.
+	sketchWidth = 1024
+	sketchDepth = 4
+
+	// candidatesPerK is the number of candidate keys that every bucket tracks
+	// per requested key, so that keys which are frequent over the window but
+	// never top of a single bucket still have a chance to be reported.
+	candidatesPerK = 4
+)
+
+// KeyCount is a key with its (estimated) count.
+type KeyCount struct {
+	Key   string
+	Count int64
+}
+
+// countMinSketch estimates the frequency of keys in sub-linear space.
+type countMinSketch struct {
+	counts [sketchDepth][sketchWidth]int64
+}
+
+func (s *countMinSketch) slots(hash uint64) [sketchDepth]int {
+	var slots [sketchDepth]int
+	h1, h2 := uint32(hash), uint32(hash>>32)
+	for i := range slots {
+		slots[i] = int((h1 + uint32(i)*h2) % sketchWidth)
+	}
+
+	return slots
+}
+
+func (s *countMinSketch) add(hash uint64, n int64) {
+	for i, slot := range s.slots(hash) {
+		s.counts[i][slot] += n
+	}
+}
+
+func (s *countMinSketch) estimate(hash uint64) int64 {
+	var min int64 = -1
+	for i, slot := range s.slots(hash) {
+		if c := s.counts[i][slot]; min < 0 || c < min {
+			min = c
+		}
+	}
+
+	return min
+}
+
+func (s *countMinSketch) merge(other *countMinSketch) {
+	for i := range s.counts {
+		for j, c := range other.counts[i] {
+			s.counts[i][j] += c
+		}
+	}
+}
+
+// candidates is a min-heap of the keys with the highest estimates in a bucket.
+type candidates struct {
+	items []KeyCount
+	index map[string]int
+}
+
+func (c *candidates) Len() int           { return len(c.items) }
+func (c *candidates) Less(i, j int) bool { return c.items[i].Count < c.items[j].Count }
+
+func (c *candidates) Swap(i, j int) {
+	c.items[i], c.items[j] = c.items[j], c.items[i]
+	c.index[c.items[i].Key] = i
+	c.index[c.items[j].Key] = j
+}
+
+func (c *candidates) Push(x interface{}) {
+	item := x.(KeyCount)
+	c.index[item.Key] = len(c.items)
+	c.items = append(c.items, item)
+}
+
+func (c *candidates) Pop() interface{} {
+	item := c.items[len(c.items)-1]
+	c.items = c.items[:len(c.items)-1]
+	delete(c.index, item.Key)
+	return item
+}
+
+// topKBucket is the state of a single TopKWindow bucket.
+type topKBucket struct {
+	sketch     countMinSketch
+	candidates candidates
+}
+
+func (b *topKBucket) add(key string, hash uint64, n int64, capacity int) {
+	b.sketch.add(hash, n)
+	estimate := b.sketch.estimate(hash)
+
+	c := &b.candidates
+	switch i, ok := c.index[key]; {
+	case ok:
+		c.items[i].Count = estimate
+		heap.Fix(c, i)
+	case c.Len() < capacity:
+		heap.Push(c, KeyCount{Key: key, Count: estimate})
+	case estimate > c.items[0].Count:
+		delete(c.index, c.items[0].Key)
+		c.items[0] = KeyCount{Key: key, Count: estimate}
+		c.index[key] = 0
+		heap.Fix(c, 0)
+	}
+}
+
+func (b *topKBucket) reset() {
+	b.sketch = countMinSketch{}
+	b.candidates.items = b.candidates.items[:0]
+	for key := range b.candidates.index {
+		delete(b.candidates.index, key)
+	}
+}
+
+// TopKWindow is a sliding time window that tracks the most frequent keys, such
+// as the noisiest IP addresses over the last 10 minutes. Every bucket keeps a
+// count-min sketch along with a bounded set of candidate keys, so the memory
+// use is independent of the number of distinct keys while contributions of old
+// buckets age out automatically.
+type TopKWindow struct {
+	sw      *SlidingWindow
+	buckets []*topKBucket
+	k       int
+}
+
+// MustNewTopKWindow returns a new TopKWindow, but panics if an error occurs.
+func MustNewTopKWindow(window, granularity time.Duration, k int) *TopKWindow {
+	tw, err := NewTopKWindow(window, granularity, k)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return tw
+}
+
+// NewTopKWindow returns a new TopKWindow that reports up to k keys. Every
+// bucket that has been used allocates a sketch of 32KiB.
+func NewTopKWindow(window, granularity time.Duration, k int) (*TopKWindow, error) {
+	if k < 1 {
+		return nil, errors.New("k has to be at least 1")
+	}
+
+	sw, err := newSlidingWindow(window, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	tw := &TopKWindow{
+		sw:      sw,
+		buckets: make([]*topKBucket, sw.len()),
+		k:       k,
+	}
+	sw.onClear = tw.clear
+
+	sw.startShifter()
+	return tw, nil
+}
+
+func (tw *TopKWindow) clear(pos int) {
+	if b := tw.buckets[pos]; b != nil {
+		b.reset()
+	}
+}
+
+// Add increments the count of key by n in the current bucket.
+func (tw *TopKWindow) Add(key string, n int64) {
+	hash := hashString(key)
+
+	tw.sw.Lock()
+	defer tw.sw.Unlock()
+
+	if !tw.sw.countAdd() {
+		return
+	}
+
+	b := tw.buckets[tw.sw.pos]
+	if b == nil {
+		b = &topKBucket{candidates: candidates{index: make(map[string]int)}}
+		tw.buckets[tw.sw.pos] = b
+	}
+
+	b.add(key, hash, n, tw.k*candidatesPerK)
+	tw.sw.increment(tw.sw.pos, float64(n), 1)
+}
+
+// TopK returns up to k keys with the highest estimated counts over the
+// specified window, ordered from most to least frequent. Estimates never
+// undercount, but might overcount when many keys collide in the sketch.
+func (tw *TopKWindow) TopK(window time.Duration) []KeyCount {
+	tw.sw.RLock()
+	defer tw.sw.RUnlock()
+
+	var merged countMinSketch
+	keys := make(map[string]struct{})
+	for i, n := 0, tw.sw.buckets(window); i < n; i++ {
+		b := tw.buckets[tw.sw.index(i)]
+		if b == nil || b.candidates.Len() == 0 {
+			continue
+		}
+
+		merged.merge(&b.sketch)
+		for _, item := range b.candidates.items {
+			keys[item.Key] = struct{}{}
+		}
+	}
+
+	result := make([]KeyCount, 0, len(keys))
+	for key := range keys {
+		result = append(result, KeyCount{Key: key, Count: merged.estimate(hashString(key))})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+
+	if len(result) > tw.k {
+		result = result[:tw.k]
+	}
+
+	return result
+}
+
+// Debug returns the DebugStats of this top-k window.
+func (tw *TopKWindow) Debug() DebugStats {
+	return tw.sw.Debug()
+}
+
+// Stop the shifter of this top-k window. A stopped TopKWindow cannot be started
+// again.
+func (tw *TopKWindow) Stop() {
+	tw.sw.Stop()
+}
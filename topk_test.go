@@ -0,0 +1,65 @@
+package average
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTopKWindow(t *testing.T) {
+	_, err := NewTopKWindow(10*time.Second, time.Second, 0)
+	assert.EqualError(t, err, "k has to be at least 1")
+
+	_, err = NewTopKWindow(10*time.Second, 0, 3)
+	assert.EqualError(t, err, "granularity cannot be 0")
+}
+
+func TestTopKWindowTopK(t *testing.T) {
+	tw := MustNewTopKWindow(3*time.Second, time.Second, 2)
+	defer tw.Stop()
+
+	assert.Empty(t, tw.TopK(3*time.Second))
+
+	tw.Add("10.0.0.1", 50)
+	tw.Add("10.0.0.2", 20)
+	for i := 0; i < 100; i++ {
+		tw.Add("192.168.0."+strconv.Itoa(i), 1)
+	}
+
+	tw.sw.Lock()
+	tw.sw.shift()
+	tw.sw.Unlock()
+
+	tw.Add("10.0.0.2", 40)
+	tw.Add("10.0.0.3", 30)
+
+	assert.Equal(t, []KeyCount{{"10.0.0.2", 40}, {"10.0.0.3", 30}}, tw.TopK(time.Second))
+	assert.Equal(t, []KeyCount{{"10.0.0.2", 60}, {"10.0.0.1", 50}}, tw.TopK(3*time.Second))
+
+	// The first bucket ages out, and with it the contributions of 10.0.0.1.
+	tw.sw.Lock()
+	tw.sw.shift()
+	tw.sw.shift()
+	tw.sw.Unlock()
+
+	assert.Equal(t, []KeyCount{{"10.0.0.2", 40}, {"10.0.0.3", 30}}, tw.TopK(3*time.Second))
+}
+
+func TestTopKBucketEviction(t *testing.T) {
+	b := &topKBucket{candidates: candidates{index: make(map[string]int)}}
+
+	b.add("a", hashString("a"), 1, 2)
+	b.add("b", hashString("b"), 2, 2)
+	b.add("c", hashString("c"), 5, 2)
+
+	assert.Equal(t, 2, b.candidates.Len())
+	_, ok := b.candidates.index["a"]
+	assert.False(t, ok)
+	assert.Equal(t, int64(2), b.candidates.items[0].Count)
+
+	b.reset()
+	assert.Equal(t, 0, b.candidates.Len())
+	assert.Equal(t, int64(0), b.sketch.estimate(hashString("c")))
+}
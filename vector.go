@@ -0,0 +1,167 @@
+package average
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUnknownField is returned when a VectorWindow doesn't have a field with the
+// specified name.
+var ErrUnknownField = errors.New("unknown field")
+
+// VectorWindow is a sliding time window for a fixed set of named series that
+// share their buckets, rotation and lock. Tracking related metrics this way
+// costs a single goroutine instead of one per series, and guarantees that all
+// series rotate at the exact same moment.
+type VectorWindow struct {
+	sw      *SlidingWindow
+	fields  []string
+	index   map[string]int
+	samples [][]float64
+	counts  [][]int64
+}
+
+// MustNewVectorWindow returns a new VectorWindow, but panics if an error
+// occurs.
+func MustNewVectorWindow(window, granularity time.Duration, fields ...string) *VectorWindow {
+	vw, err := NewVectorWindow(window, granularity, fields...)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return vw
+}
+
+// NewVectorWindow returns a new VectorWindow with the specified fields.
+func NewVectorWindow(window, granularity time.Duration, fields ...string) (*VectorWindow, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("at least one field is required")
+	}
+
+	index := make(map[string]int, len(fields))
+	for i, field := range fields {
+		if _, ok := index[field]; ok {
+			return nil, errors.New("duplicate field " + field)
+		}
+		index[field] = i
+	}
+
+	sw, err := newSlidingWindow(window, granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	vw := &VectorWindow{
+		sw:      sw,
+		fields:  append([]string(nil), fields...),
+		index:   index,
+		samples: make([][]float64, len(fields)),
+		counts:  make([][]int64, len(fields)),
+	}
+	for i := range fields {
+		vw.samples[i] = make([]float64, sw.len())
+		vw.counts[i] = make([]int64, sw.len())
+	}
+	sw.onClear = vw.clear
+
+	sw.startShifter()
+	return vw, nil
+}
+
+func (vw *VectorWindow) clear(pos int) {
+	for i := range vw.fields {
+		vw.samples[i][pos] = 0
+		vw.counts[i][pos] = 0
+	}
+}
+
+// Fields returns the names of the fields of this window.
+func (vw *VectorWindow) Fields() []string {
+	return append([]string(nil), vw.fields...)
+}
+
+// Add increments the value of the current sample of the specified field. It
+// returns ErrUnknownField if the window doesn't have the field, and ErrStopped
+// if the window is stopped.
+func (vw *VectorWindow) Add(field string, v float64) error {
+	i, ok := vw.index[field]
+	if !ok {
+		return ErrUnknownField
+	}
+
+	vw.sw.Lock()
+	defer vw.sw.Unlock()
+
+	if !vw.sw.countAdd() {
+		return ErrStopped
+	}
+
+	vw.samples[i][vw.sw.pos] += v
+	vw.counts[i][vw.sw.pos]++
+	return nil
+}
+
+// AddValues increments the current samples of all fields at once, in the order
+// in which the fields were specified at creation. Superfluous values are
+// ignored.
+func (vw *VectorWindow) AddValues(values ...float64) {
+	if len(values) > len(vw.fields) {
+		values = values[:len(vw.fields)]
+	}
+
+	vw.sw.Lock()
+	defer vw.sw.Unlock()
+
+	if !vw.sw.countAdd() {
+		return
+	}
+
+	for i, v := range values {
+		vw.samples[i][vw.sw.pos] += v
+		vw.counts[i][vw.sw.pos]++
+	}
+}
+
+// Average returns the unweighted mean of the specified field over the specified
+// window.
+func (vw *VectorWindow) Average(field string, window time.Duration) (float64, error) {
+	total, count, err := vw.Total(field, window)
+	if err != nil || count == 0 {
+		return 0, err
+	}
+
+	return total / float64(count), nil
+}
+
+// Total returns the sum of all values of the specified field over the specified
+// window, as well as the number of samples.
+func (vw *VectorWindow) Total(field string, window time.Duration) (float64, int64, error) {
+	i, ok := vw.index[field]
+	if !ok {
+		return 0, 0, ErrUnknownField
+	}
+
+	vw.sw.RLock()
+	defer vw.sw.RUnlock()
+
+	var total float64
+	var count int64
+	for age, n := 0, vw.sw.buckets(window); age < n; age++ {
+		pos := vw.sw.index(age)
+		total += vw.samples[i][pos]
+		count += vw.counts[i][pos]
+	}
+
+	return total, count, nil
+}
+
+// Debug returns the DebugStats of this vector window.
+func (vw *VectorWindow) Debug() DebugStats {
+	return vw.sw.Debug()
+}
+
+// Stop the shifter of this vector window. A stopped VectorWindow cannot be
+// started again.
+func (vw *VectorWindow) Stop() {
+	vw.sw.Stop()
+}
@@ -0,0 +1,73 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewVectorWindow(t *testing.T) {
+	_, err := NewVectorWindow(10*time.Second, time.Second)
+	assert.EqualError(t, err, "at least one field is required")
+
+	_, err = NewVectorWindow(10*time.Second, time.Second, "rx", "tx", "rx")
+	assert.EqualError(t, err, "duplicate field rx")
+
+	_, err = NewVectorWindow(time.Second, time.Second, "rx")
+	assert.EqualError(t, err, "window size has to be a multiplier of the granularity size")
+}
+
+func TestVectorWindow(t *testing.T) {
+	vw := MustNewVectorWindow(3*time.Second, time.Second, "rx", "tx")
+	defer vw.Stop()
+
+	assert.Equal(t, []string{"rx", "tx"}, vw.Fields())
+	assert.Equal(t, ErrUnknownField, vw.Add("rtt", 1))
+
+	assert.NoError(t, vw.Add("rx", 10))
+	assert.NoError(t, vw.Add("rx", 20))
+	vw.AddValues(1, 2, 3)
+
+	vw.sw.Lock()
+	vw.sw.shift()
+	vw.sw.Unlock()
+	vw.AddValues(4, 6)
+
+	total, count, err := vw.Total("rx", 3*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 35.0, total)
+	assert.Equal(t, int64(4), count)
+
+	avg, err := vw.Average("tx", 3*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 4.0, avg)
+
+	avg, err = vw.Average("tx", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 6.0, avg)
+
+	_, err = vw.Average("rtt", time.Second)
+	assert.Equal(t, ErrUnknownField, err)
+
+	vw.sw.Lock()
+	vw.sw.shift()
+	vw.sw.shift()
+	vw.sw.Unlock()
+
+	total, count, _ = vw.Total("rx", 3*time.Second)
+	assert.Equal(t, 4.0, total)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestVectorWindowStopped(t *testing.T) {
+	vw := MustNewVectorWindow(4*time.Second, time.Second, "a", "b")
+	vw.Stop()
+
+	assert.Equal(t, ErrStopped, vw.Add("a", 1))
+	vw.AddValues(1, 2)
+
+	_, count, err := vw.Total("a", 4*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+}
@@ -0,0 +1,209 @@
+package average
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WAL is an append-only log of completed buckets, for audits that require an
+// immutable record of every bucket of a window. Every bucket is written as a
+// line with its start, end, sum and sample count, like
+// "2024-01-01T00:00:00Z 2024-01-01T00:00:01Z 12.5 3".
+type WAL struct {
+	mu      sync.Mutex
+	w       io.Writer
+	file    *os.File
+	path    string
+	maxSize int64 // The size at which the file is rotated, or 0.
+	size    int64
+	fsync   bool
+	err     error
+}
+
+// WALOption configures optional behaviour of a WAL that writes to a file.
+type WALOption func(*WAL) error
+
+// WithWALMaxSize rotates the file of a WAL once it would grow beyond size
+// bytes. The full file is renamed to its path with the time of the rotation
+// appended, like "buckets.log.20240101T000000.000000000", and a new file is
+// started.
+func WithWALMaxSize(size int64) WALOption {
+	return func(l *WAL) error {
+		if size < 1 {
+			return errors.New("maximum size has to be at least 1")
+		}
+
+		l.maxSize = size
+		return nil
+	}
+}
+
+// WithWALSync flushes the file of a WAL to stable storage after every bucket.
+func WithWALSync() WALOption {
+	return func(l *WAL) error {
+		l.fsync = true
+		return nil
+	}
+}
+
+// NewWAL returns a WAL that writes to w.
+func NewWAL(w io.Writer) *WAL {
+	return &WAL{w: w}
+}
+
+// OpenWAL returns a WAL that appends to the file at path, which is created if
+// it doesn't exist.
+func OpenWAL(path string, opts ...WALOption) (*WAL, error) {
+	l := &WAL{path: path}
+	for _, opt := range opts {
+		if err := opt(l); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// WithWAL appends every bucket of the window to l once it stops being the
+// current bucket, in the order the buckets were completed, and the current
+// bucket once the window is stopped. Empty buckets are not written. Like the
+// OnEvict function, l is written to after the lock of the window was
+// released. Errors are kept until they are read with the Err method of the
+// WAL.
+func WithWAL(l *WAL) Option {
+	return func(sw *SlidingWindow) error {
+		if l == nil {
+			return errors.New("wal cannot be nil")
+		}
+
+		sw.wal = l
+		return nil
+	}
+}
+
+// open opens the file of this WAL for appending.
+func (l *WAL) open() error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	l.file, l.w, l.size = f, f, info.Size()
+	return nil
+}
+
+// Append writes b to the log. Once writing fails, the WAL keeps returning the
+// same error.
+func (l *WAL) Append(b BucketResult) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.append(b)
+}
+
+// append writes b to the log like Append. It must be called with the lock
+// held.
+func (l *WAL) append(b BucketResult) error {
+	line := make([]byte, 0, 96)
+	line = b.Start.AppendFormat(line, time.RFC3339Nano)
+	line = append(line, ' ')
+	line = b.End.AppendFormat(line, time.RFC3339Nano)
+	line = append(line, ' ')
+	line = strconv.AppendFloat(line, b.Sum, 'g', -1, 64)
+	line = append(line, ' ')
+	line = strconv.AppendInt(line, b.Count, 10)
+	line = append(line, '\n')
+
+	if l.err != nil {
+		return l.err
+	}
+
+	l.err = l.write(line)
+	return l.err
+}
+
+// write writes line to the log, rotating its file first if necessary. It must
+// be called with the lock held.
+func (l *WAL) write(line []byte) error {
+	if l.file != nil && l.maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := l.w.Write(line)
+	l.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	if l.file != nil && l.fsync {
+		return l.file.Sync()
+	}
+
+	return nil
+}
+
+// rotate moves the current file aside and starts a new one. It must be called
+// with the lock held.
+func (l *WAL) rotate() error {
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+
+	suffix := time.Now().UTC().Format("20060102T150405.000000000")
+	if err := os.Rename(l.path, l.path+"."+suffix); err != nil {
+		return err
+	}
+
+	return l.open()
+}
+
+// Err returns the first error that occurred while writing to the log.
+func (l *WAL) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.err
+}
+
+// Close closes the file of a WAL that was returned by OpenWAL. It has no
+// effect on a WAL that writes to an io.Writer.
+func (l *WAL) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+
+	err := l.file.Close()
+	l.file = nil
+	if l.err == nil {
+		l.err = errors.New("wal is closed")
+	}
+
+	return err
+}
+
+// seal queues the completed bucket b for the WAL, unless it's empty. It must
+// be called with the lock held.
+func (sw *SlidingWindow) seal(b BucketResult) {
+	if b.Count != 0 || b.Sum != 0 {
+		sw.sealed = append(sw.sealed, b)
+	}
+}
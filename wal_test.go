@@ -0,0 +1,157 @@
+package average
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithWAL(t *testing.T) {
+	var buf bytes.Buffer
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	sw := MustNewReplay(3*time.Second, time.Second, start, WithWAL(NewWAL(&buf)))
+	defer sw.Stop()
+
+	sw.AddN(12.5, 3)
+	sw.AddAt(start.Add(500*time.Millisecond), 1)
+	assert.Empty(t, buf.String())
+
+	// Empty buckets are not written.
+	sw.AddAt(start.Add(2*time.Second), 0.25)
+	sw.AdvanceTo(start.Add(3 * time.Second))
+	assert.Equal(t, "2024-03-01T12:00:00Z 2024-03-01T12:00:01Z 13.5 4\n"+
+		"2024-03-01T12:00:02Z 2024-03-01T12:00:03Z 0.25 1\n", buf.String())
+}
+
+func TestWithWALStop(t *testing.T) {
+	var buf bytes.Buffer
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	sw := MustNewReplay(3*time.Second, time.Second, start, WithWAL(NewWAL(&buf)))
+	sw.AddAt(start.Add(1500*time.Millisecond), 2)
+	assert.Empty(t, buf.String())
+
+	// The current bucket is written up to the time the window was stopped.
+	s := sw.StopAndSnapshot()
+	assert.Equal(t, []float64{2, 0}, s.Samples)
+	assert.Equal(t, "2024-03-01T12:00:01Z 2024-03-01T12:00:01.5Z 2 1\n", buf.String())
+
+	sw.Stop()
+	assert.Equal(t, "2024-03-01T12:00:01Z 2024-03-01T12:00:01.5Z 2 1\n", buf.String())
+
+	// Windows with a shifter write the current bucket as well.
+	buf.Reset()
+	sw = MustNew(time.Minute, 30*time.Second, WithWAL(NewWAL(&buf)))
+	sw.Add(1)
+	sw.Stop()
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+	assert.True(t, strings.HasSuffix(buf.String(), " 1 1\n"))
+}
+
+func TestWithWALOrder(t *testing.T) {
+	var buf bytes.Buffer
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	sw := MustNewReplay(3*time.Second, time.Second, start, WithWAL(NewWAL(&buf)))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 250; j++ {
+				sw.AddAt(start.Add(time.Duration(j*4+i)*time.Second), 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	sw.Stop()
+
+	var prev string
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.True(t, len(lines) >= 250, len(lines))
+	for _, line := range lines {
+		if !assert.True(t, prev < line, "%q appended after %q", line, prev) {
+			break
+		}
+		prev = line
+	}
+}
+
+func TestWithWALNil(t *testing.T) {
+	_, err := New(3*time.Second, time.Second, WithWAL(nil))
+	assert.EqualError(t, err, "wal cannot be nil")
+}
+
+func TestWALErr(t *testing.T) {
+	err := errors.New("disk full")
+	l := NewWAL(failingWriter{err})
+	assert.NoError(t, l.Err())
+
+	b := BucketResult{Sum: 1, Count: 1}
+	assert.Equal(t, err, l.Append(b))
+	assert.Equal(t, err, l.Append(b))
+	assert.Equal(t, err, l.Err())
+}
+
+func TestOpenWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "buckets.log")
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := BucketResult{Sum: 2, Count: 1, Start: start, End: start.Add(time.Second)}
+	line := "2024-03-01T12:00:00Z 2024-03-01T12:00:01Z 2 1\n"
+
+	l, err := OpenWAL(path, WithWALSync())
+	assert.NoError(t, err)
+	assert.NoError(t, l.Append(b))
+	assert.NoError(t, l.Close())
+	assert.EqualError(t, l.Append(b), "wal is closed")
+
+	// An existing file is appended to.
+	l, err = OpenWAL(path)
+	assert.NoError(t, err)
+	assert.NoError(t, l.Append(b))
+	assert.NoError(t, l.Close())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, line+line, string(data))
+}
+
+func TestWithWALMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "buckets.log")
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := BucketResult{Sum: 2, Count: 1, Start: start, End: start.Add(time.Second)}
+	line := "2024-03-01T12:00:00Z 2024-03-01T12:00:01Z 2 1\n"
+
+	l, err := OpenWAL(path, WithWALMaxSize(int64(2*len(line))))
+	assert.NoError(t, err)
+	defer l.Close()
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, l.Append(b))
+	}
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, line, string(data))
+
+	rotated, err := filepath.Glob(path + ".*")
+	assert.NoError(t, err)
+	if assert.Len(t, rotated, 1) {
+		data, err = os.ReadFile(rotated[0])
+		assert.NoError(t, err)
+		assert.Equal(t, line+line, string(data))
+	}
+
+	_, err = OpenWAL(path, WithWALMaxSize(0))
+	assert.EqualError(t, err, "maximum size has to be at least 1")
+}
@@ -0,0 +1,64 @@
+package average
+
+import (
+	"math"
+	"time"
+)
+
+// WeightFunc returns the weight of a bucket in a weighted average, where age
+// is the number of buckets it is older than the current one and n is the
+// number of buckets that make up the average.
+type WeightFunc func(age, n int) float64
+
+// LinearWeights weighs the buckets linearly by their age. The current bucket
+// has a weight of n and the oldest one a weight of 1.
+func LinearWeights(age, n int) float64 {
+	return float64(n - age)
+}
+
+// ExponentialWeights returns a WeightFunc that multiplies the weight of every
+// bucket by decay compared to the next newer one. A decay of 0.5 halves the
+// influence of a bucket with every rotation.
+func ExponentialWeights(decay float64) WeightFunc {
+	return func(age, n int) float64 {
+		return math.Pow(decay, float64(age))
+	}
+}
+
+// WeightedAverage returns the mean of the specified window, where the values
+// and sample counts of every bucket are multiplied by the weight that weights
+// returns for it. This gives recent buckets more influence than older ones
+// without ignoring the older ones altogether. A nil WeightFunc weighs all
+// buckets equally. For windows created WithoutCounts and gauges, this is the
+// weighted mean of the buckets.
+func (sw *SlidingWindow) WeightedAverage(window time.Duration, weights WeightFunc) float64 {
+	sw.RLock()
+	defer sw.RUnlock()
+
+	var total, divisor float64
+	n := sw.buckets(window)
+	for i := 0; i < n; i++ {
+		w := 1.0
+		if weights != nil {
+			w = weights(i, n)
+		}
+
+		pos := sw.index(i)
+		if sw.valid != nil && !sw.valid[pos] || sw.open(i) {
+			continue
+		}
+
+		total += w * sw.sum(pos)
+		if sw.hasCounts() && sw.valid == nil {
+			divisor += w * float64(sw.count(pos))
+		} else {
+			divisor += w
+		}
+	}
+
+	if divisor == 0 {
+		return 0
+	}
+
+	return total / divisor
+}
@@ -0,0 +1,43 @@
+package average
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightedAverage(t *testing.T) {
+	sw := &SlidingWindow{
+		window:      4 * time.Second,
+		granularity: time.Second,
+		samples:     []float64{10, 20, 0, 4},
+		counts:      []int64{1, 1, 0, 2},
+		pos:         1,
+		size:        4,
+	}
+
+	assert.Equal(t, 0.0, sw.WeightedAverage(0, LinearWeights))
+	assert.Equal(t, sw.Average(4*time.Second), sw.WeightedAverage(4*time.Second, nil))
+
+	// Weights 4, 3, 2 and 1 from the newest bucket to the oldest.
+	assert.Equal(t, (4*20+3*10+2*4)/(4*1+3*1+2*2.0), sw.WeightedAverage(4*time.Second, LinearWeights))
+	assert.Equal(t, (20+0.5*10+0.25*4)/(1+0.5+0.25*2), sw.WeightedAverage(4*time.Second, ExponentialWeights(0.5)))
+}
+
+func TestWeightedAverageWithoutCounts(t *testing.T) {
+	sw := &SlidingWindow{
+		window:      3 * time.Second,
+		granularity: time.Second,
+		samples:     []float64{3, 6, 9},
+		pos:         2,
+		size:        3,
+	}
+
+	assert.Equal(t, (3*9+2*6+1*3)/6.0, sw.WeightedAverage(3*time.Second, LinearWeights))
+}
+
+func TestLinearWeights(t *testing.T) {
+	assert.Equal(t, 3.0, LinearWeights(0, 3))
+	assert.Equal(t, 1.0, LinearWeights(2, 3))
+}
@@ -0,0 +1,14 @@
+package average
+
+import "time"
+
+// Window is the API that sliding time windows have in common, so that a local
+// SlidingWindow and a window that is shared between processes can be used
+// interchangeably.
+type Window interface {
+	Add(v float64)
+	Average(window time.Duration) float64
+	Total(window time.Duration) (float64, int64)
+}
+
+var _ Window = (*SlidingWindow)(nil)